@@ -16,4 +16,28 @@ type Config struct {
 		Password string `json:"password"`
 		Database string `json:"database"`
 	} `json:"database"`
+
+	// 用户认证配置，提现接口按 JWT 中的 accountId 确定账户
+	Auth struct {
+		AccessSecret string `json:"AccessSecret,optional"` // HS256 签名密钥，为空时不注册提现接口
+	} `json:"Auth,optional"`
+
+	// 管理接口配置
+	Admin struct {
+		Token string `json:"Token,optional"` // 请求头 X-Admin-Token 需与之一致，为空时管理接口全部拒绝
+	} `json:"Admin,optional"`
+
+	// 提现风控配置
+	Risk struct {
+		Rules []RiskRule `json:"Rules,optional"` // 未配置规则的币种不做风控检查
+	} `json:"Risk,optional"`
+}
+
+// RiskRule 单个币种的提现风控规则，触发任一规则的提现进入人工审核
+type RiskRule struct {
+	Coin               string `json:"Coin"`
+	MaxPerTx           string `json:"MaxPerTx,optional"`           // 单笔金额上限，为空不限制
+	MaxDaily           string `json:"MaxDaily,optional"`           // 同一账户滚动 24 小时累计金额上限（含本笔），为空不限制
+	MaxHourlyCount     int64  `json:"MaxHourlyCount,optional"`     // 同一账户 1 小时内提现笔数上限（含本笔），0 不限制
+	NewAddressCooldown int64  `json:"NewAddressCooldown,optional"` // 秒，账户首次向某地址提现后的冷却时间，期间向该地址的提现需审核，0 不限制
 }
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"go_bullayer_v1/api/internal/logic"
+	"go_bullayer_v1/api/internal/svc"
+	"go_bullayer_v1/api/internal/types"
+	"go_bullayer_v1/base/pkg/common"
+
+	"github.com/zeromicro/go-zero/rest"
+	"github.com/zeromicro/go-zero/rest/httpx"
+)
+
+// adminTokenHeader 管理员令牌请求头
+const adminTokenHeader = "X-Admin-Token"
+
+// AdminAuthMiddleware 校验管理员令牌
+// token: 配置的管理员令牌，为空时拒绝所有请求
+// 返回中间件
+func AdminAuthMiddleware(token string) rest.Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			got := r.Header.Get(adminTokenHeader)
+			if token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				httpx.WriteJsonCtx(r.Context(), w, http.StatusUnauthorized,
+					common.ErrorResponse(common.ErrCodeUnauthorized, "管理员令牌无效"))
+				return
+			}
+			next(w, r)
+		}
+	}
+}
+
+// WithdrawReviewListHandler 待审核提现列表处理器
+// ctx: 服务上下文
+// 返回 HTTP 处理器函数
+func WithdrawReviewListHandler(ctx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req types.WithdrawReviewListRequest
+		// 解析分页参数
+		if err := httpx.Parse(r, &req); err != nil {
+			httpx.ErrorCtx(r.Context(), w, err)
+			return
+		}
+
+		l := logic.NewWithdrawReviewLogic(r.Context(), ctx)
+		resp, err := l.ListReviews(&req)
+		if err != nil {
+			httpx.ErrorCtx(r.Context(), w, err)
+		} else {
+			httpx.OkJsonCtx(r.Context(), w, resp)
+		}
+	}
+}
+
+// WithdrawApproveHandler 提现审核通过处理器
+// ctx: 服务上下文
+// 返回 HTTP 处理器函数
+func WithdrawApproveHandler(ctx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req types.WithdrawReviewRequest
+		// 解析路径参数
+		if err := httpx.Parse(r, &req); err != nil {
+			httpx.ErrorCtx(r.Context(), w, err)
+			return
+		}
+
+		l := logic.NewWithdrawReviewLogic(r.Context(), ctx)
+		resp, err := l.Approve(&req)
+		if err != nil {
+			httpx.ErrorCtx(r.Context(), w, err)
+		} else {
+			httpx.OkJsonCtx(r.Context(), w, resp)
+		}
+	}
+}
+
+// WithdrawRejectHandler 提现审核拒绝处理器
+// ctx: 服务上下文
+// 返回 HTTP 处理器函数
+func WithdrawRejectHandler(ctx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req types.WithdrawReviewRequest
+		// 解析路径参数
+		if err := httpx.Parse(r, &req); err != nil {
+			httpx.ErrorCtx(r.Context(), w, err)
+			return
+		}
+
+		l := logic.NewWithdrawReviewLogic(r.Context(), ctx)
+		resp, err := l.Reject(&req)
+		if err != nil {
+			httpx.ErrorCtx(r.Context(), w, err)
+		} else {
+			httpx.OkJsonCtx(r.Context(), w, resp)
+		}
+	}
+}
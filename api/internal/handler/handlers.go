@@ -6,6 +6,7 @@ import (
 	"go_bullayer_v1/api/internal/logic"
 	"go_bullayer_v1/api/internal/svc"
 	"go_bullayer_v1/api/internal/types"
+	"go_bullayer_v1/base/pkg/logger"
 
 	"github.com/zeromicro/go-zero/rest"
 	"github.com/zeromicro/go-zero/rest/httpx"
@@ -29,6 +30,48 @@ func RegisterHandlers(server *rest.Server, ctx *svc.ServiceContext) {
 			},
 		},
 	)
+
+	// 提现接口，需携带 JWT（Authorization: Bearer），账户取自令牌中的 accountId
+	if ctx.Config.Auth.AccessSecret == "" {
+		logger.Error("未配置 Auth.AccessSecret，提现接口未注册")
+	} else {
+		server.AddRoutes(
+			[]rest.Route{
+				{
+					Method:  http.MethodPost,
+					Path:    "/api/v1/bridge/withdraw",
+					Handler: WithdrawHandler(ctx),
+				},
+				{
+					Method:  http.MethodGet,
+					Path:    "/api/v1/bridge/withdraw/history",
+					Handler: WithdrawHistoryHandler(ctx),
+				},
+			},
+			rest.WithJwt(ctx.Config.Auth.AccessSecret),
+		)
+	}
+
+	// 管理接口，需携带管理员令牌
+	server.AddRoutes(
+		rest.WithMiddleware(AdminAuthMiddleware(ctx.Config.Admin.Token),
+			rest.Route{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/admin/withdraw/reviews",
+				Handler: WithdrawReviewListHandler(ctx),
+			},
+			rest.Route{
+				Method:  http.MethodPost,
+				Path:    "/api/v1/admin/withdraw/:id/approve",
+				Handler: WithdrawApproveHandler(ctx),
+			},
+			rest.Route{
+				Method:  http.MethodPost,
+				Path:    "/api/v1/admin/withdraw/:id/reject",
+				Handler: WithdrawRejectHandler(ctx),
+			},
+		),
+	)
 }
 
 // HealthHandler 健康检查处理器
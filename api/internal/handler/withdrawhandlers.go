@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"go_bullayer_v1/api/internal/logic"
+	"go_bullayer_v1/api/internal/svc"
+	"go_bullayer_v1/api/internal/types"
+	"go_bullayer_v1/base/pkg/common"
+
+	"github.com/zeromicro/go-zero/rest/httpx"
+)
+
+// accountIDClaim JWT 中账户ID的字段名，go-zero 校验令牌后将自定义字段写入请求上下文
+const accountIDClaim = "accountId"
+
+// accountIDFromContext 读取已校验令牌中的账户ID
+func accountIDFromContext(ctx context.Context) (int64, bool) {
+	// 令牌按 json.Number 解析数字字段
+	v, ok := ctx.Value(accountIDClaim).(json.Number)
+	if !ok {
+		return 0, false
+	}
+	id, err := v.Int64()
+	return id, err == nil && id > 0
+}
+
+// writeUnauthorized 返回账户未认证
+func writeUnauthorized(w http.ResponseWriter, r *http.Request) {
+	httpx.WriteJsonCtx(r.Context(), w, http.StatusUnauthorized,
+		common.ErrorResponse(common.ErrCodeUnauthorized, "令牌中缺少有效的账户ID"))
+}
+
+// WithdrawHandler 提现申请处理器
+// ctx: 服务上下文
+// 返回 HTTP 处理器函数
+func WithdrawHandler(ctx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accountID, ok := accountIDFromContext(r.Context())
+		if !ok {
+			writeUnauthorized(w, r)
+			return
+		}
+
+		var req types.WithdrawRequest
+		// 解析请求体
+		if err := httpx.Parse(r, &req); err != nil {
+			httpx.ErrorCtx(r.Context(), w, err)
+			return
+		}
+
+		l := logic.NewWithdrawLogic(r.Context(), ctx)
+		resp, err := l.Withdraw(accountID, &req)
+		if err != nil {
+			httpx.ErrorCtx(r.Context(), w, err)
+		} else {
+			httpx.OkJsonCtx(r.Context(), w, resp)
+		}
+	}
+}
+
+// WithdrawHistoryHandler 提现记录处理器
+// ctx: 服务上下文
+// 返回 HTTP 处理器函数
+func WithdrawHistoryHandler(ctx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accountID, ok := accountIDFromContext(r.Context())
+		if !ok {
+			writeUnauthorized(w, r)
+			return
+		}
+
+		var req types.WithdrawHistoryRequest
+		// 解析分页参数
+		if err := httpx.Parse(r, &req); err != nil {
+			httpx.ErrorCtx(r.Context(), w, err)
+			return
+		}
+
+		l := logic.NewWithdrawLogic(r.Context(), ctx)
+		resp, err := l.History(accountID, &req)
+		if err != nil {
+			httpx.ErrorCtx(r.Context(), w, err)
+		} else {
+			httpx.OkJsonCtx(r.Context(), w, resp)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go_bullayer_v1/api/internal/svc"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/zeromicro/go-zero/rest/handler"
+)
+
+const testSecret = "test-access-secret"
+
+func signToken(t *testing.T, secret string, claims jwt.MapClaims) string {
+	t.Helper()
+	claims["exp"] = time.Now().Add(time.Hour).Unix()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestWithdrawHistoryHandler_RequiresAccountToken(t *testing.T) {
+	h := handler.Authorize(testSecret)(WithdrawHistoryHandler(&svc.ServiceContext{}))
+
+	cases := []struct {
+		name   string
+		header map[string]string
+		want   int
+	}{
+		// 客户端自带的 X-Account-Id 不再被信任
+		{"header only", map[string]string{"X-Account-Id": "1"}, http.StatusUnauthorized},
+		{"wrong secret", map[string]string{"Authorization": "Bearer " + signToken(t, "other", jwt.MapClaims{"accountId": 1})}, http.StatusUnauthorized},
+		{"missing claim", map[string]string{"Authorization": "Bearer " + signToken(t, testSecret, jwt.MapClaims{})}, http.StatusUnauthorized},
+		{"invalid claim", map[string]string{"Authorization": "Bearer " + signToken(t, testSecret, jwt.MapClaims{"accountId": "abc"})}, http.StatusUnauthorized},
+		// 认证通过后进入业务逻辑，测试中未配置数据库
+		{"valid token", map[string]string{"Authorization": "Bearer " + signToken(t, testSecret, jwt.MapClaims{"accountId": 7})}, http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/bridge/withdraw/history?page=1&pageSize=20", nil)
+		for k, v := range c.header {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != c.want {
+			t.Fatalf("%s: status = %d, want %d, body %s", c.name, rec.Code, c.want, rec.Body.String())
+		}
+	}
+}
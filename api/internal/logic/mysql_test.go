@@ -0,0 +1,65 @@
+package logic
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// testDSNEnv 集成测试使用的 MySQL DSN，未设置时跳过。
+// 测试会重建 bullayer_test_data.sql 中的全部表，只能指向专用的测试库。
+const testDSNEnv = "BULLAYER_TEST_MYSQL_DSN"
+
+// openTestDB 连接测试库并按 bullayer_test_data.sql 重建表结构
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s 未设置，跳过 MySQL 集成测试", testDSNEnv)
+	}
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		t.Fatalf("parse %s failed: %v", testDSNEnv, err)
+	}
+	cfg.ParseTime = true
+	cfg.Loc = time.Local
+	cfg.MultiStatements = true
+
+	db, err := sql.Open("mysql", cfg.FormatDSN())
+	if err != nil {
+		t.Fatalf("open mysql failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	schema, err := os.ReadFile("../../../bullayer_test_data.sql")
+	if err != nil {
+		t.Fatalf("read schema failed: %v", err)
+	}
+	if _, err := db.ExecContext(context.Background(), string(schema)); err != nil {
+		t.Fatalf("load schema failed: %v", err)
+	}
+	return db
+}
+
+// mustExec 执行测试数据准备语句
+func mustExec(t *testing.T, db *sql.DB, query string, args ...interface{}) {
+	t.Helper()
+	if _, err := db.Exec(query, args...); err != nil {
+		t.Fatalf("exec %q failed: %v", query, err)
+	}
+}
+
+// assetBalance 查询账户资产的可用和冻结金额
+func assetBalance(t *testing.T, db *sql.DB, accountID int64, coin string) (available, freeze string) {
+	t.Helper()
+	err := db.QueryRow("SELECT available, freeze FROM user_assets WHERE account_id = ? AND coin = ?", accountID, coin).
+		Scan(&available, &freeze)
+	if err != nil {
+		t.Fatalf("query user_assets failed: %v", err)
+	}
+	return available, freeze
+}
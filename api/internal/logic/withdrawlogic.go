@@ -0,0 +1,205 @@
+package logic
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"go_bullayer_v1/api/internal/risk"
+	"go_bullayer_v1/api/internal/store"
+	"go_bullayer_v1/api/internal/svc"
+	"go_bullayer_v1/api/internal/types"
+	"go_bullayer_v1/base/pkg/common"
+	"go_bullayer_v1/base/pkg/db"
+	"go_bullayer_v1/base/pkg/logger"
+	"go_bullayer_v1/base/pkg/utils"
+)
+
+// amountDecimals transactions 和 user_assets 金额字段 DECIMAL(36,18) 的小数位数
+const amountDecimals = 18
+
+var (
+	// hexAddressPattern 以太坊地址格式
+	hexAddressPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+	// maxAmountUnits DECIMAL(36,18) 可表示的金额上限（不含），以最小单位计
+	maxAmountUnits = new(big.Int).Exp(big.NewInt(10), big.NewInt(36), nil)
+)
+
+// WithdrawLogic 提现申请业务逻辑
+type WithdrawLogic struct {
+	ctx    context.Context     // 上下文
+	svcCtx *svc.ServiceContext // 服务上下文
+}
+
+// NewWithdrawLogic 创建提现逻辑处理器
+// ctx: 上下文
+// svcCtx: 服务上下文
+// 返回提现逻辑处理器实例
+func NewWithdrawLogic(ctx context.Context, svcCtx *svc.ServiceContext) *WithdrawLogic {
+	return &WithdrawLogic{
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// Withdraw 提交提现申请
+// 在同一事务内将 amount + fee 从可用余额转入冻结、执行风控评估并写入提现记录，
+// 触发风控规则的提现进入待审核，否则等待提现执行任务广播
+// accountID: 认证令牌中的账户ID
+// req: 提现申请
+// 返回提现记录和错误信息
+func (l *WithdrawLogic) Withdraw(accountID int64, req *types.WithdrawRequest) (resp *types.WithdrawResponse, err error) {
+	if l.svcCtx.DB == nil {
+		return nil, common.NewError(common.ErrCodeInternal, "数据库未配置")
+	}
+	if accountID <= 0 {
+		return nil, common.NewError(common.ErrCodeUnauthorized, "账户ID无效")
+	}
+	coin := strings.ToUpper(strings.TrimSpace(req.Coin))
+	to := strings.TrimSpace(req.To)
+	if coin == "" {
+		return nil, common.NewError(common.ErrCodeInvalidParam, "币种不能为空")
+	}
+	if !hexAddressPattern.MatchString(to) {
+		return nil, common.NewError(common.ErrCodeInvalidParam, "接收地址无效")
+	}
+	amount, err := utils.ParseUnits(strings.TrimSpace(req.Amount), amountDecimals)
+	if err != nil || amount.Sign() <= 0 || amount.Cmp(maxAmountUnits) >= 0 {
+		return nil, common.NewError(common.ErrCodeInvalidParam, "提现金额无效")
+	}
+
+	err = db.WithTx(l.ctx, l.svcCtx.DB, func(tx *sql.Tx) error {
+		c, err := store.GetWithdrawCoin(l.ctx, tx, coin)
+		if errors.Is(err, store.ErrCoinNotFound) {
+			return common.NewError(common.ErrCodeInvalidParam, "不支持的币种")
+		}
+		if err != nil {
+			return err
+		}
+		if c.Status != common.CoinStatusEnabled {
+			return common.NewError(common.ErrCodeForbidden, "币种已暂停提现")
+		}
+		// 提现执行任务按币种精度换算链上金额，超出精度的金额无法发送
+		if _, err := utils.ParseUnits(formatAmount(amount), c.Decimals); err != nil {
+			return common.NewError(common.ErrCodeInvalidParam, fmt.Sprintf("提现金额小数位数不能超过 %d 位", c.Decimals))
+		}
+
+		minimum, err := utils.ParseUnits(c.MinWithdraw, amountDecimals)
+		if err != nil {
+			return err
+		}
+		if amount.Cmp(minimum) < 0 {
+			return common.NewError(common.ErrCodeInvalidParam, "提现金额低于最小提现金额 "+formatAmount(minimum))
+		}
+		fee, err := utils.ParseUnits(c.WithdrawFee, amountDecimals)
+		if err != nil {
+			return err
+		}
+		total := new(big.Int).Add(amount, fee)
+		if total.Cmp(maxAmountUnits) >= 0 {
+			return common.NewError(common.ErrCodeInvalidParam, "提现金额无效")
+		}
+
+		// 先冻结余额锁定资产行，同一账户的并发申请在此排队，风控统计不会遗漏
+		frozen, err := store.FreezeAsset(l.ctx, tx, accountID, c.Coin, formatAmount(total))
+		if err != nil {
+			return err
+		}
+		if !frozen {
+			return common.NewError(common.ErrCodeForbidden, "可用余额不足")
+		}
+
+		decision, err := l.svcCtx.Risk.Evaluate(l.ctx, tx, risk.Request{
+			AccountID: accountID,
+			Coin:      c.Coin,
+			Amount:    formatAmount(amount),
+			To:        to,
+		})
+		if err != nil {
+			return err
+		}
+		status := common.TxStatusPending
+		if decision.NeedsReview() {
+			status = common.TxStatusPendingReview
+		}
+
+		id, err := store.InsertWithdrawal(l.ctx, tx, store.NewWithdrawal{
+			AccountID:   accountID,
+			Coin:        c.Coin,
+			CoinAddress: c.CoinAddress,
+			Amount:      formatAmount(amount),
+			Fee:         formatAmount(fee),
+			To:          to,
+			Status:      status,
+			RiskReason:  decision.Reason(),
+		})
+		if err != nil {
+			return err
+		}
+		resp = &types.WithdrawResponse{ID: id, Amount: formatAmount(amount), Fee: formatAmount(fee), Status: status}
+		return nil
+	})
+	if err != nil {
+		var baseErr *common.BaseError
+		if errors.As(err, &baseErr) {
+			return nil, baseErr
+		}
+		logger.Error("账户 %d 提交提现失败: %v", accountID, err)
+		return nil, common.NewError(common.ErrCodeInternal, "提交提现失败")
+	}
+
+	if resp.Status == common.TxStatusPendingReview {
+		logger.Info("账户 %d 提现 %d 触发风控规则，等待审核: %s %s -> %s", accountID, resp.ID, resp.Amount, coin, to)
+	} else {
+		logger.Info("账户 %d 提交提现 %d: %s %s -> %s", accountID, resp.ID, resp.Amount, coin, to)
+	}
+	return resp, nil
+}
+
+// History 分页查询账户的提现记录
+// accountID: 认证令牌中的账户ID
+// req: 分页参数
+// 返回提现记录和错误信息
+func (l *WithdrawLogic) History(accountID int64, req *types.WithdrawHistoryRequest) (resp *types.WithdrawHistoryResponse, err error) {
+	if l.svcCtx.DB == nil {
+		return nil, common.NewError(common.ErrCodeInternal, "数据库未配置")
+	}
+	if accountID <= 0 {
+		return nil, common.NewError(common.ErrCodeUnauthorized, "账户ID无效")
+	}
+	if req.Page <= 0 || req.PageSize <= 0 || req.PageSize > maxPageSize {
+		return nil, common.NewError(common.ErrCodeInvalidParam, "分页参数无效")
+	}
+
+	records, err := store.ListAccountWithdrawals(l.ctx, l.svcCtx.DB, accountID, req.PageSize, (req.Page-1)*req.PageSize)
+	if err != nil {
+		logger.Error("查询账户 %d 提现记录失败: %v", accountID, err)
+		return nil, common.NewError(common.ErrCodeInternal, "查询提现记录失败")
+	}
+
+	resp = &types.WithdrawHistoryResponse{List: make([]types.WithdrawHistoryItem, 0, len(records))}
+	for _, r := range records {
+		resp.List = append(resp.List, types.WithdrawHistoryItem{
+			ID:        r.ID,
+			Coin:      r.Coin,
+			Amount:    r.Amount,
+			Fee:       r.Fee,
+			To:        r.To,
+			TxHash:    r.TxHash,
+			Status:    r.Status,
+			CreatedAt: utils.FormatDateTime(r.CreatedAt),
+		})
+	}
+	return resp, nil
+}
+
+// formatAmount 将最小单位金额格式化为十进制字符串
+func formatAmount(units *big.Int) string {
+	// 输入均由 ParseUnits 得到，格式化不会失败
+	s, _ := utils.FormatUnits(units.String(), amountDecimals)
+	return s
+}
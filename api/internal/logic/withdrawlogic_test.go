@@ -0,0 +1,205 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"go_bullayer_v1/api/internal/config"
+	"go_bullayer_v1/api/internal/risk"
+	"go_bullayer_v1/api/internal/svc"
+	"go_bullayer_v1/api/internal/types"
+	"go_bullayer_v1/base/pkg/common"
+)
+
+const testRecipient = "0x00000000000000000000000000000000000000aa"
+
+// newWithdrawTestContext 准备 USDT 币种配置（6 位精度，最小提现 10，手续费 1）和账户 1 的 100 USDT 可用余额
+func newWithdrawTestContext(t *testing.T, rules []config.RiskRule) *svc.ServiceContext {
+	t.Helper()
+	db := openTestDB(t)
+	mustExec(t, db, `INSERT INTO coin_configs (coin, coin_address, min_deposit, min_withdraw, withdraw_fee, decimals, status)
+		VALUES ('USDT', '0xdac17f958d2ee523a2206206994597c13d831ec7', 1, 10, 1, 6, 1)`)
+	mustExec(t, db, "INSERT INTO user_assets (account_id, coin, total, freeze, available) VALUES (1, 'USDT', 100, 0, 100)")
+
+	engine, err := risk.NewEngine(rules)
+	if err != nil {
+		t.Fatalf("create risk engine failed: %v", err)
+	}
+	return &svc.ServiceContext{DB: db, Risk: engine}
+}
+
+func TestWithdrawFreezesAndReleasesOnReject(t *testing.T) {
+	svcCtx := newWithdrawTestContext(t, []config.RiskRule{{Coin: "USDT", MaxPerTx: "50"}})
+	ctx := context.Background()
+	l := NewWithdrawLogic(ctx, svcCtx)
+
+	// 未触发风控：直接待处理，冻结金额加手续费
+	resp, err := l.Withdraw(1, &types.WithdrawRequest{Coin: "usdt", Amount: "20", To: testRecipient})
+	if err != nil {
+		t.Fatalf("withdraw failed: %v", err)
+	}
+	if resp.Status != common.TxStatusPending || resp.Fee != "1" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if available, freeze := assetBalance(t, svcCtx.DB, 1, "USDT"); available != "79.000000000000000000" || freeze != "21.000000000000000000" {
+		t.Fatalf("unexpected balance after withdraw: available=%s freeze=%s", available, freeze)
+	}
+
+	// 超过单笔上限：进入待审核，同样冻结
+	review, err := l.Withdraw(1, &types.WithdrawRequest{Coin: "USDT", Amount: "60", To: testRecipient})
+	if err != nil {
+		t.Fatalf("withdraw failed: %v", err)
+	}
+	if review.Status != common.TxStatusPendingReview {
+		t.Fatalf("expected pending review, got %+v", review)
+	}
+	var reason string
+	if err := svcCtx.DB.QueryRow("SELECT risk_reason FROM transactions WHERE id = ?", review.ID).Scan(&reason); err != nil {
+		t.Fatal(err)
+	}
+	if reason != risk.ReasonMaxPerTx {
+		t.Fatalf("unexpected risk reason %q", reason)
+	}
+	if available, freeze := assetBalance(t, svcCtx.DB, 1, "USDT"); available != "18.000000000000000000" || freeze != "82.000000000000000000" {
+		t.Fatalf("unexpected balance after review withdraw: available=%s freeze=%s", available, freeze)
+	}
+
+	// 可用余额不足：不写入记录，余额不变
+	_, err = l.Withdraw(1, &types.WithdrawRequest{Coin: "USDT", Amount: "18", To: testRecipient})
+	var baseErr *common.BaseError
+	if !errors.As(err, &baseErr) || baseErr.Code != common.ErrCodeForbidden {
+		t.Fatalf("expected insufficient balance error, got %v", err)
+	}
+	// 低于最小提现金额
+	_, err = l.Withdraw(1, &types.WithdrawRequest{Coin: "USDT", Amount: "9.99", To: testRecipient})
+	if !errors.As(err, &baseErr) || baseErr.Code != common.ErrCodeInvalidParam {
+		t.Fatalf("expected below minimum error, got %v", err)
+	}
+	// 超过 USDT 的 6 位精度：链上无法发送，申请时直接拒绝
+	_, err = l.Withdraw(1, &types.WithdrawRequest{Coin: "USDT", Amount: "10.0000001", To: testRecipient})
+	if !errors.As(err, &baseErr) || baseErr.Code != common.ErrCodeInvalidParam {
+		t.Fatalf("expected precision error, got %v", err)
+	}
+
+	// 审核拒绝：退回冻结资产，不能重复审核
+	reviewLogic := NewWithdrawReviewLogic(ctx, svcCtx)
+	list, err := reviewLogic.ListReviews(&types.WithdrawReviewListRequest{Page: 1, PageSize: 20})
+	if err != nil {
+		t.Fatalf("list reviews failed: %v", err)
+	}
+	if len(list.List) != 1 || list.List[0].ID != review.ID {
+		t.Fatalf("unexpected review list: %+v", list.List)
+	}
+	if _, err := reviewLogic.Reject(&types.WithdrawReviewRequest{ID: review.ID}); err != nil {
+		t.Fatalf("reject failed: %v", err)
+	}
+	if available, freeze := assetBalance(t, svcCtx.DB, 1, "USDT"); available != "79.000000000000000000" || freeze != "21.000000000000000000" {
+		t.Fatalf("unexpected balance after reject: available=%s freeze=%s", available, freeze)
+	}
+	_, err = reviewLogic.Approve(&types.WithdrawReviewRequest{ID: review.ID})
+	if !errors.As(err, &baseErr) || baseErr.Code != common.ErrCodeNotFound {
+		t.Fatalf("expected not found for reviewed withdrawal, got %v", err)
+	}
+
+	history, err := l.History(1, &types.WithdrawHistoryRequest{Page: 1, PageSize: 20})
+	if err != nil {
+		t.Fatalf("history failed: %v", err)
+	}
+	if len(history.List) != 2 || history.List[0].ID != review.ID || history.List[0].Status != common.TxStatusRejected ||
+		history.List[1].ID != resp.ID || history.List[1].Status != common.TxStatusPending {
+		t.Fatalf("unexpected history: %+v", history.List)
+	}
+}
+
+func TestWithdrawApproveKeepsFundsFrozen(t *testing.T) {
+	svcCtx := newWithdrawTestContext(t, []config.RiskRule{{Coin: "USDT", NewAddressCooldown: 3600}})
+	ctx := context.Background()
+
+	resp, err := NewWithdrawLogic(ctx, svcCtx).Withdraw(1, &types.WithdrawRequest{Coin: "USDT", Amount: "10", To: testRecipient})
+	if err != nil {
+		t.Fatalf("withdraw failed: %v", err)
+	}
+	if resp.Status != common.TxStatusPendingReview {
+		t.Fatalf("expected new address review, got %+v", resp)
+	}
+	approved, err := NewWithdrawReviewLogic(ctx, svcCtx).Approve(&types.WithdrawReviewRequest{ID: resp.ID})
+	if err != nil {
+		t.Fatalf("approve failed: %v", err)
+	}
+	if approved.Status != common.TxStatusPending {
+		t.Fatalf("unexpected approve response: %+v", approved)
+	}
+	if available, freeze := assetBalance(t, svcCtx.DB, 1, "USDT"); available != "89.000000000000000000" || freeze != "11.000000000000000000" {
+		t.Fatalf("unexpected balance after approve: available=%s freeze=%s", available, freeze)
+	}
+}
+
+func TestWithdrawPendingReviewDoesNotClearNewAddress(t *testing.T) {
+	svcCtx := newWithdrawTestContext(t, []config.RiskRule{{Coin: "USDT", NewAddressCooldown: 3600}})
+	ctx := context.Background()
+	l := NewWithdrawLogic(ctx, svcCtx)
+
+	first, err := l.Withdraw(1, &types.WithdrawRequest{Coin: "USDT", Amount: "10", To: testRecipient})
+	if err != nil {
+		t.Fatalf("withdraw failed: %v", err)
+	}
+	if first.Status != common.TxStatusPendingReview {
+		t.Fatalf("expected new address review, got %+v", first)
+	}
+
+	// 首笔提现一直待审核、已超过冷却期：地址仍视为新地址
+	mustExec(t, svcCtx.DB, "UPDATE transactions SET created_at = NOW() - INTERVAL 2 HOUR WHERE id = ?", first.ID)
+	second, err := l.Withdraw(1, &types.WithdrawRequest{Coin: "USDT", Amount: "10", To: testRecipient})
+	if err != nil {
+		t.Fatalf("withdraw failed: %v", err)
+	}
+	if second.Status != common.TxStatusPendingReview {
+		t.Fatalf("expected new address review while first withdrawal awaits review, got %+v", second)
+	}
+
+	// 首笔审核通过后按其创建时间计算冷却期
+	if _, err := NewWithdrawReviewLogic(ctx, svcCtx).Approve(&types.WithdrawReviewRequest{ID: first.ID}); err != nil {
+		t.Fatalf("approve failed: %v", err)
+	}
+	third, err := l.Withdraw(1, &types.WithdrawRequest{Coin: "USDT", Amount: "10", To: testRecipient})
+	if err != nil {
+		t.Fatalf("withdraw failed: %v", err)
+	}
+	if third.Status != common.TxStatusPending {
+		t.Fatalf("expected approved address to pass cooldown, got %+v", third)
+	}
+}
+
+func TestWithdrawConcurrentRequestsNeverOverdraw(t *testing.T) {
+	svcCtx := newWithdrawTestContext(t, nil)
+	ctx := context.Background()
+
+	// 100 可用，每笔冻结 31，最多成功 3 笔
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		succeeded int
+	)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := NewWithdrawLogic(ctx, svcCtx).Withdraw(1, &types.WithdrawRequest{Coin: "USDT", Amount: "30", To: testRecipient})
+			if err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if succeeded != 3 {
+		t.Fatalf("expected 3 withdrawals, got %d", succeeded)
+	}
+	if available, freeze := assetBalance(t, svcCtx.DB, 1, "USDT"); available != "7.000000000000000000" || freeze != "93.000000000000000000" {
+		t.Fatalf("unexpected balance: available=%s freeze=%s", available, freeze)
+	}
+}
@@ -0,0 +1,111 @@
+package logic
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"go_bullayer_v1/api/internal/store"
+	"go_bullayer_v1/api/internal/svc"
+	"go_bullayer_v1/api/internal/types"
+	"go_bullayer_v1/base/pkg/common"
+	"go_bullayer_v1/base/pkg/db"
+	"go_bullayer_v1/base/pkg/logger"
+	"go_bullayer_v1/base/pkg/utils"
+)
+
+// maxPageSize 分页查询每页最大条数
+const maxPageSize = 100
+
+// WithdrawReviewLogic 提现人工审核业务逻辑
+type WithdrawReviewLogic struct {
+	ctx    context.Context     // 上下文
+	svcCtx *svc.ServiceContext // 服务上下文
+}
+
+// NewWithdrawReviewLogic 创建提现审核逻辑处理器
+// ctx: 上下文
+// svcCtx: 服务上下文
+// 返回提现审核逻辑处理器实例
+func NewWithdrawReviewLogic(ctx context.Context, svcCtx *svc.ServiceContext) *WithdrawReviewLogic {
+	return &WithdrawReviewLogic{
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// ListReviews 分页查询待审核的提现
+// req: 分页参数
+// 返回待审核提现列表和错误信息
+func (l *WithdrawReviewLogic) ListReviews(req *types.WithdrawReviewListRequest) (resp *types.WithdrawReviewListResponse, err error) {
+	if l.svcCtx.DB == nil {
+		return nil, common.NewError(common.ErrCodeInternal, "数据库未配置")
+	}
+	if req.Page <= 0 || req.PageSize <= 0 || req.PageSize > maxPageSize {
+		return nil, common.NewError(common.ErrCodeInvalidParam, "分页参数无效")
+	}
+
+	withdrawals, err := store.ListReviewWithdrawals(l.ctx, l.svcCtx.DB, req.PageSize, (req.Page-1)*req.PageSize)
+	if err != nil {
+		logger.Error("查询待审核提现失败: %v", err)
+		return nil, common.NewError(common.ErrCodeInternal, "查询待审核提现失败")
+	}
+
+	resp = &types.WithdrawReviewListResponse{List: make([]types.WithdrawReviewItem, 0, len(withdrawals))}
+	for _, w := range withdrawals {
+		resp.List = append(resp.List, types.WithdrawReviewItem{
+			ID:         w.ID,
+			AccountID:  w.AccountID,
+			Coin:       w.Coin,
+			Amount:     w.Amount,
+			Fee:        w.Fee,
+			To:         w.To,
+			RiskReason: w.RiskReason,
+			CreatedAt:  utils.FormatDateTime(w.CreatedAt),
+		})
+	}
+	return resp, nil
+}
+
+// Approve 审核通过，提现回到待处理状态由提现执行任务广播
+// req: 提现记录ID
+// 返回审核结果和错误信息
+func (l *WithdrawReviewLogic) Approve(req *types.WithdrawReviewRequest) (resp *types.WithdrawReviewResponse, err error) {
+	return l.finish(req, common.TxStatusPending)
+}
+
+// Reject 审核拒绝，提现不再处理，冻结的金额和手续费退回可用余额
+// req: 提现记录ID
+// 返回审核结果和错误信息
+func (l *WithdrawReviewLogic) Reject(req *types.WithdrawReviewRequest) (resp *types.WithdrawReviewResponse, err error) {
+	return l.finish(req, common.TxStatusRejected)
+}
+
+func (l *WithdrawReviewLogic) finish(req *types.WithdrawReviewRequest, status int) (*types.WithdrawReviewResponse, error) {
+	if l.svcCtx.DB == nil {
+		return nil, common.NewError(common.ErrCodeInternal, "数据库未配置")
+	}
+	if req.ID <= 0 {
+		return nil, common.NewError(common.ErrCodeInvalidParam, "提现ID无效")
+	}
+
+	err := db.WithTx(l.ctx, l.svcCtx.DB, func(tx *sql.Tx) error {
+		if err := store.FinishWithdrawalReview(l.ctx, tx, req.ID, status); err != nil {
+			return err
+		}
+		if status == common.TxStatusRejected {
+			return store.ReleaseWithdrawalFunds(l.ctx, tx, req.ID)
+		}
+		return nil
+	})
+	if errors.Is(err, store.ErrWithdrawalNotInReview) {
+		return nil, common.NewError(common.ErrCodeNotFound, err.Error())
+	}
+	if err != nil {
+		logger.Error("审核提现失败: %v", err)
+		return nil, common.NewError(common.ErrCodeInternal, "审核提现失败")
+	}
+
+	logger.Info("提现 %d 审核完成，状态: %d", req.ID, status)
+	return &types.WithdrawReviewResponse{ID: req.ID, Status: status}, nil
+}
@@ -0,0 +1,161 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"go_bullayer_v1/api/internal/config"
+	"go_bullayer_v1/api/internal/store"
+	"go_bullayer_v1/base/pkg/db"
+)
+
+// 风控规则触发原因，多个原因以逗号连接写入 transactions.risk_reason
+const (
+	ReasonMaxPerTx    = "max_per_tx"   // 超过单笔金额上限
+	ReasonDailyLimit  = "daily_limit"  // 超过 24 小时累计金额上限
+	ReasonHourlyCount = "hourly_count" // 超过 1 小时提现笔数上限
+	ReasonNewAddress  = "new_address"  // 提现地址处于冷却期
+)
+
+// Request 待评估的提现申请，尚未写入 transactions
+type Request struct {
+	AccountID int64
+	Coin      string
+	Amount    string // 十进制金额
+	To        string
+}
+
+// Decision 风控评估结果
+type Decision struct {
+	Reasons []string // 触发的规则，为空表示通过
+}
+
+// NeedsReview 是否需要人工审核
+func (d Decision) NeedsReview() bool {
+	return len(d.Reasons) > 0
+}
+
+// Reason 以逗号连接的触发原因
+func (d Decision) Reason() string {
+	return strings.Join(d.Reasons, ",")
+}
+
+// rule 解析后的币种规则，金额上限为 nil 表示不限制
+type rule struct {
+	maxPerTx           *big.Rat
+	maxDaily           *big.Rat
+	maxHourlyCount     int64
+	newAddressCooldown int64
+}
+
+// usage 账户近期的提现情况
+type usage struct {
+	dailyAmount  *big.Rat
+	hourlyCount  int64
+	addressAge   int64 // 首次向该地址提现距今的秒数
+	addressKnown bool  // 是否向该地址提现过
+}
+
+// Engine 按币种规则评估提现申请
+type Engine struct {
+	rules map[string]rule
+}
+
+// NewEngine 解析风控规则配置，同一币种重复配置或金额格式错误时返回错误
+func NewEngine(rules []config.RiskRule) (*Engine, error) {
+	e := &Engine{rules: make(map[string]rule, len(rules))}
+	for _, c := range rules {
+		coin := normalizeCoin(c.Coin)
+		if coin == "" {
+			return nil, errors.New("风控规则缺少币种")
+		}
+		if _, ok := e.rules[coin]; ok {
+			return nil, fmt.Errorf("币种 %s 的风控规则重复配置", coin)
+		}
+		if c.MaxHourlyCount < 0 || c.NewAddressCooldown < 0 {
+			return nil, fmt.Errorf("币种 %s 的风控规则不能为负数", coin)
+		}
+
+		r := rule{maxHourlyCount: c.MaxHourlyCount, newAddressCooldown: c.NewAddressCooldown}
+		var err error
+		if r.maxPerTx, err = parseLimit(c.MaxPerTx); err != nil {
+			return nil, fmt.Errorf("币种 %s 的 MaxPerTx 无效: %w", coin, err)
+		}
+		if r.maxDaily, err = parseLimit(c.MaxDaily); err != nil {
+			return nil, fmt.Errorf("币种 %s 的 MaxDaily 无效: %w", coin, err)
+		}
+		e.rules[coin] = r
+	}
+	return e, nil
+}
+
+// Evaluate 评估提现申请。调用方应在锁定账户资产行的事务中评估并写入提现记录，
+// 避免同一账户的并发申请绕过累计限制。
+func (e *Engine) Evaluate(ctx context.Context, q db.Querier, req Request) (Decision, error) {
+	amount, ok := new(big.Rat).SetString(strings.TrimSpace(req.Amount))
+	if !ok || amount.Sign() <= 0 {
+		return Decision{}, fmt.Errorf("提现金额无效: %s", req.Amount)
+	}
+	r, ok := e.rules[normalizeCoin(req.Coin)]
+	if !ok {
+		return Decision{}, nil
+	}
+
+	u := usage{dailyAmount: new(big.Rat)}
+	if r.maxDaily != nil || r.maxHourlyCount > 0 {
+		activity, err := store.GetWithdrawalActivity(ctx, q, req.AccountID, req.Coin)
+		if err != nil {
+			return Decision{}, err
+		}
+		if _, ok := u.dailyAmount.SetString(activity.DailyAmount); !ok {
+			return Decision{}, fmt.Errorf("账户 %d 提现累计金额无效: %s", req.AccountID, activity.DailyAmount)
+		}
+		u.hourlyCount = activity.HourlyCount
+	}
+	if r.newAddressCooldown > 0 {
+		age, found, err := store.GetAddressFirstUsedAge(ctx, q, req.AccountID, req.To)
+		if err != nil {
+			return Decision{}, err
+		}
+		u.addressAge, u.addressKnown = age, found
+	}
+	return Decision{Reasons: r.check(amount, u)}, nil
+}
+
+// check 返回提现触发的规则
+func (r rule) check(amount *big.Rat, u usage) []string {
+	var reasons []string
+	if r.maxPerTx != nil && amount.Cmp(r.maxPerTx) > 0 {
+		reasons = append(reasons, ReasonMaxPerTx)
+	}
+	if r.maxDaily != nil && new(big.Rat).Add(u.dailyAmount, amount).Cmp(r.maxDaily) > 0 {
+		reasons = append(reasons, ReasonDailyLimit)
+	}
+	if r.maxHourlyCount > 0 && u.hourlyCount+1 > r.maxHourlyCount {
+		reasons = append(reasons, ReasonHourlyCount)
+	}
+	if r.newAddressCooldown > 0 && (!u.addressKnown || u.addressAge < r.newAddressCooldown) {
+		reasons = append(reasons, ReasonNewAddress)
+	}
+	return reasons
+}
+
+// parseLimit 解析十进制金额上限，为空返回 nil
+func parseLimit(value string) (*big.Rat, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	limit, ok := new(big.Rat).SetString(value)
+	if !ok || limit.Sign() < 0 {
+		return nil, fmt.Errorf("金额格式错误: %s", value)
+	}
+	return limit, nil
+}
+
+func normalizeCoin(coin string) string {
+	return strings.ToUpper(strings.TrimSpace(coin))
+}
@@ -0,0 +1,80 @@
+package risk
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"go_bullayer_v1/api/internal/config"
+)
+
+func TestRuleCheck(t *testing.T) {
+	engine, err := NewEngine([]config.RiskRule{{
+		Coin:               "usdt",
+		MaxPerTx:           "1000",
+		MaxDaily:           "5000",
+		MaxHourlyCount:     3,
+		NewAddressCooldown: 86400,
+	}})
+	if err != nil {
+		t.Fatalf("NewEngine failed: %v", err)
+	}
+	r := engine.rules["USDT"]
+
+	known := usage{dailyAmount: big.NewRat(4000, 1), hourlyCount: 2, addressAge: 86400, addressKnown: true}
+	cases := []struct {
+		name   string
+		amount int64
+		usage  usage
+		want   []string
+	}{
+		{"within limits", 1000, known, nil},
+		{"max per tx", 1001, usage{dailyAmount: new(big.Rat), addressAge: 86400, addressKnown: true}, []string{ReasonMaxPerTx}},
+		{"daily limit", 1000, usage{dailyAmount: big.NewRat(4001, 1), addressAge: 86400, addressKnown: true}, []string{ReasonDailyLimit}},
+		{"hourly count", 1, usage{dailyAmount: new(big.Rat), hourlyCount: 3, addressAge: 86400, addressKnown: true}, []string{ReasonHourlyCount}},
+		{"new address", 1, usage{dailyAmount: new(big.Rat)}, []string{ReasonNewAddress}},
+		{"address in cooldown", 1, usage{dailyAmount: new(big.Rat), addressAge: 86399, addressKnown: true}, []string{ReasonNewAddress}},
+		{"all rules", 2000, usage{dailyAmount: big.NewRat(4000, 1), hourlyCount: 5}, []string{ReasonMaxPerTx, ReasonDailyLimit, ReasonHourlyCount, ReasonNewAddress}},
+	}
+	for _, c := range cases {
+		got := r.check(big.NewRat(c.amount, 1), c.usage)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Fatalf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestEvaluateWithoutRule(t *testing.T) {
+	engine, err := NewEngine([]config.RiskRule{{Coin: "USDT", MaxPerTx: "1"}})
+	if err != nil {
+		t.Fatalf("NewEngine failed: %v", err)
+	}
+	// 未配置规则的币种不查询数据库
+	decision, err := engine.Evaluate(context.Background(), nil, Request{AccountID: 1, Coin: "ETH", Amount: "100", To: "0xabc"})
+	if err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	if decision.NeedsReview() {
+		t.Fatalf("unexpected review: %s", decision.Reason())
+	}
+
+	if _, err := engine.Evaluate(context.Background(), nil, Request{Coin: "USDT", Amount: "0"}); err == nil {
+		t.Fatal("expected error for zero amount")
+	}
+}
+
+func TestNewEngineRejectsInvalidRules(t *testing.T) {
+	invalid := [][]config.RiskRule{
+		{{Coin: ""}},
+		{{Coin: "ETH"}, {Coin: "eth"}},
+		{{Coin: "ETH", MaxPerTx: "abc"}},
+		{{Coin: "ETH", MaxDaily: "-1"}},
+		{{Coin: "ETH", MaxHourlyCount: -1}},
+	}
+	for _, rules := range invalid {
+		if _, err := NewEngine(rules); err == nil {
+			t.Fatalf("expected error for %+v", rules)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"go_bullayer_v1/base/pkg/db"
+)
+
+// FreezeAsset 将 amount 从可用余额转入冻结，可用余额不足时 frozen 返回 false，必须在事务内调用。
+// 更新会锁定账户该币种的资产行，直到事务结束。
+func FreezeAsset(ctx context.Context, q db.Querier, accountID int64, coin, amount string) (frozen bool, err error) {
+	// 显式转换为 DECIMAL，避免字符串参数按浮点数参与运算
+	res, err := q.ExecContext(ctx,
+		`UPDATE user_assets
+		SET available = available - CAST(? AS DECIMAL(36,18)), freeze = freeze + CAST(? AS DECIMAL(36,18))
+		WHERE account_id = ? AND coin = ? AND available >= CAST(? AS DECIMAL(36,18))`,
+		amount, amount, accountID, coin, amount,
+	)
+	if err != nil {
+		return false, fmt.Errorf("冻结账户 %d 资产失败: %w", accountID, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("冻结账户 %d 资产失败: %w", accountID, err)
+	}
+	return n == 1, nil
+}
@@ -0,0 +1,40 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"go_bullayer_v1/base/pkg/db"
+)
+
+// ErrCoinNotFound coin_configs 中没有该币种
+var ErrCoinNotFound = errors.New("币种不存在")
+
+// WithdrawCoin 提现所需的币种配置
+type WithdrawCoin struct {
+	Coin        string
+	CoinAddress string // 原生 ETH 为空
+	MinWithdraw string // 十进制
+	WithdrawFee string // 十进制
+	Decimals    int    // 链上精度
+	Status      int    // common.CoinStatus*
+}
+
+// GetWithdrawCoin 查询币种的提现配置，币种不存在时返回 ErrCoinNotFound
+func GetWithdrawCoin(ctx context.Context, q db.Querier, coin string) (WithdrawCoin, error) {
+	var c WithdrawCoin
+	err := q.QueryRowContext(ctx,
+		`SELECT coin, COALESCE(coin_address, ''), min_withdraw, withdraw_fee, decimals, status
+		FROM coin_configs WHERE coin = ?`,
+		coin,
+	).Scan(&c.Coin, &c.CoinAddress, &c.MinWithdraw, &c.WithdrawFee, &c.Decimals, &c.Status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return WithdrawCoin{}, ErrCoinNotFound
+	}
+	if err != nil {
+		return WithdrawCoin{}, fmt.Errorf("查询币种 %s 配置失败: %w", coin, err)
+	}
+	return c, nil
+}
@@ -0,0 +1,205 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"go_bullayer_v1/base/pkg/common"
+	"go_bullayer_v1/base/pkg/db"
+)
+
+// WithdrawalActivity 账户某币种最近 24 小时内的提现统计，不含失败、已回滚和已拒绝的记录
+type WithdrawalActivity struct {
+	DailyAmount string // 最近 24 小时累计金额，十进制
+	HourlyCount int64  // 最近 1 小时的提现笔数
+}
+
+// GetWithdrawalActivity 统计账户某币种的近期提现，时间窗口按数据库时钟计算
+func GetWithdrawalActivity(ctx context.Context, q db.Querier, accountID int64, coin string) (WithdrawalActivity, error) {
+	var a WithdrawalActivity
+	err := q.QueryRowContext(ctx,
+		`SELECT COALESCE(SUM(amount), 0), COALESCE(SUM(created_at >= NOW() - INTERVAL 1 HOUR), 0)
+		FROM transactions
+		WHERE account_id = ? AND coin = ? AND tx_type = ? AND status NOT IN (?, ?, ?)
+		AND created_at >= NOW() - INTERVAL 24 HOUR`,
+		accountID, coin, common.TxTypeWithdraw,
+		common.TxStatusFailed, common.TxStatusReverted, common.TxStatusRejected,
+	).Scan(&a.DailyAmount, &a.HourlyCount)
+	if err != nil {
+		return WithdrawalActivity{}, fmt.Errorf("统计账户 %d 提现失败: %w", accountID, err)
+	}
+	return a, nil
+}
+
+// GetAddressFirstUsedAge 查询账户首次向该地址提现距今的秒数，从未提现过时 found 返回 false。
+// 待审核、已拒绝和失败的提现不计入，未经审核通过的地址不会因等待审核而度过冷却期。
+func GetAddressFirstUsedAge(ctx context.Context, q db.Querier, accountID int64, to string) (age int64, found bool, err error) {
+	var seconds sql.NullInt64
+	err = q.QueryRowContext(ctx,
+		`SELECT TIMESTAMPDIFF(SECOND, MIN(created_at), NOW())
+		FROM transactions
+		WHERE account_id = ? AND tx_type = ? AND to_address = ? AND status NOT IN (?, ?, ?)`,
+		accountID, common.TxTypeWithdraw, to,
+		common.TxStatusFailed, common.TxStatusRejected, common.TxStatusPendingReview,
+	).Scan(&seconds)
+	if err != nil {
+		return 0, false, fmt.Errorf("查询账户 %d 提现地址记录失败: %w", accountID, err)
+	}
+	return seconds.Int64, seconds.Valid, nil
+}
+
+// ReviewWithdrawal 待审核的提现
+type ReviewWithdrawal struct {
+	ID         int64
+	AccountID  int64
+	Coin       string
+	Amount     string
+	Fee        string
+	To         string
+	RiskReason string
+	CreatedAt  time.Time
+}
+
+// ListReviewWithdrawals 按 id 顺序分页查询待审核的提现
+func ListReviewWithdrawals(ctx context.Context, q db.Querier, limit, offset int) ([]ReviewWithdrawal, error) {
+	rows, err := q.QueryContext(ctx,
+		`SELECT id, account_id, coin, amount, COALESCE(fee, 0), COALESCE(to_address, ''), COALESCE(risk_reason, ''), created_at
+		FROM transactions WHERE tx_type = ? AND status = ?
+		ORDER BY id LIMIT ? OFFSET ?`,
+		common.TxTypeWithdraw, common.TxStatusPendingReview, limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("查询待审核提现失败: %w", err)
+	}
+	defer rows.Close()
+
+	var withdrawals []ReviewWithdrawal
+	for rows.Next() {
+		var w ReviewWithdrawal
+		if err := rows.Scan(&w.ID, &w.AccountID, &w.Coin, &w.Amount, &w.Fee, &w.To, &w.RiskReason, &w.CreatedAt); err != nil {
+			return nil, fmt.Errorf("读取待审核提现失败: %w", err)
+		}
+		withdrawals = append(withdrawals, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取待审核提现失败: %w", err)
+	}
+	return withdrawals, nil
+}
+
+// ErrWithdrawalNotInReview 提现不存在或已不是待审核状态
+var ErrWithdrawalNotInReview = errors.New("提现不存在或不是待审核状态")
+
+// FinishWithdrawalReview 将待审核提现更新为 status：
+// common.TxStatusPending 交由提现执行任务广播，common.TxStatusRejected 结束。
+// 记录已被审核时返回 ErrWithdrawalNotInReview。
+func FinishWithdrawalReview(ctx context.Context, q db.Querier, id int64, status int) error {
+	res, err := q.ExecContext(ctx,
+		"UPDATE transactions SET status = ? WHERE id = ? AND tx_type = ? AND status = ?",
+		status, id, common.TxTypeWithdraw, common.TxStatusPendingReview,
+	)
+	if err != nil {
+		return fmt.Errorf("审核提现 %d 失败: %w", id, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("审核提现 %d 失败: %w", id, err)
+	}
+	if affected != 1 {
+		return ErrWithdrawalNotInReview
+	}
+	return nil
+}
+
+// NewWithdrawal 待写入的提现申请
+type NewWithdrawal struct {
+	AccountID   int64
+	Coin        string
+	CoinAddress string // 原生 ETH 为空
+	Amount      string
+	Fee         string
+	To          string
+	Status      int    // common.TxStatusPending 或 common.TxStatusPendingReview
+	RiskReason  string // 未触发风控规则时为空
+}
+
+// InsertWithdrawal 写入提现申请，返回记录 id
+func InsertWithdrawal(ctx context.Context, q db.Querier, w NewWithdrawal) (int64, error) {
+	var coinAddress, riskReason interface{}
+	if w.CoinAddress != "" {
+		coinAddress = w.CoinAddress
+	}
+	if w.RiskReason != "" {
+		riskReason = w.RiskReason
+	}
+	res, err := q.ExecContext(ctx,
+		`INSERT INTO transactions (tx_type, account_id, coin, coin_address, amount, fee, to_address, status, risk_reason)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		common.TxTypeWithdraw, w.AccountID, w.Coin, coinAddress, w.Amount, w.Fee, w.To, w.Status, riskReason,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("写入账户 %d 提现失败: %w", w.AccountID, err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("写入账户 %d 提现失败: %w", w.AccountID, err)
+	}
+	return id, nil
+}
+
+// WithdrawalRecord 账户的提现记录
+type WithdrawalRecord struct {
+	ID        int64
+	Coin      string
+	Amount    string
+	Fee       string
+	To        string
+	TxHash    string // 广播前为空
+	Status    int
+	CreatedAt time.Time
+}
+
+// ListAccountWithdrawals 按 id 倒序分页查询账户的提现记录
+func ListAccountWithdrawals(ctx context.Context, q db.Querier, accountID int64, limit, offset int) ([]WithdrawalRecord, error) {
+	rows, err := q.QueryContext(ctx,
+		`SELECT id, coin, amount, COALESCE(fee, 0), COALESCE(to_address, ''), COALESCE(tx_hash, ''), status, created_at
+		FROM transactions WHERE account_id = ? AND tx_type = ?
+		ORDER BY id DESC LIMIT ? OFFSET ?`,
+		accountID, common.TxTypeWithdraw, limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("查询账户 %d 提现记录失败: %w", accountID, err)
+	}
+	defer rows.Close()
+
+	var records []WithdrawalRecord
+	for rows.Next() {
+		var r WithdrawalRecord
+		if err := rows.Scan(&r.ID, &r.Coin, &r.Amount, &r.Fee, &r.To, &r.TxHash, &r.Status, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("读取提现记录失败: %w", err)
+		}
+		records = append(records, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取提现记录失败: %w", err)
+	}
+	return records, nil
+}
+
+// ReleaseWithdrawalFunds 将提现冻结的 amount + fee 退回可用余额，
+// 需在同一事务内先把提现切换为结束状态，保证只退回一次
+func ReleaseWithdrawalFunds(ctx context.Context, q db.Querier, id int64) error {
+	_, err := q.ExecContext(ctx,
+		`UPDATE user_assets u JOIN transactions t ON t.account_id = u.account_id AND t.coin = u.coin
+		SET u.available = u.available + (t.amount + COALESCE(t.fee, 0)), u.freeze = u.freeze - (t.amount + COALESCE(t.fee, 0))
+		WHERE t.id = ?`,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("退回提现 %d 冻结资产失败: %w", id, err)
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 
 	"go_bullayer_v1/api/internal/config"
+	"go_bullayer_v1/api/internal/risk"
 	"go_bullayer_v1/base/pkg/db"
 )
 
@@ -12,16 +13,22 @@ import (
 type ServiceContext struct {
 	Config config.Config // 服务配置
 	DB     *sql.DB       // 数据库连接（示例，根据实际需要添加）
+	Risk   *risk.Engine  // 提现风控引擎
 	// 可以在这里添加其他依赖，如：
 	// Redis客户端、消息队列客户端、第三方服务客户端等
 }
 
 // NewServiceContext 创建服务上下文
 // c: 服务配置
-// 返回服务上下文实例
+// 返回服务上下文实例，风控规则配置错误时 panic
 func NewServiceContext(c config.Config) *ServiceContext {
+	engine, err := risk.NewEngine(c.Risk.Rules)
+	if err != nil {
+		panic(err)
+	}
 	ctx := &ServiceContext{
 		Config: c,
+		Risk:   engine,
 	}
 
 	// 初始化数据库连接（示例）
@@ -22,3 +22,75 @@ type UserResponse struct {
 	Username string `json:"username"` // 用户名
 	Email    string `json:"email"`    // 邮箱
 }
+
+// WithdrawReviewListRequest 待审核提现列表请求
+type WithdrawReviewListRequest struct {
+	Page     int `form:"page,default=1"`      // 页码，从 1 开始
+	PageSize int `form:"pageSize,default=20"` // 每页条数，最大 100
+}
+
+// WithdrawReviewItem 待审核提现
+type WithdrawReviewItem struct {
+	ID         int64  `json:"id"`         // 提现记录ID
+	AccountID  int64  `json:"accountId"`  // 账户ID
+	Coin       string `json:"coin"`       // 币种
+	Amount     string `json:"amount"`     // 提现金额
+	Fee        string `json:"fee"`        // 手续费
+	To         string `json:"to"`         // 接收地址
+	RiskReason string `json:"riskReason"` // 触发的风控规则，多个以逗号分隔
+	CreatedAt  string `json:"createdAt"`  // 申请时间
+}
+
+// WithdrawReviewListResponse 待审核提现列表响应
+type WithdrawReviewListResponse struct {
+	List []WithdrawReviewItem `json:"list"` // 待审核提现
+}
+
+// WithdrawReviewRequest 审核提现请求
+type WithdrawReviewRequest struct {
+	ID int64 `path:"id"` // 提现记录ID
+}
+
+// WithdrawReviewResponse 审核提现响应
+type WithdrawReviewResponse struct {
+	ID     int64 `json:"id"`     // 提现记录ID
+	Status int   `json:"status"` // 审核后的状态：0-待处理，6-已拒绝
+}
+
+// WithdrawRequest 提现申请请求
+type WithdrawRequest struct {
+	Coin   string `json:"coin"`   // 币种
+	Amount string `json:"amount"` // 提现金额，不含手续费
+	To     string `json:"to"`     // 接收地址
+}
+
+// WithdrawResponse 提现申请响应
+type WithdrawResponse struct {
+	ID     int64  `json:"id"`     // 提现记录ID
+	Amount string `json:"amount"` // 提现金额
+	Fee    string `json:"fee"`    // 手续费
+	Status int    `json:"status"` // 状态：0-待处理，5-待审核
+}
+
+// WithdrawHistoryRequest 提现记录请求
+type WithdrawHistoryRequest struct {
+	Page     int `form:"page,default=1"`      // 页码，从 1 开始
+	PageSize int `form:"pageSize,default=20"` // 每页条数，最大 100
+}
+
+// WithdrawHistoryItem 提现记录
+type WithdrawHistoryItem struct {
+	ID        int64  `json:"id"`        // 提现记录ID
+	Coin      string `json:"coin"`      // 币种
+	Amount    string `json:"amount"`    // 提现金额
+	Fee       string `json:"fee"`       // 手续费
+	To        string `json:"to"`        // 接收地址
+	TxHash    string `json:"txHash"`    // 交易哈希，广播前为空
+	Status    int    `json:"status"`    // 状态：0-待处理，1-成功，2-失败，4-处理中，5-待审核，6-已拒绝
+	CreatedAt string `json:"createdAt"` // 申请时间
+}
+
+// WithdrawHistoryResponse 提现记录响应
+type WithdrawHistoryResponse struct {
+	List []WithdrawHistoryItem `json:"list"` // 提现记录，按申请时间倒序
+}
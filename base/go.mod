@@ -6,4 +6,11 @@ require (
 	github.com/ethereum/go-ethereum v1.14.12
 	github.com/zeromicro/go-zero v1.6.0
 	github.com/go-sql-driver/mysql v1.7.1
+	github.com/tyler-smith/go-bip32 v1.0.0
+	github.com/tyler-smith/go-bip39 v1.1.0
+)
+
+require (
+	github.com/FactomProject/basen v0.0.0-20150613233007-fe3947df716e // indirect
+	github.com/FactomProject/btcutilecc v0.0.0-20130527213604-d3a63a5752ec // indirect
 )
@@ -0,0 +1,7 @@
+package common
+
+// 币配置表（coin_configs）状态定义
+const (
+	CoinStatusEnabled  = 1 // 启用
+	CoinStatusDisabled = 2 // 禁用
+)
@@ -0,0 +1,14 @@
+package common
+
+// 归集交易表（sweep_transactions）类型定义
+const (
+	SweepKindSweep    = 1 // 充值地址余额归集到热钱包
+	SweepKindGasTopUp = 2 // 为 ERC20 归集向充值地址补充 ETH 手续费
+)
+
+// 归集交易表（sweep_transactions）状态定义
+const (
+	SweepStatusPending = 0 // 已广播，等待确认
+	SweepStatusSuccess = 1 // 成功
+	SweepStatusFailed  = 2 // 广播失败或执行失败
+)
@@ -0,0 +1,34 @@
+package common
+
+// 充值提现交易表（transactions）类型定义
+const (
+	TxTypeUnknown  = 0 // 未知
+	TxTypeDeposit  = 1 // 充值
+	TxTypeWithdraw = 2 // 提现
+)
+
+// 充值提现交易表（transactions）状态定义
+const (
+	TxStatusPending       = 0 // 待确认
+	TxStatusSuccess       = 1 // 成功
+	TxStatusFailed        = 2 // 失败
+	TxStatusReverted      = 3 // 已回滚（所在区块被链重组移出主链）
+	TxStatusProcessing    = 4 // 处理中（提现已签名广播，等待回执）
+	TxStatusPendingReview = 5 // 待审核（提现触发风控规则，等待人工审核）
+	TxStatusRejected      = 6 // 已拒绝（提现人工审核未通过）
+)
+
+// 提现广播记录表（withdrawal_attempts）类型定义
+const (
+	AttemptKindOriginal = 0 // 原始交易
+	AttemptKindSpeedUp  = 1 // 相同 nonce 加价替换
+	AttemptKindCancel   = 2 // 相同 nonce 的 0 金额自转账，取消原交易
+)
+
+// 提现广播记录表（withdrawal_attempts）状态定义
+const (
+	AttemptStatusPending  = 0 // 待打包
+	AttemptStatusMined    = 1 // 已打包
+	AttemptStatusReplaced = 2 // 同 nonce 的其他交易已打包
+	AttemptStatusDropped  = 3 // 广播失败
+)
@@ -0,0 +1,8 @@
+package common
+
+// 热钱包余额快照表（wallet_balance_snapshots）状态定义
+const (
+	WalletBalanceNormal       = 0 // 余额足以覆盖待处理提现和下限，且未超过上限
+	WalletBalanceBelowFloor   = 1 // 余额低于待处理提现与下限之和，需要从冷钱包补充
+	WalletBalanceAboveCeiling = 2 // 余额高于上限，应转入冷钱包
+)
@@ -2,65 +2,64 @@ package eth
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
 	"strings"
-	"sync"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
-var (
-	initOnce sync.Once
+// Client 链上数据读取接口。
+// 每条链使用独立的实例，测试中可替换为 FakeClient。
+type Client interface {
+	// LatestBlockNumber 获取链上最新区块高度。
+	LatestBlockNumber(ctx context.Context) (uint64, error)
+	// HeaderByNumber 按区块号查询区块头。
+	HeaderByNumber(ctx context.Context, blockNumber *big.Int) (*types.Header, error)
+	// BlockByNumber 按区块号查询完整区块（包含全部交易）。
+	BlockByNumber(ctx context.Context, blockNumber *big.Int) (*types.Block, error)
+	// BlockReceiptsByNumber 按区块号查询区块内全部回执。
+	BlockReceiptsByNumber(ctx context.Context, blockNumber *big.Int) ([]*types.Receipt, error)
+	// TransactionByHash 按交易哈希查询交易对象。
+	TransactionByHash(ctx context.Context, txHash common.Hash) (*types.Transaction, error)
+	// TransactionSender 查询交易发送方地址。
+	TransactionSender(ctx context.Context, tx *types.Transaction, blockHash common.Hash, index uint) (common.Address, error)
+	// TraceBlockByNumber 使用指定 tracer 调用 debug_traceBlockByNumber，返回节点原始结果。
+	TraceBlockByNumber(ctx context.Context, blockNumber *big.Int, tracer string) (json.RawMessage, error)
+	// CallContract 在指定区块状态上执行 eth_call，blockNumber 为空时使用最新区块。
+	CallContract(ctx context.Context, to common.Address, data []byte, blockNumber *big.Int) ([]byte, error)
+	// TokenBalanceAt 查询 ERC20 合约在指定区块结束时 holder 的余额（balanceOf）。
+	TokenBalanceAt(ctx context.Context, token, holder common.Address, blockNumber *big.Int) (*big.Int, error)
+}
 
-	client    *ethclient.Client
-	clientURL string
-	initErr   error
-)
+// rpcClient 基于 JSON-RPC 节点的 Client 实现
+type rpcClient struct {
+	client *ethclient.Client
+}
 
-// InitClient 初始化 ETH 客户端，只会初始化一次。
-// 如果后续用不同 RPCURL 再次初始化，会返回错误。
-func InitClient(rpcURL string) error {
+// Dial 连接 RPC 节点并返回 Client。
+func Dial(rpcURL string) (Client, error) {
 	rpcURL = strings.TrimSpace(rpcURL)
 	if rpcURL == "" {
-		return errors.New("rpcURL is required")
+		return nil, errors.New("rpcURL is required")
 	}
 
-	initOnce.Do(func() {
-		c, err := ethclient.Dial(rpcURL)
-		if err != nil {
-			initErr = fmt.Errorf("dial eth rpc failed: %w", err)
-			return
-		}
-		client = c
-		clientURL = rpcURL
-	})
-
-	if initErr != nil {
-		return initErr
-	}
-	if clientURL != rpcURL {
-		return fmt.Errorf("eth client already initialized with %s", clientURL)
+	c, err := ethclient.Dial(rpcURL)
+	if err != nil {
+		return nil, fmt.Errorf("dial eth rpc failed: %w", err)
 	}
-	return nil
+	return &rpcClient{client: c}, nil
 }
 
-// IsInitialized 返回客户端是否已初始化成功。
-func IsInitialized() bool {
-	return client != nil
-}
-
-// LatestBlockNumber 获取链上最新区块高度。
-func LatestBlockNumber(ctx context.Context) (uint64, error) {
-	if client == nil {
-		return 0, errors.New("eth client is not initialized")
-	}
-
-	header, err := client.HeaderByNumber(ctx, nil)
+func (c *rpcClient) LatestBlockNumber(ctx context.Context) (uint64, error) {
+	header, err := c.client.HeaderByNumber(ctx, nil)
 	if err != nil {
 		return 0, err
 	}
@@ -70,38 +69,76 @@ func LatestBlockNumber(ctx context.Context) (uint64, error) {
 	return header.Number.Uint64(), nil
 }
 
-// BlockReceiptsByNumber 按区块号查询区块内全部回执。
-func BlockReceiptsByNumber(ctx context.Context, blockNumber *big.Int) ([]*types.Receipt, error) {
-	if client == nil {
-		return nil, errors.New("eth client is not initialized")
+func (c *rpcClient) HeaderByNumber(ctx context.Context, blockNumber *big.Int) (*types.Header, error) {
+	if blockNumber == nil {
+		return nil, errors.New("blockNumber is required")
+	}
+
+	header, err := c.client.HeaderByNumber(ctx, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, fmt.Errorf("header %s is nil", blockNumber.String())
+	}
+	return header, nil
+}
+
+func (c *rpcClient) BlockByNumber(ctx context.Context, blockNumber *big.Int) (*types.Block, error) {
+	if blockNumber == nil {
+		return nil, errors.New("blockNumber is required")
 	}
+	return c.client.BlockByNumber(ctx, blockNumber)
+}
+
+func (c *rpcClient) BlockReceiptsByNumber(ctx context.Context, blockNumber *big.Int) ([]*types.Receipt, error) {
 	if blockNumber == nil {
 		return nil, errors.New("blockNumber is required")
 	}
 
 	blockRef := rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(blockNumber.Int64()))
-	return client.BlockReceipts(ctx, blockRef)
+	return c.client.BlockReceipts(ctx, blockRef)
 }
 
-// TransactionByHash 按交易哈希查询交易对象。
-func TransactionByHash(ctx context.Context, txHash common.Hash) (*types.Transaction, error) {
-	if client == nil {
-		return nil, errors.New("eth client is not initialized")
-	}
-	tx, _, err := client.TransactionByHash(ctx, txHash)
+func (c *rpcClient) TransactionByHash(ctx context.Context, txHash common.Hash) (*types.Transaction, error) {
+	tx, _, err := c.client.TransactionByHash(ctx, txHash)
 	if err != nil {
 		return nil, err
 	}
 	return tx, nil
 }
 
-// TransactionSender 查询交易发送方地址。
-func TransactionSender(ctx context.Context, tx *types.Transaction, blockHash common.Hash, index uint) (common.Address, error) {
-	if client == nil {
-		return common.Address{}, errors.New("eth client is not initialized")
-	}
+func (c *rpcClient) TransactionSender(ctx context.Context, tx *types.Transaction, blockHash common.Hash, index uint) (common.Address, error) {
 	if tx == nil {
 		return common.Address{}, errors.New("transaction is nil")
 	}
-	return client.TransactionSender(ctx, tx, blockHash, index)
+	return c.client.TransactionSender(ctx, tx, blockHash, index)
+}
+
+// TraceBlockByNumber 需要节点开启 debug 命名空间。
+func (c *rpcClient) TraceBlockByNumber(ctx context.Context, blockNumber *big.Int, tracer string) (json.RawMessage, error) {
+	if blockNumber == nil {
+		return nil, errors.New("blockNumber is required")
+	}
+
+	var result json.RawMessage
+	err := c.client.Client().CallContext(ctx, &result, "debug_traceBlockByNumber",
+		hexutil.EncodeBig(blockNumber), map[string]string{"tracer": tracer})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *rpcClient) CallContract(ctx context.Context, to common.Address, data []byte, blockNumber *big.Int) ([]byte, error) {
+	return c.client.CallContract(ctx, ethereum.CallMsg{To: &to, Data: data}, blockNumber)
+}
+
+// TokenBalanceAt 历史区块的余额查询需要节点保留对应状态（归档节点或在裁剪窗口内）。
+func (c *rpcClient) TokenBalanceAt(ctx context.Context, token, holder common.Address, blockNumber *big.Int) (*big.Int, error) {
+	if blockNumber == nil {
+		return nil, errors.New("blockNumber is required")
+	}
+
+	return ERC20BalanceOf(ctx, c, token, holder, blockNumber)
 }
@@ -0,0 +1,118 @@
+package eth
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ERC20 方法选择器
+var (
+	// ERC20TransferSelector transfer(address,uint256) 方法选择器
+	ERC20TransferSelector = crypto.Keccak256([]byte("transfer(address,uint256)"))[:4]
+	// ERC20BalanceOfSelector balanceOf(address) 方法选择器
+	ERC20BalanceOfSelector = crypto.Keccak256([]byte("balanceOf(address)"))[:4]
+	// ERC20DecimalsSelector decimals() 方法选择器
+	ERC20DecimalsSelector = crypto.Keccak256([]byte("decimals()"))[:4]
+)
+
+// maxERC20Decimals decimals() 的上限，10^77 是 uint256 能表示的最大 10 的幂
+const maxERC20Decimals = 77
+
+// ContractCaller 执行 eth_call 的接口，Client 满足该接口。
+type ContractCaller interface {
+	CallContract(ctx context.Context, to common.Address, data []byte, blockNumber *big.Int) ([]byte, error)
+}
+
+// CallMsgCaller 将 go-ethereum 的 ethereum.ContractCaller（如 *ethclient.Client）适配为 ContractCaller。
+func CallMsgCaller(caller ethereum.ContractCaller) ContractCaller {
+	return callMsgCaller{caller: caller}
+}
+
+type callMsgCaller struct {
+	caller ethereum.ContractCaller
+}
+
+func (c callMsgCaller) CallContract(ctx context.Context, to common.Address, data []byte, blockNumber *big.Int) ([]byte, error) {
+	return c.caller.CallContract(ctx, ethereum.CallMsg{To: &to, Data: data}, blockNumber)
+}
+
+// ERC20TransferData 编码 transfer(to, amount) 调用数据
+func ERC20TransferData(to common.Address, amount *big.Int) []byte {
+	data := append(append([]byte{}, ERC20TransferSelector...), common.LeftPadBytes(to.Bytes(), 32)...)
+	return append(data, common.LeftPadBytes(amount.Bytes(), 32)...)
+}
+
+// ERC20BalanceOfData 编码 balanceOf(holder) 调用数据
+func ERC20BalanceOfData(holder common.Address) []byte {
+	return append(append([]byte{}, ERC20BalanceOfSelector...), common.LeftPadBytes(holder.Bytes(), 32)...)
+}
+
+// ERC20Decimals 查询合约 decimals()，返回值超过 77 时报错。
+func ERC20Decimals(ctx context.Context, caller ContractCaller, token common.Address) (int, error) {
+	out, err := caller.CallContract(ctx, token, ERC20DecimalsSelector, nil)
+	if err != nil {
+		return 0, fmt.Errorf("call decimals() of %s failed: %w", token.Hex(), err)
+	}
+	if len(out) < 32 {
+		return 0, fmt.Errorf("decimals() of %s returned %d bytes", token.Hex(), len(out))
+	}
+	decimals := new(big.Int).SetBytes(out[:32])
+	if !decimals.IsInt64() || decimals.Int64() > maxERC20Decimals {
+		return 0, fmt.Errorf("decimals() of %s out of range: %s", token.Hex(), decimals)
+	}
+	return int(decimals.Int64()), nil
+}
+
+// ERC20BalanceOf 查询 holder 在指定区块结束时的余额（balanceOf），blockNumber 为空时使用最新区块。
+func ERC20BalanceOf(ctx context.Context, caller ContractCaller, token, holder common.Address, blockNumber *big.Int) (*big.Int, error) {
+	out, err := caller.CallContract(ctx, token, ERC20BalanceOfData(holder), blockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("call balanceOf of %s failed: %w", token.Hex(), err)
+	}
+	if len(out) < 32 {
+		return nil, fmt.Errorf("balanceOf of %s returned %d bytes", token.Hex(), len(out))
+	}
+	return new(big.Int).SetBytes(out[:32]), nil
+}
+
+// TokenReader 查询 ERC20 精度和最新余额，精度按合约缓存，可并发使用。
+type TokenReader struct {
+	caller   ContractCaller
+	mu       sync.Mutex
+	decimals map[common.Address]int
+}
+
+// NewTokenReader 创建 ERC20 读取器
+func NewTokenReader(caller ContractCaller) *TokenReader {
+	return &TokenReader{caller: caller, decimals: make(map[common.Address]int)}
+}
+
+// Decimals 查询合约 decimals()，成功后缓存
+func (r *TokenReader) Decimals(ctx context.Context, token common.Address) (int, error) {
+	r.mu.Lock()
+	d, ok := r.decimals[token]
+	r.mu.Unlock()
+	if ok {
+		return d, nil
+	}
+
+	d, err := ERC20Decimals(ctx, r.caller, token)
+	if err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	r.decimals[token] = d
+	r.mu.Unlock()
+	return d, nil
+}
+
+// BalanceOf 查询 holder 在最新区块的余额
+func (r *TokenReader) BalanceOf(ctx context.Context, token, holder common.Address) (*big.Int, error) {
+	return ERC20BalanceOf(ctx, r.caller, token, holder, nil)
+}
@@ -0,0 +1,55 @@
+package eth
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestERC20TransferData(t *testing.T) {
+	to := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+	data := ERC20TransferData(to, big.NewInt(1000))
+	if len(data) != 68 || !bytes.Equal(data[:4], []byte{0xa9, 0x05, 0x9c, 0xbb}) {
+		t.Fatalf("unexpected call data %x", data)
+	}
+	if common.BytesToAddress(data[4:36]) != to || new(big.Int).SetBytes(data[36:]).Int64() != 1000 {
+		t.Fatalf("unexpected call arguments %x", data[4:])
+	}
+}
+
+func TestTokenReader(t *testing.T) {
+	token := common.HexToAddress("0x00000000000000000000000000000000000000c0")
+	holder := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+	client := NewFakeClient(1)
+	client.SetCallResult(token, ERC20DecimalsSelector, common.LeftPadBytes([]byte{6}, 32))
+	client.SetCallResult(token, ERC20BalanceOfData(holder), common.LeftPadBytes(big.NewInt(2_500_000).Bytes(), 32))
+	reader := NewTokenReader(client)
+	ctx := context.Background()
+
+	decimals, err := reader.Decimals(ctx, token)
+	if err != nil || decimals != 6 {
+		t.Fatalf("decimals = %d, %v; want 6", decimals, err)
+	}
+	balance, err := reader.BalanceOf(ctx, token, holder)
+	if err != nil || balance.Int64() != 2_500_000 {
+		t.Fatalf("balance = %v, %v; want 2500000", balance, err)
+	}
+
+	// 精度已缓存，不再查询合约
+	client.SetCallResult(token, ERC20DecimalsSelector, common.LeftPadBytes([]byte{18}, 32))
+	if decimals, err := reader.Decimals(ctx, token); err != nil || decimals != 6 {
+		t.Fatalf("cached decimals = %d, %v; want 6", decimals, err)
+	}
+
+	invalid := common.HexToAddress("0x00000000000000000000000000000000000000c1")
+	client.SetCallResult(invalid, ERC20DecimalsSelector, common.LeftPadBytes([]byte{78}, 32))
+	if _, err := reader.Decimals(ctx, invalid); err == nil {
+		t.Fatal("expected error for decimals above 77")
+	}
+	if _, err := reader.Decimals(ctx, common.HexToAddress("0x00000000000000000000000000000000000000c2")); err == nil {
+		t.Fatal("expected error for contract without decimals()")
+	}
+}
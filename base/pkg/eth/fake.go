@@ -0,0 +1,301 @@
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// FakeClient 内存中的 Client 实现，用于单元测试。
+// 区块按高度保存，支持截断重放模拟链重组，并可按方法注入错误和统计调用次数。
+type FakeClient struct {
+	mu       sync.Mutex
+	signer   types.Signer
+	blocks   map[uint64]*types.Block
+	receipts map[uint64][]*types.Receipt
+	traces   map[uint64]json.RawMessage
+	txs      map[common.Hash]*types.Transaction
+	balances map[tokenBalanceKey]*big.Int
+	results  map[string][]byte // 合约地址 + calldata -> 返回值
+	latest   uint64
+	calls    map[string]int
+	errs     map[string]*injectedError
+}
+
+// tokenBalanceKey 代币余额索引
+type tokenBalanceKey struct {
+	token, holder common.Address
+	block         uint64
+}
+
+type injectedError struct {
+	err   error
+	times int // 剩余次数，小于 0 表示一直返回错误
+}
+
+// NewFakeClient 创建指定链ID的内存客户端，交易发送方按该链的签名规则恢复。
+func NewFakeClient(chainID int64) *FakeClient {
+	return &FakeClient{
+		signer:   types.LatestSignerForChainID(big.NewInt(chainID)),
+		blocks:   make(map[uint64]*types.Block),
+		receipts: make(map[uint64][]*types.Receipt),
+		traces:   make(map[uint64]json.RawMessage),
+		txs:      make(map[common.Hash]*types.Transaction),
+		balances: make(map[tokenBalanceKey]*big.Int),
+		results:  make(map[string][]byte),
+		calls:    make(map[string]int),
+		errs:     make(map[string]*injectedError),
+	}
+}
+
+// AddBlock 写入区块及其回执，已存在的同高度区块会被替换。
+func (f *FakeClient) AddBlock(block *types.Block, receipts []*types.Receipt) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	n := block.NumberU64()
+	f.blocks[n] = block
+	f.receipts[n] = receipts
+	for _, tx := range block.Transactions() {
+		f.txs[tx.Hash()] = tx
+	}
+	if n > f.latest || len(f.blocks) == 1 {
+		f.latest = n
+	}
+}
+
+// Truncate 删除 height 及以上的区块，用于模拟链重组。
+func (f *FakeClient) Truncate(height uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for n := range f.blocks {
+		if n >= height {
+			delete(f.blocks, n)
+			delete(f.receipts, n)
+			delete(f.traces, n)
+		}
+	}
+	f.latest = 0
+	for n := range f.blocks {
+		if n > f.latest {
+			f.latest = n
+		}
+	}
+}
+
+// SetTrace 设置区块的 debug_traceBlockByNumber 返回结果。
+func (f *FakeClient) SetTrace(blockNumber uint64, raw json.RawMessage) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.traces[blockNumber] = raw
+}
+
+// SetTokenBalance 设置 holder 自 blockNumber 起的代币余额，之后的区块沿用该值直到再次设置。
+func (f *FakeClient) SetTokenBalance(token, holder common.Address, blockNumber uint64, balance *big.Int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.balances[tokenBalanceKey{token: token, holder: holder, block: blockNumber}] = new(big.Int).Set(balance)
+}
+
+// SetCallResult 设置对合约 to 以 data 调用 eth_call 的返回值，与区块无关。
+func (f *FakeClient) SetCallResult(to common.Address, data []byte, result []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.results[callKey(to, data)] = result
+}
+
+func callKey(to common.Address, data []byte) string {
+	return to.Hex() + common.Bytes2Hex(data)
+}
+
+// InjectError 让 method 接下来的 times 次调用返回 err，times 小于 0 时一直返回错误。
+func (f *FakeClient) InjectError(method string, times int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errs[method] = &injectedError{err: err, times: times}
+}
+
+// CallCount 返回 method 被调用的次数，method 为空时返回全部调用次数。
+func (f *FakeClient) CallCount(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if method != "" {
+		return f.calls[method]
+	}
+	total := 0
+	for _, n := range f.calls {
+		total += n
+	}
+	return total
+}
+
+// call 记录调用并返回注入的错误，调用方需持有锁
+func (f *FakeClient) call(method string) error {
+	f.calls[method]++
+
+	injected, ok := f.errs[method]
+	if !ok || injected.times == 0 {
+		return nil
+	}
+	if injected.times > 0 {
+		injected.times--
+	}
+	return injected.err
+}
+
+func (f *FakeClient) LatestBlockNumber(ctx context.Context) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("LatestBlockNumber"); err != nil {
+		return 0, err
+	}
+	if len(f.blocks) == 0 {
+		return 0, errors.New("no blocks")
+	}
+	return f.latest, nil
+}
+
+func (f *FakeClient) HeaderByNumber(ctx context.Context, blockNumber *big.Int) (*types.Header, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("HeaderByNumber"); err != nil {
+		return nil, err
+	}
+	block, err := f.blockLocked(blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return block.Header(), nil
+}
+
+func (f *FakeClient) BlockByNumber(ctx context.Context, blockNumber *big.Int) (*types.Block, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("BlockByNumber"); err != nil {
+		return nil, err
+	}
+	return f.blockLocked(blockNumber)
+}
+
+func (f *FakeClient) BlockReceiptsByNumber(ctx context.Context, blockNumber *big.Int) ([]*types.Receipt, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("BlockReceiptsByNumber"); err != nil {
+		return nil, err
+	}
+	block, err := f.blockLocked(blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return f.receipts[block.NumberU64()], nil
+}
+
+func (f *FakeClient) TransactionByHash(ctx context.Context, txHash common.Hash) (*types.Transaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("TransactionByHash"); err != nil {
+		return nil, err
+	}
+	tx, ok := f.txs[txHash]
+	if !ok {
+		return nil, fmt.Errorf("transaction %s not found", txHash.Hex())
+	}
+	return tx, nil
+}
+
+func (f *FakeClient) TransactionSender(ctx context.Context, tx *types.Transaction, blockHash common.Hash, index uint) (common.Address, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("TransactionSender"); err != nil {
+		return common.Address{}, err
+	}
+	if tx == nil {
+		return common.Address{}, errors.New("transaction is nil")
+	}
+	return types.Sender(f.signer, tx)
+}
+
+func (f *FakeClient) TraceBlockByNumber(ctx context.Context, blockNumber *big.Int, tracer string) (json.RawMessage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("TraceBlockByNumber"); err != nil {
+		return nil, err
+	}
+	if blockNumber == nil {
+		return nil, errors.New("blockNumber is required")
+	}
+	raw, ok := f.traces[blockNumber.Uint64()]
+	if !ok {
+		return json.RawMessage("[]"), nil
+	}
+	return raw, nil
+}
+
+func (f *FakeClient) CallContract(ctx context.Context, to common.Address, data []byte, blockNumber *big.Int) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("CallContract"); err != nil {
+		return nil, err
+	}
+	result, ok := f.results[callKey(to, data)]
+	if !ok {
+		return nil, errors.New("execution reverted")
+	}
+	return result, nil
+}
+
+func (f *FakeClient) TokenBalanceAt(ctx context.Context, token, holder common.Address, blockNumber *big.Int) (*big.Int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("TokenBalanceAt"); err != nil {
+		return nil, err
+	}
+	if blockNumber == nil {
+		return nil, errors.New("blockNumber is required")
+	}
+
+	// 取不晚于 blockNumber 的最近一次设置，未设置时余额为 0
+	var (
+		balance = new(big.Int)
+		at      uint64
+		found   bool
+	)
+	for key, v := range f.balances {
+		if key.token != token || key.holder != holder || key.block > blockNumber.Uint64() {
+			continue
+		}
+		if !found || key.block > at {
+			balance, at, found = v, key.block, true
+		}
+	}
+	return new(big.Int).Set(balance), nil
+}
+
+// blockLocked 按高度查询区块，调用方需持有锁
+func (f *FakeClient) blockLocked(blockNumber *big.Int) (*types.Block, error) {
+	if blockNumber == nil {
+		return nil, errors.New("blockNumber is required")
+	}
+	block, ok := f.blocks[blockNumber.Uint64()]
+	if !ok {
+		return nil, fmt.Errorf("block %s not found", blockNumber.String())
+	}
+	return block, nil
+}
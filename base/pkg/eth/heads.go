@@ -0,0 +1,37 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// HeadSubscriber 新区块头订阅接口（eth_subscribe newHeads），测试中可替换为内存实现。
+type HeadSubscriber interface {
+	// SubscribeNewHead 订阅新区块头，订阅断开时 Subscription.Err() 返回错误。
+	SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error)
+	// Close 关闭底层连接。
+	Close()
+}
+
+// DialHeadSubscriber 连接 WebSocket RPC 节点用于订阅新区块头。
+func DialHeadSubscriber(ctx context.Context, wsURL string) (HeadSubscriber, error) {
+	wsURL = strings.TrimSpace(wsURL)
+	if wsURL == "" {
+		return nil, errors.New("wsURL is required")
+	}
+	if !strings.HasPrefix(wsURL, "ws://") && !strings.HasPrefix(wsURL, "wss://") {
+		return nil, fmt.Errorf("wsURL must use ws:// or wss://: %s", wsURL)
+	}
+
+	c, err := ethclient.DialContext(ctx, wsURL)
+	if err != nil {
+		return nil, fmt.Errorf("dial eth websocket failed: %w", err)
+	}
+	return c, nil
+}
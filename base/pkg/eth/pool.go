@@ -0,0 +1,318 @@
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// 节点健康评分参数
+const (
+	ewmaAlpha              = 0.3              // 延迟和错误率的指数加权系数
+	errorPenaltyMs         = 2000.0           // 错误率为 100% 时的评分惩罚（毫秒）
+	lagPenaltyMs           = 200.0            // 每落后一个区块的评分惩罚（毫秒）
+	maxConsecutiveFailures = 3                // 连续失败多少次后暂停使用
+	endpointCooldown       = 30 * time.Second // 暂停使用的时长
+)
+
+// EndpointHealth 节点健康状况快照
+type EndpointHealth struct {
+	URL       string
+	LatencyMs float64 // 延迟的指数加权平均值
+	ErrorRate float64 // 错误率的指数加权平均值，0-1
+	Head      uint64  // 最近一次观察到的最新高度
+	HeadLag   uint64  // 落后于所有节点最高高度的区块数
+	Available bool    // 是否未处于暂停期
+}
+
+// endpoint 单个 RPC 节点及其健康统计
+type endpoint struct {
+	url    string
+	client Client
+
+	mu        sync.Mutex
+	latency   float64
+	errorRate float64
+	head      uint64
+	failures  int
+	downUntil time.Time
+}
+
+func (e *endpoint) recordSuccess(elapsed time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.latency = ewma(e.latency, float64(elapsed.Microseconds())/1000)
+	e.errorRate = ewma(e.errorRate, 0)
+	e.failures = 0
+}
+
+// recordFailure 记录失败；countable 为 false 的错误（例如区块尚不存在）只计延迟，不计入错误率
+func (e *endpoint) recordFailure(elapsed time.Duration, countable bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.latency = ewma(e.latency, float64(elapsed.Microseconds())/1000)
+	if !countable {
+		return
+	}
+	e.errorRate = ewma(e.errorRate, 1)
+	e.failures++
+	if e.failures >= maxConsecutiveFailures {
+		e.downUntil = time.Now().Add(endpointCooldown)
+		e.failures = 0
+	}
+}
+
+func (e *endpoint) recordHead(head uint64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if head > e.head {
+		e.head = head
+	}
+}
+
+func (e *endpoint) health(maxHead uint64, now time.Time) EndpointHealth {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	h := EndpointHealth{
+		URL:       e.url,
+		LatencyMs: e.latency,
+		ErrorRate: e.errorRate,
+		Head:      e.head,
+		Available: !now.Before(e.downUntil),
+	}
+	// 尚未观察到高度的节点不计落后
+	if e.head > 0 && maxHead > e.head {
+		h.HeadLag = maxHead - e.head
+	}
+	return h
+}
+
+// score 评分越低越优先
+func (h EndpointHealth) score() float64 {
+	return h.LatencyMs + h.ErrorRate*errorPenaltyMs + float64(h.HeadLag)*lagPenaltyMs
+}
+
+func ewma(prev, sample float64) float64 {
+	return prev*(1-ewmaAlpha) + sample*ewmaAlpha
+}
+
+// Pool 多 RPC 节点客户端，实现 Client 接口。
+// 按延迟、错误率和高度落后情况为节点评分，每次调用优先使用最健康的节点，失败时自动切换到下一个。
+type Pool struct {
+	endpoints []*endpoint
+}
+
+// DialPool 连接多个 RPC 节点，重复地址会被忽略。
+func DialPool(rpcURLs ...string) (*Pool, error) {
+	seen := make(map[string]struct{}, len(rpcURLs))
+	pool := &Pool{}
+	for _, url := range rpcURLs {
+		url = strings.TrimSpace(url)
+		if url == "" {
+			continue
+		}
+		if _, ok := seen[url]; ok {
+			continue
+		}
+		seen[url] = struct{}{}
+
+		client, err := Dial(url)
+		if err != nil {
+			return nil, fmt.Errorf("dial %s failed: %w", url, err)
+		}
+		pool.endpoints = append(pool.endpoints, &endpoint{url: url, client: client})
+	}
+	if len(pool.endpoints) == 0 {
+		return nil, errors.New("at least one rpcURL is required")
+	}
+	return pool, nil
+}
+
+// Health 返回全部节点的健康状况。
+func (p *Pool) Health() []EndpointHealth {
+	maxHead := p.maxHead()
+	now := time.Now()
+	out := make([]EndpointHealth, 0, len(p.endpoints))
+	for _, e := range p.endpoints {
+		out = append(out, e.health(maxHead, now))
+	}
+	return out
+}
+
+func (p *Pool) maxHead() uint64 {
+	var maxHead uint64
+	for _, e := range p.endpoints {
+		e.mu.Lock()
+		if e.head > maxHead {
+			maxHead = e.head
+		}
+		e.mu.Unlock()
+	}
+	return maxHead
+}
+
+// ranked 按评分排序节点，暂停期内的节点排在最后，仅在其他节点都失败时使用
+func (p *Pool) ranked() []*endpoint {
+	maxHead := p.maxHead()
+	now := time.Now()
+
+	type candidate struct {
+		e *endpoint
+		h EndpointHealth
+	}
+	candidates := make([]candidate, 0, len(p.endpoints))
+	for _, e := range p.endpoints {
+		candidates = append(candidates, candidate{e: e, h: e.health(maxHead, now)})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].h.Available != candidates[j].h.Available {
+			return candidates[i].h.Available
+		}
+		return candidates[i].h.score() < candidates[j].h.score()
+	})
+
+	out := make([]*endpoint, 0, len(candidates))
+	for _, c := range candidates {
+		out = append(out, c.e)
+	}
+	return out
+}
+
+// poolCall 依次在评分最优的节点上执行 fn，直到成功或全部节点失败
+func poolCall[T any](ctx context.Context, p *Pool, fn func(Client) (T, error)) (T, error) {
+	var (
+		zero    T
+		lastErr error
+	)
+	for _, e := range p.ranked() {
+		start := time.Now()
+		result, err := fn(e.client)
+		if err == nil {
+			e.recordSuccess(time.Since(start))
+			return result, nil
+		}
+		if ctx.Err() != nil {
+			return zero, err
+		}
+		e.recordFailure(time.Since(start), !errors.Is(err, ethereum.NotFound))
+		lastErr = fmt.Errorf("%s: %w", e.url, err)
+	}
+	return zero, lastErr
+}
+
+// LatestBlockNumber 并发查询所有可用节点的最新高度，用于更新高度落后统计，返回其中的最高高度。
+func (p *Pool) LatestBlockNumber(ctx context.Context) (uint64, error) {
+	now := time.Now()
+	targets := make([]*endpoint, 0, len(p.endpoints))
+	for _, e := range p.endpoints {
+		if e.health(0, now).Available {
+			targets = append(targets, e)
+		}
+	}
+	if len(targets) == 0 {
+		targets = p.endpoints
+	}
+
+	type result struct {
+		head uint64
+		err  error
+	}
+	results := make([]result, len(targets))
+	var wg sync.WaitGroup
+	for i, e := range targets {
+		wg.Add(1)
+		go func(i int, e *endpoint) {
+			defer wg.Done()
+			start := time.Now()
+			head, err := e.client.LatestBlockNumber(ctx)
+			if err != nil {
+				e.recordFailure(time.Since(start), ctx.Err() == nil)
+				results[i].err = fmt.Errorf("%s: %w", e.url, err)
+				return
+			}
+			e.recordSuccess(time.Since(start))
+			e.recordHead(head)
+			results[i].head = head
+		}(i, e)
+	}
+	wg.Wait()
+
+	var (
+		best uint64
+		errs []error
+	)
+	for _, r := range results {
+		if r.err != nil {
+			errs = append(errs, r.err)
+			continue
+		}
+		if r.head > best {
+			best = r.head
+		}
+	}
+	if len(errs) == len(results) {
+		return 0, errors.Join(errs...)
+	}
+	return best, nil
+}
+
+func (p *Pool) HeaderByNumber(ctx context.Context, blockNumber *big.Int) (*types.Header, error) {
+	return poolCall(ctx, p, func(c Client) (*types.Header, error) {
+		return c.HeaderByNumber(ctx, blockNumber)
+	})
+}
+
+func (p *Pool) BlockByNumber(ctx context.Context, blockNumber *big.Int) (*types.Block, error) {
+	return poolCall(ctx, p, func(c Client) (*types.Block, error) {
+		return c.BlockByNumber(ctx, blockNumber)
+	})
+}
+
+func (p *Pool) BlockReceiptsByNumber(ctx context.Context, blockNumber *big.Int) ([]*types.Receipt, error) {
+	return poolCall(ctx, p, func(c Client) ([]*types.Receipt, error) {
+		return c.BlockReceiptsByNumber(ctx, blockNumber)
+	})
+}
+
+func (p *Pool) TransactionByHash(ctx context.Context, txHash common.Hash) (*types.Transaction, error) {
+	return poolCall(ctx, p, func(c Client) (*types.Transaction, error) {
+		return c.TransactionByHash(ctx, txHash)
+	})
+}
+
+func (p *Pool) TransactionSender(ctx context.Context, tx *types.Transaction, blockHash common.Hash, index uint) (common.Address, error) {
+	return poolCall(ctx, p, func(c Client) (common.Address, error) {
+		return c.TransactionSender(ctx, tx, blockHash, index)
+	})
+}
+
+func (p *Pool) TraceBlockByNumber(ctx context.Context, blockNumber *big.Int, tracer string) (json.RawMessage, error) {
+	return poolCall(ctx, p, func(c Client) (json.RawMessage, error) {
+		return c.TraceBlockByNumber(ctx, blockNumber, tracer)
+	})
+}
+
+func (p *Pool) CallContract(ctx context.Context, to common.Address, data []byte, blockNumber *big.Int) ([]byte, error) {
+	return poolCall(ctx, p, func(c Client) ([]byte, error) {
+		return c.CallContract(ctx, to, data, blockNumber)
+	})
+}
+
+func (p *Pool) TokenBalanceAt(ctx context.Context, token, holder common.Address, blockNumber *big.Int) (*big.Int, error) {
+	return poolCall(ctx, p, func(c Client) (*big.Int, error) {
+		return c.TokenBalanceAt(ctx, token, holder, blockNumber)
+	})
+}
@@ -0,0 +1,202 @@
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// rpcStandIn 本地 JSON-RPC 节点替身，只实现 eth_getBlockByNumber
+type rpcStandIn struct {
+	server   *httptest.Server
+	mu       sync.Mutex
+	head     uint64
+	delay    time.Duration
+	down     bool
+	requests atomic.Int64
+}
+
+func newRPCStandIn(t *testing.T, head uint64) *rpcStandIn {
+	s := &rpcStandIn{head: head}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.server.Close)
+	return s
+}
+
+func (s *rpcStandIn) set(fn func(s *rpcStandIn)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(s)
+}
+
+func (s *rpcStandIn) serve(w http.ResponseWriter, r *http.Request) {
+	s.requests.Add(1)
+
+	s.mu.Lock()
+	head, delay, down := s.head, s.delay, s.down
+	s.mu.Unlock()
+
+	time.Sleep(delay)
+	if down {
+		http.Error(w, "rate limited", http.StatusTooManyRequests)
+		return
+	}
+
+	var req struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var result interface{}
+	switch req.Method {
+	case "eth_getBlockByNumber":
+		var tag string
+		_ = json.Unmarshal(req.Params[0], &tag)
+		number := head
+		if tag != "latest" {
+			n, err := hexutil.DecodeUint64(tag)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			number = n
+		}
+		if number <= head {
+			result = &types.Header{Number: new(big.Int).SetUint64(number), Difficulty: big.NewInt(0)}
+		}
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"jsonrpc": "2.0", "id": req.ID,
+			"error": map[string]interface{}{"code": -32601, "message": "method not found"},
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+}
+
+func TestPool_FailsOverToHealthyEndpoint(t *testing.T) {
+	bad := newRPCStandIn(t, 100)
+	good := newRPCStandIn(t, 100)
+	bad.set(func(s *rpcStandIn) { s.down = true })
+
+	pool, err := DialPool(bad.server.URL, good.server.URL)
+	if err != nil {
+		t.Fatalf("dial pool failed: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		header, err := pool.HeaderByNumber(context.Background(), big.NewInt(50))
+		if err != nil {
+			t.Fatalf("call %d should fail over, got %v", i, err)
+		}
+		if header.Number.Uint64() != 50 {
+			t.Fatalf("unexpected header: %d", header.Number.Uint64())
+		}
+	}
+
+	// 首次失败后故障节点评分变差，之后的调用直接路由到健康节点
+	if n := bad.requests.Load(); n != 1 {
+		t.Fatalf("expected failing endpoint to be tried once, got %d", n)
+	}
+	health := pool.Health()
+	if health[0].ErrorRate == 0 || health[1].ErrorRate != 0 {
+		t.Fatalf("unexpected error rates: %+v", health)
+	}
+}
+
+func TestPool_PrefersEndpointWithoutHeadLag(t *testing.T) {
+	lagging := newRPCStandIn(t, 90)
+	synced := newRPCStandIn(t, 100)
+
+	pool, err := DialPool(lagging.server.URL, synced.server.URL)
+	if err != nil {
+		t.Fatalf("dial pool failed: %v", err)
+	}
+
+	latest, err := pool.LatestBlockNumber(context.Background())
+	if err != nil || latest != 100 {
+		t.Fatalf("expected highest head 100, got %d (%v)", latest, err)
+	}
+	if lag := pool.Health()[0].HeadLag; lag != 10 {
+		t.Fatalf("expected lagging endpoint to be 10 blocks behind, got %d", lag)
+	}
+
+	before := lagging.requests.Load()
+	if _, err := pool.HeaderByNumber(context.Background(), big.NewInt(100)); err != nil {
+		t.Fatalf("header call failed: %v", err)
+	}
+	if lagging.requests.Load() != before {
+		t.Fatal("call must be routed to the synced endpoint")
+	}
+}
+
+func TestPool_PrefersLowLatencyEndpoint(t *testing.T) {
+	slow := newRPCStandIn(t, 100)
+	fast := newRPCStandIn(t, 100)
+	slow.set(func(s *rpcStandIn) { s.delay = 50 * time.Millisecond })
+
+	pool, err := DialPool(slow.server.URL, fast.server.URL)
+	if err != nil {
+		t.Fatalf("dial pool failed: %v", err)
+	}
+	if _, err := pool.LatestBlockNumber(context.Background()); err != nil {
+		t.Fatalf("latest block failed: %v", err)
+	}
+
+	before := slow.requests.Load()
+	for i := 0; i < 3; i++ {
+		if _, err := pool.HeaderByNumber(context.Background(), big.NewInt(10)); err != nil {
+			t.Fatalf("header call failed: %v", err)
+		}
+	}
+	if slow.requests.Load() != before {
+		t.Fatal("calls must be routed to the low latency endpoint")
+	}
+}
+
+func TestPool_AllEndpointsDown(t *testing.T) {
+	a := newRPCStandIn(t, 100)
+	b := newRPCStandIn(t, 100)
+	a.set(func(s *rpcStandIn) { s.down = true })
+	b.set(func(s *rpcStandIn) { s.down = true })
+
+	pool, err := DialPool(a.server.URL, b.server.URL, a.server.URL)
+	if err != nil {
+		t.Fatalf("dial pool failed: %v", err)
+	}
+	if len(pool.Health()) != 2 {
+		t.Fatal("duplicate urls must be ignored")
+	}
+
+	if _, err := pool.LatestBlockNumber(context.Background()); err == nil {
+		t.Fatal("expected error when all endpoints are down")
+	}
+	_, err = pool.HeaderByNumber(context.Background(), big.NewInt(1))
+	if err == nil || !strings.Contains(err.Error(), "429") {
+		t.Fatalf("expected last endpoint error, got %v", err)
+	}
+
+	// 节点恢复后自动重新使用
+	b.set(func(s *rpcStandIn) { s.down = false })
+	if _, err := pool.HeaderByNumber(context.Background(), big.NewInt(1)); err != nil {
+		t.Fatalf("expected recovery after endpoint comes back, got %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// TxSender 发送交易所需的节点接口。
+// *ethclient.Client 和 go-ethereum 的模拟后端（ethclient/simulated）均满足该接口。
+type TxSender interface {
+	ChainID(ctx context.Context) (*big.Int, error)
+	BlockNumber(ctx context.Context) (uint64, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	// NonceAt 返回截至指定区块已打包的交易数，blockNumber 为 nil 时取最新区块。
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+	// TransactionReceipt 交易未打包时返回 ethereum.NotFound。
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
+// DialSender 连接用于广播交易的 RPC 节点。
+func DialSender(ctx context.Context, rpcURL string) (*ethclient.Client, error) {
+	rpcURL = strings.TrimSpace(rpcURL)
+	if rpcURL == "" {
+		return nil, errors.New("rpcURL is required")
+	}
+	c, err := ethclient.DialContext(ctx, rpcURL)
+	if err != nil {
+		return nil, fmt.Errorf("dial eth rpc failed: %w", err)
+	}
+	return c, nil
+}
+
+// IsReceiptNotFound 判断回执查询错误是否表示交易尚未打包。
+// 节点在交易索引构建期间返回 "transaction indexing is in progress"，同样视为未找到。
+func IsReceiptNotFound(err error) bool {
+	if err == nil {
+		return false
+	}
+	return errors.Is(err, ethereum.NotFound) || strings.Contains(err.Error(), "transaction indexing is in progress")
+}
+
+// txRejectedMessages 节点校验交易失败时返回的错误，此时交易不会进入交易池。
+var txRejectedMessages = []string{
+	"insufficient funds",
+	"intrinsic gas too low",
+	"invalid sender",
+	"exceeds block gas limit",
+	"max fee per gas less than block base fee",
+	"max priority fee per gas higher than max fee per gas",
+	"transaction underpriced",
+	"oversized data",
+	"negative value",
+}
+
+// IsTxRejected 判断广播错误是否表示节点明确拒绝了交易，拒绝的交易不会被打包，nonce 可以重新使用。
+// 超时、连接中断、"already known"、"nonce too low" 等错误下交易可能已经发出或已打包，返回 false；
+// "replacement transaction underpriced" 表示交易池中已有相同 nonce 的交易，同样返回 false。
+func IsTxRejected(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	if strings.Contains(msg, "replacement transaction underpriced") {
+		return false
+	}
+	for _, m := range txRejectedMessages {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+	return false
+}
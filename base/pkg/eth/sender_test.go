@@ -0,0 +1,32 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestIsTxRejected(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("insufficient funds for gas * price + value: balance 0, tx cost 1, overshot 1"), true},
+		{errors.New("intrinsic gas too low: gas 0, minimum needed 21000"), true},
+		{errors.New("invalid sender"), true},
+		{errors.New("max fee per gas less than block base fee: address 0x0, maxFeePerGas: 1, baseFee: 2"), true},
+		{errors.New("transaction underpriced: tip needed 1, tip permitted 0"), true},
+		{errors.New("replacement transaction underpriced"), false},
+		{errors.New("already known"), false},
+		{errors.New("nonce too low: next nonce 2, tx nonce 1"), false},
+		{fmt.Errorf("post rpc: %w", context.DeadlineExceeded), false},
+		{errors.New("read tcp 127.0.0.1:8545: connection reset by peer"), false},
+	}
+	for _, c := range cases {
+		if got := IsTxRejected(c.err); got != c.want {
+			t.Errorf("IsTxRejected(%v) = %v, want %v", c.err, got, c.want)
+		}
+	}
+}
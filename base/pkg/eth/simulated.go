@@ -0,0 +1,207 @@
+package eth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// erc20TransferTopic Transfer(address,address,uint256) 事件签名
+var erc20TransferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// 模拟链默认参数
+const (
+	defaultSimBlocksPerPoll = 3
+	defaultSimTxsPerBlock   = 8
+	simSenderCount          = 16
+	simBlockInterval        = 12 // 秒
+)
+
+// SimulationConfig 模拟链配置
+type SimulationConfig struct {
+	ChainID       int64
+	Seed          int64
+	Genesis       uint64           // 起始高度，低于该高度的区块不存在
+	BlocksPerPoll int              // 每次查询最新高度时链向前推进的区块数
+	TxsPerBlock   int              // 每个区块的交易数
+	Recipients    []common.Address // 转账接收方，每笔交易有一半概率转入其中之一
+	Tokens        []common.Address // ERC20 合约地址，为空时只生成原生 ETH 转账
+}
+
+// SimulatedChain 确定性的模拟链，实现 Client 接口。
+// 相同配置总是生成相同的区块、回执和转账；每次查询最新高度时链向前推进 BlocksPerPoll 个区块。
+type SimulatedChain struct {
+	*FakeClient
+
+	mu      sync.Mutex
+	cfg     SimulationConfig
+	signer  types.Signer
+	senders []*ecdsa.PrivateKey
+	nonces  []uint64
+	head    uint64
+	parent  common.Hash
+}
+
+// NewSimulatedChain 创建模拟链并生成起始区块。
+func NewSimulatedChain(cfg SimulationConfig) (*SimulatedChain, error) {
+	if cfg.ChainID <= 0 {
+		return nil, fmt.Errorf("invalid chainID: %d", cfg.ChainID)
+	}
+	if cfg.BlocksPerPoll <= 0 {
+		cfg.BlocksPerPoll = defaultSimBlocksPerPoll
+	}
+	if cfg.TxsPerBlock <= 0 {
+		cfg.TxsPerBlock = defaultSimTxsPerBlock
+	}
+
+	c := &SimulatedChain{
+		FakeClient: NewFakeClient(cfg.ChainID),
+		cfg:        cfg,
+		signer:     types.LatestSignerForChainID(big.NewInt(cfg.ChainID)),
+		nonces:     make([]uint64, simSenderCount),
+	}
+	for i := 0; i < simSenderCount; i++ {
+		seed := make([]byte, 16)
+		binary.BigEndian.PutUint64(seed, uint64(cfg.Seed))
+		binary.BigEndian.PutUint64(seed[8:], uint64(i))
+		key, err := crypto.ToECDSA(crypto.Keccak256(seed))
+		if err != nil {
+			return nil, fmt.Errorf("derive simulated sender failed: %w", err)
+		}
+		c.senders = append(c.senders, key)
+	}
+	// 模拟代币统一使用 18 位精度
+	for _, token := range cfg.Tokens {
+		c.SetCallResult(token, ERC20DecimalsSelector, common.LeftPadBytes([]byte{18}, 32))
+	}
+
+	if err := c.generate(cfg.Genesis); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// LatestBlockNumber 推进模拟链并返回最新高度。
+func (c *SimulatedChain) LatestBlockNumber(ctx context.Context) (uint64, error) {
+	c.mu.Lock()
+	target := c.head + uint64(c.cfg.BlocksPerPoll)
+	c.mu.Unlock()
+
+	for {
+		c.mu.Lock()
+		next := c.head + 1
+		c.mu.Unlock()
+		if next > target {
+			break
+		}
+		if err := c.generate(next); err != nil {
+			return 0, err
+		}
+	}
+	return c.FakeClient.LatestBlockNumber(ctx)
+}
+
+// generate 生成高度 n 的区块，区块内容只取决于配置和高度
+func (c *SimulatedChain) generate(n uint64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	rng := rand.New(rand.NewSource(c.cfg.Seed ^ int64(n)*0x9E3779B9))
+	txs := make([]*types.Transaction, 0, c.cfg.TxsPerBlock)
+	logs := make([][]*types.Log, 0, c.cfg.TxsPerBlock)
+	logIndex := uint(0)
+
+	for i := 0; i < c.cfg.TxsPerBlock; i++ {
+		senderIdx := rng.Intn(len(c.senders))
+		sender := c.senders[senderIdx]
+		from := crypto.PubkeyToAddress(sender.PublicKey)
+
+		recipient := common.BigToAddress(big.NewInt(rng.Int63()))
+		if len(c.cfg.Recipients) > 0 && rng.Intn(2) == 0 {
+			recipient = c.cfg.Recipients[rng.Intn(len(c.cfg.Recipients))]
+		}
+		// 0.001 - 1 个单位
+		amount := new(big.Int).Mul(big.NewInt(rng.Int63n(1000)+1), big.NewInt(1e15))
+
+		inner := &types.DynamicFeeTx{
+			ChainID:   big.NewInt(c.cfg.ChainID),
+			Nonce:     c.nonces[senderIdx],
+			GasTipCap: big.NewInt(1e9),
+			GasFeeCap: big.NewInt(30e9),
+			Gas:       21000,
+			To:        &recipient,
+			Value:     amount,
+		}
+		var txLogs []*types.Log
+		if len(c.cfg.Tokens) > 0 && rng.Intn(2) == 0 {
+			token := c.cfg.Tokens[rng.Intn(len(c.cfg.Tokens))]
+			inner.To, inner.Value, inner.Gas, inner.Data = &token, big.NewInt(0), 65000, ERC20TransferData(recipient, amount)
+			txLogs = []*types.Log{{
+				Address: token,
+				Topics: []common.Hash{
+					erc20TransferTopic,
+					common.BytesToHash(from.Bytes()),
+					common.BytesToHash(recipient.Bytes()),
+				},
+				Data:  common.LeftPadBytes(amount.Bytes(), 32),
+				Index: logIndex,
+			}}
+			logIndex++
+		}
+
+		tx, err := types.SignNewTx(sender, c.signer, inner)
+		if err != nil {
+			return fmt.Errorf("sign simulated tx failed: %w", err)
+		}
+		c.nonces[senderIdx]++
+		txs = append(txs, tx)
+		logs = append(logs, txLogs)
+	}
+
+	receipts := make([]*types.Receipt, 0, len(txs))
+	for i, tx := range txs {
+		receipts = append(receipts, &types.Receipt{
+			Type:              tx.Type(),
+			Status:            types.ReceiptStatusSuccessful,
+			CumulativeGasUsed: uint64(i+1) * tx.Gas(),
+			GasUsed:           tx.Gas(),
+			Logs:              logs[i],
+			TxHash:            tx.Hash(),
+			TransactionIndex:  uint(i),
+		})
+	}
+
+	header := &types.Header{
+		ParentHash: c.parent,
+		Number:     new(big.Int).SetUint64(n),
+		GasLimit:   30_000_000,
+		Time:       n * simBlockInterval,
+		Difficulty: big.NewInt(0),
+		BaseFee:    big.NewInt(1e9),
+	}
+	block := types.NewBlock(header, &types.Body{Transactions: txs}, receipts, trie.NewStackTrie(nil))
+	for _, r := range receipts {
+		r.BlockHash = block.Hash()
+		r.BlockNumber = block.Number()
+		for _, lg := range r.Logs {
+			lg.BlockNumber = n
+			lg.BlockHash = block.Hash()
+			lg.TxHash = r.TxHash
+			lg.TxIndex = r.TransactionIndex
+		}
+	}
+
+	c.AddBlock(block, receipts)
+	c.head = n
+	c.parent = block.Hash()
+	return nil
+}
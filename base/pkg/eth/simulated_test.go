@@ -0,0 +1,95 @@
+package eth
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func newTestSimulatedChain(t *testing.T) *SimulatedChain {
+	t.Helper()
+
+	c, err := NewSimulatedChain(SimulationConfig{
+		ChainID:    11155111,
+		Seed:       42,
+		Genesis:    100,
+		Recipients: []common.Address{common.HexToAddress("0xabc0000000000000000000000000000000000001")},
+		Tokens:     []common.Address{common.HexToAddress("0xdac17f958d2ee523a2206206994597c13d831ec7")},
+	})
+	if err != nil {
+		t.Fatalf("create simulated chain failed: %v", err)
+	}
+	return c
+}
+
+func TestSimulatedChain_Deterministic(t *testing.T) {
+	ctx := context.Background()
+	a, b := newTestSimulatedChain(t), newTestSimulatedChain(t)
+
+	for i := 0; i < 2; i++ {
+		headA, err := a.LatestBlockNumber(ctx)
+		if err != nil {
+			t.Fatalf("latest block failed: %v", err)
+		}
+		headB, _ := b.LatestBlockNumber(ctx)
+		if headA != headB || headA != 100+uint64(defaultSimBlocksPerPoll*(i+1)) {
+			t.Fatalf("unexpected heads: %d, %d", headA, headB)
+		}
+	}
+
+	for n := uint64(100); n <= 106; n++ {
+		blockA, err := a.BlockByNumber(ctx, new(big.Int).SetUint64(n))
+		if err != nil {
+			t.Fatalf("block %d failed: %v", n, err)
+		}
+		blockB, _ := b.BlockByNumber(ctx, new(big.Int).SetUint64(n))
+		if blockA.Hash() != blockB.Hash() {
+			t.Fatalf("block %d differs between chains with the same seed", n)
+		}
+		if n > 100 {
+			parent, _ := a.HeaderByNumber(ctx, new(big.Int).SetUint64(n-1))
+			if blockA.ParentHash() != parent.Hash() {
+				t.Fatalf("block %d does not link to its parent", n)
+			}
+		}
+	}
+}
+
+func TestSimulatedChain_ProducesSignedTransfersAndLogs(t *testing.T) {
+	ctx := context.Background()
+	c := newTestSimulatedChain(t)
+	if _, err := c.LatestBlockNumber(ctx); err != nil {
+		t.Fatalf("latest block failed: %v", err)
+	}
+
+	var native, tokenLogs int
+	for n := uint64(100); n <= 103; n++ {
+		number := new(big.Int).SetUint64(n)
+		block, _ := c.BlockByNumber(ctx, number)
+		receipts, err := c.BlockReceiptsByNumber(ctx, number)
+		if err != nil || len(receipts) != len(block.Transactions()) {
+			t.Fatalf("block %d receipts mismatch: %v", n, err)
+		}
+		for i, tx := range block.Transactions() {
+			if _, err := c.TransactionSender(ctx, tx, block.Hash(), uint(i)); err != nil {
+				t.Fatalf("sender of %s not recoverable: %v", tx.Hash().Hex(), err)
+			}
+			if receipts[i].TxHash != tx.Hash() || receipts[i].BlockHash != block.Hash() {
+				t.Fatalf("receipt %d does not match tx", i)
+			}
+			if tx.Value().Sign() > 0 {
+				native++
+			}
+			for _, lg := range receipts[i].Logs {
+				if lg.Topics[0] == erc20TransferTopic {
+					tokenLogs++
+				}
+			}
+		}
+	}
+	if native == 0 || tokenLogs == 0 {
+		t.Fatalf("expected both native and token transfers, got %d and %d", native, tokenLogs)
+	}
+}
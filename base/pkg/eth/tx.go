@@ -0,0 +1,65 @@
+package eth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// LoadKeystore 读取并解密 keystore 文件，密码文件为空时使用空密码，密码末尾的换行符会被去掉。
+func LoadKeystore(keystoreFile, passwordFile string) (*ecdsa.PrivateKey, error) {
+	if keystoreFile == "" {
+		return nil, errors.New("keystore file is required")
+	}
+	keyJSON, err := os.ReadFile(keystoreFile)
+	if err != nil {
+		return nil, fmt.Errorf("read keystore file failed: %w", err)
+	}
+	var password string
+	if passwordFile != "" {
+		raw, err := os.ReadFile(passwordFile)
+		if err != nil {
+			return nil, fmt.Errorf("read keystore password file failed: %w", err)
+		}
+		password = strings.TrimRight(string(raw), "\r\n")
+	}
+	key, err := keystore.DecryptKey(keyJSON, password)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt keystore failed: %w", err)
+	}
+	return key.PrivateKey, nil
+}
+
+// SuggestFees 计算 EIP-1559 手续费：maxFeePerGas = 2 * baseFee + tip。
+func SuggestFees(ctx context.Context, sender TxSender) (tipCap, feeCap *big.Int, err error) {
+	head, err := sender.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get latest header failed: %w", err)
+	}
+	tipCap, err = sender.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("suggest gas tip cap failed: %w", err)
+	}
+
+	baseFee := head.BaseFee
+	if baseFee == nil {
+		baseFee = new(big.Int)
+	}
+	feeCap = new(big.Int).Add(new(big.Int).Mul(baseFee, big.NewInt(2)), tipCap)
+	return tipCap, feeCap, nil
+}
+
+// MaxFeePerGas 将以 gwei 配置的 maxFeePerGas 上限换算为 wei，未配置（<= 0）时返回 nil。
+func MaxFeePerGas(gwei int64) *big.Int {
+	if gwei <= 0 {
+		return nil
+	}
+	return new(big.Int).Mul(big.NewInt(gwei), big.NewInt(params.GWei))
+}
@@ -0,0 +1,42 @@
+package eth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestLoadKeystore(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	keyJSON, err := keystore.EncryptKey(&keystore.Key{Address: address, PrivateKey: key},
+		"secret", keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	keyFile, passwordFile := filepath.Join(dir, "key.json"), filepath.Join(dir, "password")
+	if err := os.WriteFile(keyFile, keyJSON, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(passwordFile, []byte("secret\r\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LoadKeystore(keyFile, passwordFile)
+	if err != nil {
+		t.Fatalf("LoadKeystore failed: %v", err)
+	}
+	if crypto.PubkeyToAddress(got.PublicKey) != address {
+		t.Fatal("decrypted key does not match")
+	}
+	if _, err := LoadKeystore(keyFile, ""); err == nil {
+		t.Fatal("expected error for wrong password")
+	}
+}
@@ -0,0 +1,135 @@
+package hdwallet
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tyler-smith/go-bip32"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// ExternalChain BIP-44 外部链（收款地址）序号
+const ExternalChain uint32 = 0
+
+// AccountPath ETH 第 0 个账户的 BIP-44 路径，xpub 应在该层级导出
+const AccountPath = "m/44'/60'/0'"
+
+// XPub 账户层级的扩展公钥，只能派生地址，不持有私钥
+type XPub struct {
+	key *bip32.Key
+}
+
+// ParseXPub 解析 Base58 编码的扩展公钥
+func ParseXPub(xpub string) (*XPub, error) {
+	key, err := bip32.B58Deserialize(strings.TrimSpace(xpub))
+	if err != nil {
+		return nil, fmt.Errorf("parse xpub failed: %w", err)
+	}
+	if key.IsPrivate {
+		return nil, errors.New("extended private key is not allowed, export the xpub instead")
+	}
+	return &XPub{key: key}, nil
+}
+
+// DeriveAddress 派生外部链上第 index 个地址（路径 <xpub>/0/index），返回 EIP-55 校验格式地址
+func (x *XPub) DeriveAddress(index uint32) (string, error) {
+	if index >= bip32.FirstHardenedChild {
+		return "", fmt.Errorf("invalid address index: %d", index)
+	}
+
+	chain, err := x.key.NewChildKey(ExternalChain)
+	if err != nil {
+		return "", fmt.Errorf("derive external chain failed: %w", err)
+	}
+	child, err := chain.NewChildKey(index)
+	if err != nil {
+		return "", fmt.Errorf("derive address %d failed: %w", index, err)
+	}
+
+	pub, err := crypto.DecompressPubkey(child.Key)
+	if err != nil {
+		return "", fmt.Errorf("decompress public key failed: %w", err)
+	}
+	return crypto.PubkeyToAddress(*pub).Hex(), nil
+}
+
+// XPrv 账户层级的扩展私钥，用于签名充值地址发出的交易（例如归集）
+type XPrv struct {
+	key *bip32.Key
+}
+
+// ParseXPrv 解析 Base58 编码的扩展私钥
+func ParseXPrv(xprv string) (*XPrv, error) {
+	key, err := bip32.B58Deserialize(strings.TrimSpace(xprv))
+	if err != nil {
+		return nil, fmt.Errorf("parse xprv failed: %w", err)
+	}
+	if !key.IsPrivate {
+		return nil, errors.New("extended public key cannot sign, export the xprv instead")
+	}
+	return &XPrv{key: key}, nil
+}
+
+// DerivePrivateKey 派生外部链上第 index 个地址的私钥（路径 <xprv>/0/index），
+// 与 XPub.DeriveAddress 派生的地址一一对应
+func (x *XPrv) DerivePrivateKey(index uint32) (*ecdsa.PrivateKey, error) {
+	if index >= bip32.FirstHardenedChild {
+		return nil, fmt.Errorf("invalid address index: %d", index)
+	}
+
+	chain, err := x.key.NewChildKey(ExternalChain)
+	if err != nil {
+		return nil, fmt.Errorf("derive external chain failed: %w", err)
+	}
+	child, err := chain.NewChildKey(index)
+	if err != nil {
+		return nil, fmt.Errorf("derive key %d failed: %w", index, err)
+	}
+	key, err := crypto.ToECDSA(child.Key)
+	if err != nil {
+		return nil, fmt.Errorf("convert private key %d failed: %w", index, err)
+	}
+	return key, nil
+}
+
+// XPubFromMnemonic 由助记词导出 AccountPath 层级的扩展公钥，用于测试和离线生成 xpub
+func XPubFromMnemonic(mnemonic, password string) (string, error) {
+	key, err := accountKey(mnemonic, password)
+	if err != nil {
+		return "", err
+	}
+	return key.PublicKey().B58Serialize(), nil
+}
+
+// XPrvFromMnemonic 由助记词导出 AccountPath 层级的扩展私钥，用于测试和离线生成 xprv
+func XPrvFromMnemonic(mnemonic, password string) (string, error) {
+	key, err := accountKey(mnemonic, password)
+	if err != nil {
+		return "", err
+	}
+	return key.B58Serialize(), nil
+}
+
+// accountKey 由助记词派生 AccountPath 层级的扩展私钥
+func accountKey(mnemonic, password string) (*bip32.Key, error) {
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return nil, errors.New("invalid mnemonic")
+	}
+
+	master, err := bip32.NewMasterKey(bip39.NewSeed(mnemonic, password))
+	if err != nil {
+		return nil, fmt.Errorf("create master key failed: %w", err)
+	}
+
+	key := master
+	for _, idx := range []uint32{44, 60, 0} {
+		key, err = key.NewChildKey(bip32.FirstHardenedChild + idx)
+		if err != nil {
+			return nil, fmt.Errorf("derive account key failed: %w", err)
+		}
+	}
+	return key, nil
+}
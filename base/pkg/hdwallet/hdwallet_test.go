@@ -0,0 +1,69 @@
+package hdwallet
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestDeriveAddress(t *testing.T) {
+	xpub, err := XPubFromMnemonic(testMnemonic, "")
+	if err != nil {
+		t.Fatalf("export xpub failed: %v", err)
+	}
+	key, err := ParseXPub(xpub)
+	if err != nil {
+		t.Fatalf("parse xpub failed: %v", err)
+	}
+
+	// m/44'/60'/0'/0/i 的已知地址
+	want := map[uint32]string{
+		0: "0x9858EfFD232B4033E47d90003D41EC34EcaEda94",
+		1: "0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0",
+	}
+	for index, addr := range want {
+		got, err := key.DeriveAddress(index)
+		if err != nil {
+			t.Fatalf("derive %d failed: %v", index, err)
+		}
+		if got != addr {
+			t.Fatalf("address %d = %s, want %s", index, got, addr)
+		}
+	}
+}
+
+func TestParseXPub_RejectsPrivateKey(t *testing.T) {
+	xprv := "xprv9s21ZrQH143K3QTDL4LXw2F7HEK3wJUD2nW2nRk4stbPy6cq3jPPqjiChkVvvNKmPGJxWUtg6LnF5kejMRNNU3TGtRBeJgk33yuGBxrMPHi"
+	if _, err := ParseXPub(xprv); err == nil {
+		t.Fatal("expected xprv to be rejected")
+	}
+}
+
+func TestDerivePrivateKey_MatchesXPub(t *testing.T) {
+	xprv, err := XPrvFromMnemonic(testMnemonic, "")
+	if err != nil {
+		t.Fatalf("export xprv failed: %v", err)
+	}
+	key, err := ParseXPrv(xprv)
+	if err != nil {
+		t.Fatalf("parse xprv failed: %v", err)
+	}
+
+	priv, err := key.DerivePrivateKey(0)
+	if err != nil {
+		t.Fatalf("derive key failed: %v", err)
+	}
+	if got := crypto.PubkeyToAddress(priv.PublicKey).Hex(); got != "0x9858EfFD232B4033E47d90003D41EC34EcaEda94" {
+		t.Fatalf("derived key address = %s", got)
+	}
+
+	xpub, err := XPubFromMnemonic(testMnemonic, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseXPrv(xpub); err == nil {
+		t.Fatal("expected xpub to be rejected")
+	}
+}
@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// FormatUnits 将最小单位的整数字符串按精度转换为十进制字符串
+// 例如 FormatUnits("1500000", 6) 返回 "1.5"，全程使用整数运算，不经过浮点
+func FormatUnits(value string, decimals int) (string, error) {
+	if decimals < 0 {
+		return "", fmt.Errorf("invalid decimals: %d", decimals)
+	}
+
+	n, ok := new(big.Int).SetString(strings.TrimSpace(value), 10)
+	if !ok {
+		return "", fmt.Errorf("invalid integer amount: %q", value)
+	}
+
+	sign := ""
+	if n.Sign() < 0 {
+		sign = "-"
+		n.Neg(n)
+	}
+
+	digits := n.String()
+	if decimals == 0 {
+		return sign + digits, nil
+	}
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+	}
+
+	intPart := digits[:len(digits)-decimals]
+	fracPart := strings.TrimRight(digits[len(digits)-decimals:], "0")
+	if fracPart == "" {
+		return sign + intPart, nil
+	}
+	return sign + intPart + "." + fracPart, nil
+}
+
+// ScaleToDecimal 将最小单位的整数字符串按精度换算为可写入 DECIMAL(precision, scale) 的十进制字符串
+// 超出 scale 的小数位直接截断（只舍不入，保证不会多记金额），整数位超出 precision-scale 时返回错误
+func ScaleToDecimal(value string, decimals, precision, scale int) (string, error) {
+	if scale < 0 || precision <= scale {
+		return "", fmt.Errorf("invalid decimal(%d,%d)", precision, scale)
+	}
+
+	formatted, err := FormatUnits(value, decimals)
+	if err != nil {
+		return "", err
+	}
+
+	intPart, fracPart, _ := strings.Cut(formatted, ".")
+	if len(fracPart) > scale {
+		fracPart = strings.TrimRight(fracPart[:scale], "0")
+	}
+	if len(strings.TrimPrefix(intPart, "-")) > precision-scale {
+		return "", fmt.Errorf("amount %s overflows decimal(%d,%d)", formatted, precision, scale)
+	}
+
+	if fracPart == "" {
+		if intPart == "-0" {
+			return "0", nil
+		}
+		return intPart, nil
+	}
+	return intPart + "." + fracPart, nil
+}
+
+// ParseUnits 将十进制金额字符串按精度转换为最小单位的整数
+// 例如 ParseUnits("1.5", 6) 返回 1500000；小数位超过精度时返回错误，避免静默截断金额
+func ParseUnits(value string, decimals int) (*big.Int, error) {
+	if decimals < 0 {
+		return nil, fmt.Errorf("invalid decimals: %d", decimals)
+	}
+
+	s := strings.TrimSpace(value)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	if s == "" || s == "." {
+		return nil, fmt.Errorf("invalid decimal amount: %q", value)
+	}
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	fracPart = strings.TrimRight(fracPart, "0")
+	if len(fracPart) > decimals {
+		return nil, fmt.Errorf("amount %q has more than %d decimals", value, decimals)
+	}
+	if intPart == "" {
+		intPart = "0"
+	}
+
+	digits := intPart + fracPart + strings.Repeat("0", decimals-len(fracPart))
+	n, ok := new(big.Int).SetString(sign+digits, 10)
+	if !ok || strings.ContainsAny(digits, "+-") {
+		return nil, fmt.Errorf("invalid decimal amount: %q", value)
+	}
+	return n, nil
+}
@@ -0,0 +1,91 @@
+package utils
+
+import "testing"
+
+func TestFormatUnits(t *testing.T) {
+	cases := []struct {
+		value    string
+		decimals int
+		want     string
+	}{
+		{"1500000", 6, "1.5"},
+		{"1000000000000000000", 18, "1"},
+		{"1", 18, "0.000000000000000001"},
+		{"0", 18, "0"},
+		{"123", 0, "123"},
+		{"-2500", 3, "-2.5"},
+	}
+	for _, c := range cases {
+		got, err := FormatUnits(c.value, c.decimals)
+		if err != nil {
+			t.Fatalf("FormatUnits(%s, %d) failed: %v", c.value, c.decimals, err)
+		}
+		if got != c.want {
+			t.Fatalf("FormatUnits(%s, %d) = %s, want %s", c.value, c.decimals, got, c.want)
+		}
+	}
+
+	if _, err := FormatUnits("1.5", 6); err == nil {
+		t.Fatal("expected error for non-integer amount")
+	}
+}
+
+func TestScaleToDecimal(t *testing.T) {
+	cases := []struct {
+		value    string
+		decimals int
+		want     string
+	}{
+		// USDT 6 位精度
+		{"10000000", 6, "10"},
+		// 18 位精度不丢失最小单位
+		{"123456789012345678901", 18, "123.456789012345678901"},
+		// 超过 18 位小数的部分截断，不四舍五入
+		{"1999999999999999999999", 24, "0.001999999999999999"},
+		{"1", 24, "0"},
+	}
+	for _, c := range cases {
+		got, err := ScaleToDecimal(c.value, c.decimals, 36, 18)
+		if err != nil {
+			t.Fatalf("ScaleToDecimal(%s, %d) failed: %v", c.value, c.decimals, err)
+		}
+		if got != c.want {
+			t.Fatalf("ScaleToDecimal(%s, %d) = %s, want %s", c.value, c.decimals, got, c.want)
+		}
+	}
+
+	// 整数部分 19 位，超出 DECIMAL(36,18)
+	if _, err := ScaleToDecimal("1000000000000000000", 0, 36, 18); err == nil {
+		t.Fatal("expected overflow error")
+	}
+}
+
+func TestParseUnits(t *testing.T) {
+	cases := []struct {
+		value    string
+		decimals int
+		want     string
+	}{
+		{"1.5", 6, "1500000"},
+		{"10.000000000000000000", 18, "10000000000000000000"},
+		{"0.000000000000000001", 18, "1"},
+		{".25", 2, "25"},
+		{"7", 0, "7"},
+		{"-2.5", 3, "-2500"},
+	}
+	for _, c := range cases {
+		got, err := ParseUnits(c.value, c.decimals)
+		if err != nil {
+			t.Fatalf("ParseUnits(%s, %d) failed: %v", c.value, c.decimals, err)
+		}
+		if got.String() != c.want {
+			t.Fatalf("ParseUnits(%s, %d) = %s, want %s", c.value, c.decimals, got, c.want)
+		}
+	}
+
+	for _, value := range []string{"1.2345678", "abc", "1.-5", ""} {
+		if _, err := ParseUnits(value, 6); err == nil {
+			t.Fatalf("expected error for %q", value)
+		}
+	}
+}
@@ -36,6 +36,70 @@ CREATE TABLE `accounts` (
   KEY `idx_invitation_code` (`invitation_code`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='账户表';
 
+-- ----------------------------
+-- Table structure for asset_shortfalls
+-- ----------------------------
+DROP TABLE IF EXISTS `asset_shortfalls`;
+CREATE TABLE `asset_shortfalls` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `account_id` bigint NOT NULL COMMENT '账户ID',
+  `coin` varchar(16) NOT NULL COMMENT '币种',
+  `transaction_id` bigint NOT NULL COMMENT '被链重组扣回的充值记录ID',
+  `amount` decimal(36,18) NOT NULL COMMENT '可用余额不足、未能扣回的金额',
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_account_coin` (`account_id`,`coin`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='链重组扣回充值时的资产缺口，待人工处理';
+
+-- ----------------------------
+-- Table structure for block_cursors
+-- ----------------------------
+DROP TABLE IF EXISTS `block_cursors`;
+CREATE TABLE `block_cursors` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `chain_id` bigint NOT NULL COMMENT '链ID',
+  `name` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL COMMENT '游标名称',
+  `height` bigint NOT NULL COMMENT '最后完整处理的区块高度',
+  `block_hash` varchar(128) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL DEFAULT '' COMMENT '最后完整处理的区块哈希',
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_chain_name` (`chain_id`,`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='区块处理游标';
+
+-- ----------------------------
+-- Table structure for block_hashes
+-- ----------------------------
+DROP TABLE IF EXISTS `block_hashes`;
+CREATE TABLE `block_hashes` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `chain_id` bigint NOT NULL COMMENT '链ID',
+  `height` bigint NOT NULL COMMENT '区块高度',
+  `block_hash` varchar(128) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL COMMENT '区块哈希',
+  `parent_hash` varchar(128) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL COMMENT '父区块哈希',
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_chain_height` (`chain_id`,`height`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='最近已处理区块哈希（链重组检测窗口）';
+
+-- ----------------------------
+-- Table structure for chain_reorgs
+-- ----------------------------
+DROP TABLE IF EXISTS `chain_reorgs`;
+CREATE TABLE `chain_reorgs` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `chain_id` bigint NOT NULL COMMENT '链ID',
+  `detected_height` bigint NOT NULL COMMENT '发现父哈希不一致的区块高度',
+  `common_ancestor` bigint NOT NULL COMMENT '共同祖先区块高度',
+  `depth` bigint NOT NULL COMMENT '回滚区块数量',
+  `old_hash` varchar(128) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL COMMENT '本地记录的旧链区块哈希',
+  `new_hash` varchar(128) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL COMMENT '主链区块哈希',
+  `reverted_count` bigint DEFAULT '0' COMMENT '标记为回滚的交易数量',
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_chain_created` (`chain_id`,`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='链重组审计记录';
+
 -- ----------------------------
 -- Table structure for coin_configs
 -- ----------------------------
@@ -45,6 +109,9 @@ CREATE TABLE `coin_configs` (
   `coin` varchar(16) NOT NULL COMMENT '币种：BTC, ETH, USDT',
   `coin_address` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci DEFAULT NULL COMMENT '合约地址（ERC20）',
   `min_deposit` decimal(36,18) NOT NULL COMMENT '最小充值金额',
+  `min_withdraw` decimal(36,18) NOT NULL DEFAULT '0.000000000000000000' COMMENT '最小提现金额',
+  `withdraw_fee` decimal(36,18) NOT NULL DEFAULT '0.000000000000000000' COMMENT '提现手续费，与提现金额一起从可用余额冻结',
+  `decimals` tinyint unsigned NOT NULL DEFAULT '18' COMMENT '链上精度，需与合约 decimals() 一致，提现金额的小数位不能超过该精度',
   `status` tinyint DEFAULT '1' COMMENT '状态：1-启用，2-禁用',
   `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
   `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
@@ -53,6 +120,66 @@ CREATE TABLE `coin_configs` (
   UNIQUE KEY `uk_coin_address` (`coin_address`)
 ) ENGINE=InnoDB AUTO_INCREMENT=4 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='币配置表';
 
+-- ----------------------------
+-- Table structure for contract_events
+-- ----------------------------
+DROP TABLE IF EXISTS `contract_events`;
+CREATE TABLE `contract_events` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `chain_id` bigint NOT NULL COMMENT '链ID',
+  `tx_hash` varchar(128) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL COMMENT '交易哈希',
+  `log_index` int NOT NULL COMMENT '日志在区块内的序号',
+  `block_number` bigint NOT NULL COMMENT '区块高度',
+  `contract_address` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL COMMENT '合约地址',
+  `event_name` varchar(64) NOT NULL COMMENT '事件名称',
+  `signature` varchar(255) NOT NULL COMMENT '事件签名',
+  `args` json NOT NULL COMMENT '具名参数，整数以字符串保存',
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_chain_tx_log` (`chain_id`,`tx_hash`,`log_index`),
+  KEY `idx_contract_event` (`contract_address`,`event_name`),
+  KEY `idx_chain_block` (`chain_id`,`block_number`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='按 ABI 解码的合约事件';
+
+-- ----------------------------
+-- Table structure for deposit_addresses
+-- ----------------------------
+DROP TABLE IF EXISTS `deposit_addresses`;
+CREATE TABLE `deposit_addresses` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `account_id` bigint NOT NULL COMMENT '账户ID',
+  `address` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL COMMENT '充值地址',
+  `derivation_index` int unsigned NOT NULL COMMENT '派生序号，地址路径为 m/44\'/60\'/0\'/0/derivation_index',
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_account_id` (`account_id`),
+  UNIQUE KEY `uk_address` (`address`),
+  UNIQUE KEY `uk_derivation_index` (`derivation_index`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='账户充值地址（HD 派生）';
+
+-- ----------------------------
+-- Table structure for deposit_rejections
+-- ----------------------------
+DROP TABLE IF EXISTS `deposit_rejections`;
+CREATE TABLE `deposit_rejections` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `tx_hash` varchar(128) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL COMMENT '交易哈希',
+  `event_index` int NOT NULL DEFAULT '-1' COMMENT '事件序号：ERC20 为日志序号，原生 ETH 为 -1',
+  `block_number` bigint NOT NULL COMMENT '区块高度',
+  `coin` varchar(16) NOT NULL COMMENT '币种',
+  `coin_address` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci DEFAULT NULL COMMENT '合约地址（ERC20）',
+  `amount` decimal(36,18) NOT NULL COMMENT '金额',
+  `from_address` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL COMMENT '发送地址',
+  `to_address` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL COMMENT '接收地址',
+  `reason` varchar(32) NOT NULL COMMENT '拒绝原因：coin_disabled, below_min_deposit, invalid_amount',
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_tx_event` (`tx_hash`,`event_index`),
+  KEY `idx_to_address` (`to_address`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='未通过币种规则的充值记录';
+
 -- ----------------------------
 -- Table structure for klines
 -- ----------------------------
@@ -253,6 +380,33 @@ CREATE TABLE `spot_trades` (
   KEY `idx_order_id` (`order_id`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='成交记录';
 
+-- ----------------------------
+-- Table structure for sweep_transactions
+-- ----------------------------
+DROP TABLE IF EXISTS `sweep_transactions`;
+CREATE TABLE `sweep_transactions` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `chain_id` bigint NOT NULL COMMENT '链ID',
+  `kind` tinyint NOT NULL COMMENT '类型：1-归集，2-gas补充',
+  `coin` varchar(16) NOT NULL COMMENT '币种，gas补充为 ETH',
+  `coin_address` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci DEFAULT NULL COMMENT '合约地址（ERC20）',
+  `amount` decimal(36,18) NOT NULL COMMENT '金额',
+  `from_address` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL COMMENT '发送地址：归集为充值地址，gas补充为gas钱包',
+  `to_address` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL COMMENT '接收地址：归集为热钱包，gas补充为充值地址',
+  `deposit_address` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL COMMENT '涉及的充值地址',
+  `tx_hash` varchar(128) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL COMMENT '交易哈希',
+  `nonce` bigint NOT NULL COMMENT '交易 nonce',
+  `gas` decimal(36,18) DEFAULT '0.000000000000000000' COMMENT '以 ETH 计的 gas 费用',
+  `block_number` bigint DEFAULT NULL COMMENT '区块号',
+  `status` tinyint NOT NULL DEFAULT '0' COMMENT '状态：0-待确认，1-成功，2-失败',
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_tx_hash` (`tx_hash`),
+  KEY `idx_deposit_status` (`deposit_address`,`status`),
+  KEY `idx_status` (`status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='充值地址归集交易表，与用户充值分开记录';
+
 -- ----------------------------
 -- Table structure for trading_pairs
 -- ----------------------------
@@ -278,8 +432,10 @@ CREATE TABLE `trading_pairs` (
 DROP TABLE IF EXISTS `transactions`;
 CREATE TABLE `transactions` (
   `id` bigint NOT NULL AUTO_INCREMENT,
+  `chain_id` bigint NOT NULL DEFAULT '0' COMMENT '链ID：充值为来源链，提现为 0',
   `block_number` bigint DEFAULT NULL COMMENT '区块号',
-  `tx_hash` varchar(128) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL COMMENT '交易哈希',
+  `tx_hash` varchar(128) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci DEFAULT NULL COMMENT '交易哈希（提现签名前为空）',
+  `event_index` int NOT NULL DEFAULT '-1' COMMENT '事件序号：ERC20 为日志序号，原生 ETH 为 -1',
   `tx_type` tinyint DEFAULT '0' COMMENT '状态：0-未知，1-充值:deposits，2-提现:withdrawals',
   `account_id` bigint NOT NULL COMMENT '账户ID',
   `coin` varchar(16) NOT NULL COMMENT '币种',
@@ -289,13 +445,17 @@ CREATE TABLE `transactions` (
   `fee` decimal(36,18) DEFAULT '0.000000000000000000' COMMENT '手续费',
   `from_address` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci DEFAULT NULL COMMENT '发送地址',
   `to_address` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci DEFAULT NULL COMMENT '接收地址',
+  `nonce` bigint DEFAULT NULL COMMENT '提现交易 nonce',
   `confirmations` int DEFAULT '0' COMMENT '确认数',
-  `status` tinyint DEFAULT '0' COMMENT '状态：0-待确认，1-成功，2-失败',
+  `status` tinyint DEFAULT '0' COMMENT '状态：0-待确认，1-成功，2-失败，3-已回滚，4-处理中，5-待审核，6-已拒绝',
+  `risk_reason` varchar(255) DEFAULT NULL COMMENT '提现触发的风控规则，多个以逗号分隔',
   `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
   `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
   PRIMARY KEY (`id`),
-  UNIQUE KEY `tx_hash` (`tx_hash`),
+  UNIQUE KEY `uk_chain_tx_event` (`chain_id`,`tx_hash`,`event_index`),
+  KEY `idx_chain_block` (`chain_id`,`block_number`),
   KEY `idx_account_id` (`account_id`),
+  KEY `idx_account_type_created` (`account_id`,`tx_type`,`created_at`),
   KEY `idx_tx_hash` (`tx_hash`),
   KEY `idx_status` (`status`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='充值提现交易表';
@@ -309,7 +469,7 @@ CREATE TABLE `user_assets` (
   `account_id` bigint NOT NULL COMMENT '账户ID',
   `coin` varchar(16) NOT NULL COMMENT '币种',
   `total` decimal(36,18) DEFAULT '0.000000000000000000' COMMENT '总资产',
-  `freeze` decimal(36,18) DEFAULT '0.000000000000000000' COMMENT '冻结资产（订单占用）',
+  `freeze` decimal(36,18) DEFAULT '0.000000000000000000' COMMENT '冻结资产（订单和提现占用）',
   `available` decimal(36,18) DEFAULT '0.000000000000000000' COMMENT '可用资产 = total - freeze',
   `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
   `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
@@ -318,4 +478,57 @@ CREATE TABLE `user_assets` (
   KEY `idx_account_id` (`account_id`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='用户资产表';
 
+-- ----------------------------
+-- Table structure for wallet_balance_snapshots
+-- ----------------------------
+DROP TABLE IF EXISTS `wallet_balance_snapshots`;
+CREATE TABLE `wallet_balance_snapshots` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `coin` varchar(16) NOT NULL COMMENT '币种',
+  `wallet_address` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL COMMENT '热钱包地址',
+  `balance` decimal(36,18) NOT NULL COMMENT '链上余额',
+  `pending_withdrawals` decimal(36,18) NOT NULL DEFAULT '0.000000000000000000' COMMENT '待审核、待处理和尚未打包的处理中提现金额合计',
+  `floor` decimal(36,18) NOT NULL DEFAULT '0.000000000000000000' COMMENT '覆盖提现后需保留的最低余额',
+  `ceiling` decimal(36,18) DEFAULT NULL COMMENT '余额上限，为空表示不限制',
+  `status` tinyint NOT NULL DEFAULT '0' COMMENT '状态：0-正常，1-低于下限，2-高于上限',
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_coin_created` (`coin`,`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='热钱包余额快照表';
+
+-- ----------------------------
+-- Table structure for wallet_nonces
+-- ----------------------------
+DROP TABLE IF EXISTS `wallet_nonces`;
+CREATE TABLE `wallet_nonces` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `chain_id` bigint NOT NULL COMMENT '链ID',
+  `address` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL COMMENT '发送地址',
+  `next_nonce` bigint NOT NULL COMMENT '下一个可分配的 nonce',
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_chain_address` (`chain_id`,`address`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='热钱包 nonce 分配表';
+
+-- ----------------------------
+-- Table structure for withdrawal_attempts
+-- ----------------------------
+DROP TABLE IF EXISTS `withdrawal_attempts`;
+CREATE TABLE `withdrawal_attempts` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `withdrawal_id` bigint NOT NULL COMMENT '提现记录ID（transactions.id）',
+  `nonce` bigint NOT NULL COMMENT '交易 nonce，同一提现的所有尝试相同',
+  `tx_hash` varchar(128) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL COMMENT '交易哈希',
+  `kind` tinyint NOT NULL DEFAULT '0' COMMENT '类型：0-原始交易，1-加价替换，2-取消（0 金额转给自己）',
+  `gas_tip_cap` bigint unsigned NOT NULL COMMENT 'maxPriorityFeePerGas（wei）',
+  `gas_fee_cap` bigint unsigned NOT NULL COMMENT 'maxFeePerGas（wei）',
+  `status` tinyint NOT NULL DEFAULT '0' COMMENT '状态：0-待打包，1-已打包，2-已被替换，3-广播失败',
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_tx_hash` (`tx_hash`),
+  KEY `idx_withdrawal_id` (`withdrawal_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='提现交易广播记录（含加价替换和取消）';
+
 SET FOREIGN_KEY_CHECKS = 1;
@@ -18,7 +18,13 @@ import (
 var configFile = flag.String("f", "etc/processor.yaml", "配置文件路径")
 
 // main 程序入口函数
+// 子命令 backfill 用于回填历史区块：processor backfill -f etc/processor.yaml --from N --to M
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "backfill" {
+		runBackfill(os.Args[2:])
+		return
+	}
+
 	flag.Parse()
 
 	var c config.Config
@@ -31,7 +37,12 @@ func main() {
 	defer cancel()
 
 	processorService := service.NewProcessorService(ctx, c)
-	processorService.Start()
+	if err := processorService.Start(); err != nil {
+		fmt.Printf("数据处理服务启动失败: %v\n", err)
+		logger.Error("数据处理服务启动失败: %v", err)
+		processorService.Stop()
+		os.Exit(1)
+	}
 
 	fmt.Println("数据处理服务启动成功")
 	logger.Info("数据处理服务启动成功")
@@ -48,3 +59,31 @@ func main() {
 	fmt.Println("数据处理服务已关闭")
 	logger.Info("数据处理服务已关闭")
 }
+
+// runBackfill 回填指定区间的历史区块，中断后以相同参数重新运行即可续跑
+func runBackfill(args []string) {
+	fs := flag.NewFlagSet("backfill", flag.ExitOnError)
+	file := fs.String("f", "etc/processor.yaml", "配置文件路径")
+	from := fs.Int64("from", -1, "起始区块高度（含）")
+	to := fs.Int64("to", -1, "结束区块高度（含）")
+	_ = fs.Parse(args)
+
+	if *from < 0 || *to < *from {
+		fmt.Println("用法: processor backfill -f etc/processor.yaml --from N --to M")
+		os.Exit(2)
+	}
+
+	var c config.Config
+	baseconfig.MustLoadConfig(*file, &c)
+	logger.InitLogger("processor-backfill")
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := service.RunBackfill(ctx, c, *from, *to); err != nil {
+		fmt.Printf("回填失败: %v\n", err)
+		logger.Error("回填失败: %v", err)
+		os.Exit(1)
+	}
+	fmt.Println("回填完成")
+}
@@ -1,5 +1,11 @@
 package config
 
+// 链数据来源
+const (
+	ChainModeRPC      = "rpc"      // 连接 RPCURL/RPCURLs 配置的节点
+	ChainModeSimulate = "simulate" // 本地确定性模拟链，仅用于开发和演示
+)
+
 // Config 数据处理服务配置结构
 type Config struct {
 	Name             string `json:"Name"`
@@ -8,11 +14,23 @@ type Config struct {
 
 	// 链配置
 	Chain struct {
-		RPCURL            string `json:"RPCURL"`
-		ChainID           int64  `json:"ChainID"`
-		StartHeight       int64  `json:"StartHeight"`
-		Confirmations     int64  `json:"Confirmations"`
-		MaxBlocksPerRound int64  `json:"MaxBlocksPerRound"`
+		Mode              string   `json:"Mode,default=rpc,options=rpc|simulate"` // rpc: 连接真实节点；simulate: 使用本地确定性模拟链
+		RPCURL            string   `json:"RPCURL,optional"`
+		RPCURLs           []string `json:"RPCURLs,optional"`         // 备用 RPC 节点，与 RPCURL 组成节点池按健康状况自动切换
+		WSURL             string   `json:"WSURL,optional"`           // WebSocket 节点，配置后订阅 newHeads 触发区块处理，订阅断开时回退到 Interval 轮询
+		HeadDebounce      int      `json:"HeadDebounce,default=500"` // 合并连续新区块触发的毫秒数
+		ChainID           int64    `json:"ChainID"`
+		StartHeight       int64    `json:"StartHeight"`
+		Confirmations     int64    `json:"Confirmations"`
+		MaxBlocksPerRound int64    `json:"MaxBlocksPerRound"`
+		ReorgWindow       int64    `json:"ReorgWindow,default=64"` // 保留的最近区块哈希数量，决定可处理的最大重组深度
+
+		// 模拟链配置，仅 Mode=simulate 时生效
+		Simulation struct {
+			Seed          int64 `json:"Seed,default=1"`          // 相同种子生成相同的区块和转账
+			BlocksPerPoll int   `json:"BlocksPerPoll,default=3"` // 每次查询最新高度时出块数
+			TxsPerBlock   int   `json:"TxsPerBlock,default=8"`   // 每个区块的交易数
+		} `json:"Simulation,optional"`
 	} `json:"Chain"`
 
 	// 区块解析任务配置
@@ -22,11 +40,45 @@ type Config struct {
 		ParseTx         bool              `json:"ParseTx"`
 		ParseEvent      bool              `json:"ParseEvent"`
 		ParseWorkers    int               `json:"ParseWorkers"`
+		ParseRetries    int               `json:"ParseRetries,default=3"`      // 单笔交易解析失败后的重试次数
+		ParseRetryDelay int               `json:"ParseRetryDelay,default=200"` // 首次重试前的等待毫秒数，之后每次翻倍
+		TraceInternal   bool              `json:"TraceInternal,optional"`      // 通过 debug_traceBlockByNumber 解析合约内部转出的 ETH
 		TargetAddresses []string          `json:"TargetAddresses"`
-		TrackedAssets   []string          `json:"TrackedAssets"`
-		TokenContracts  map[string]string `json:"TokenContracts"`
+		TrackedAssets   []string          `json:"TrackedAssets,optional"`  // 仅未配置数据库时使用，否则取自 coin_configs
+		TokenContracts  map[string]string `json:"TokenContracts,optional"` // 仅未配置数据库时使用，否则取自 coin_configs
+		TokenDecimals   map[string]int    `json:"TokenDecimals,optional"`  // symbol -> 精度，覆盖合约 decimals() 的查询结果
+		// 转账收手续费的代币合约，按 balanceOf 在区块前后的差额校验实际到账，需要节点保留历史状态
+		FeeOnTransferTokens []string `json:"FeeOnTransferTokens,optional"`
+		// 内部钱包地址（热钱包、归集 gas 钱包），从这些地址转入的转账不作为充值
+		InternalAddresses []string `json:"InternalAddresses,optional"`
+		// coin_configs 重新加载间隔（秒），修改最小充值金额或禁用币种无需重启
+		CoinConfigRefreshInterval int `json:"CoinConfigRefreshInterval,default=60"`
+		// ParseEvent 开启时按 ABI 解码的合约事件
+		Events []EventContract `json:"Events,optional"`
 	} `json:"BlockProcessor"`
 
+	// 充值地址分配任务配置
+	DepositAddress struct {
+		Enabled   bool   `json:"Enabled,optional"`
+		XPub      string `json:"XPub,optional"`         // m/44'/60'/0' 层级的扩展公钥，地址路径为 <XPub>/0/index
+		BatchSize int    `json:"BatchSize,default=100"` // 每轮最多分配的地址数量
+	} `json:"DepositAddress,optional"`
+
+	// 提现执行任务配置
+	Withdrawal struct {
+		Enabled              bool    `json:"Enabled,optional"`
+		RPCURL               string  `json:"RPCURL,optional"`               // 广播节点，未配置时使用 Chain.RPCURL 或 Chain.RPCURLs 的第一个
+		KeystoreFile         string  `json:"KeystoreFile,optional"`         // 热钱包 keystore（Web3 Secret Storage）文件
+		KeystorePasswordFile string  `json:"KeystorePasswordFile,optional"` // keystore 密码文件，避免密码写入配置
+		BatchSize            int     `json:"BatchSize,default=20"`          // 每轮最多签名广播的提现数量
+		Confirmations        int64   `json:"Confirmations,default=12"`      // 回执所在区块达到确认数后更新最终状态
+		GasLimitMultiplier   float64 `json:"GasLimitMultiplier,default=1.2"`
+		MaxFeePerGasGwei     int64   `json:"MaxFeePerGasGwei,optional"` // maxFeePerGas 上限，0 表示不限制
+		StuckAfter           int64   `json:"StuckAfter,default=300"`    // 广播后超过该秒数仍未打包视为卡住，按相同 nonce 加价替换
+		FeeBumpPercent       int64   `json:"FeeBumpPercent,default=15"` // 替换交易的手续费涨幅（百分比），节点要求至少 10
+		MaxReplacements      int     `json:"MaxReplacements,default=3"` // 加价替换次数上限，超过后发送 0 金额自转账取消原交易
+	} `json:"Withdrawal,optional"`
+
 	// 数据库配置（可选）
 	Database struct {
 		Host     string `json:"Host"`
@@ -36,3 +88,10 @@ type Config struct {
 		Database string `json:"Database"`
 	} `json:"Database"`
 }
+
+// EventContract 需要解码事件的合约
+type EventContract struct {
+	Contract string   `json:"Contract"`        // 合约地址
+	ABIFile  string   `json:"ABIFile"`         // ABI JSON 文件，支持纯 ABI 数组或带 abi 字段的编译产物
+	Events   []string `json:"Events,optional"` // 需要解码的事件名称，为空时解码 ABI 中的全部事件
+}
@@ -0,0 +1,70 @@
+package core
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// 充值被拒绝的原因
+const (
+	RejectReasonCoinDisabled    = "coin_disabled"     // 币种已禁用（coin_configs.status=2）
+	RejectReasonBelowMinDeposit = "below_min_deposit" // 金额低于 coin_configs.min_deposit
+	RejectReasonInvalidAmount   = "invalid_amount"    // 金额或最小充值配置无法解析
+)
+
+// CoinConfig 币种充值配置，对应 coin_configs 表
+type CoinConfig struct {
+	Coin        string
+	CoinAddress string
+	MinDeposit  string // 十进制最小充值金额，例如 "0.01"
+	Decimals    int    // 链上最小单位精度
+	Enabled     bool
+}
+
+// CoinConfigSource 币种配置数据源，实现方可以热更新配置
+type CoinConfigSource interface {
+	// CoinConfig 按币种符号查询配置，未配置时返回 false
+	CoinConfig(coin string) (CoinConfig, bool)
+}
+
+// RejectedTransfer 流入目标地址但未通过币种规则的转账
+type RejectedTransfer struct {
+	TransferRecord
+	Reason string
+}
+
+// checkCoinConfig 按币种配置校验转账，通过时返回空原因
+func checkCoinConfig(cfg CoinConfig, t TransferRecord) string {
+	if !cfg.Enabled {
+		return RejectReasonCoinDisabled
+	}
+	if strings.TrimSpace(cfg.MinDeposit) == "" {
+		return ""
+	}
+
+	below, err := belowMinDeposit(t.Amount, cfg.Decimals, cfg.MinDeposit)
+	if err != nil {
+		return RejectReasonInvalidAmount
+	}
+	if below {
+		return RejectReasonBelowMinDeposit
+	}
+	return ""
+}
+
+// belowMinDeposit 判断最小单位金额 raw 换算后是否低于十进制的 minDeposit，使用有理数精确比较
+func belowMinDeposit(raw string, decimals int, minDeposit string) (bool, error) {
+	amount, ok := new(big.Int).SetString(strings.TrimSpace(raw), 10)
+	if !ok {
+		return false, fmt.Errorf("invalid amount: %q", raw)
+	}
+	min, ok := new(big.Rat).SetString(strings.TrimSpace(minDeposit))
+	if !ok {
+		return false, fmt.Errorf("invalid min deposit: %q", minDeposit)
+	}
+
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	value := new(big.Rat).SetFrac(amount, scale)
+	return value.Cmp(min) < 0, nil
+}
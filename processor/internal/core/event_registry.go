@@ -0,0 +1,196 @@
+package core
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// EventRecord 按 ABI 解码后的合约事件
+type EventRecord struct {
+	TxHash      string
+	LogIndex    int // 日志在区块内的序号
+	BlockNumber int64
+	Contract    string // 合约地址（EIP-55）
+	Name        string // 事件名称，例如 Deposit
+	Signature   string // 事件签名，例如 Deposit(address,address,uint256)
+	Args        map[string]interface{}
+}
+
+// ArgsJSON 将事件参数编码为 JSON：整数和字节转为字符串，地址使用 EIP-55 格式，避免大整数丢失精度
+func (e EventRecord) ArgsJSON() ([]byte, error) {
+	out := make(map[string]interface{}, len(e.Args))
+	for name, v := range e.Args {
+		out[name] = normalizeEventArg(v)
+	}
+	return json.Marshal(out)
+}
+
+func normalizeEventArg(v interface{}) interface{} {
+	switch val := v.(type) {
+	case *big.Int:
+		return val.String()
+	case common.Address:
+		return val.Hex()
+	case common.Hash:
+		return val.Hex()
+	case []byte:
+		return "0x" + hex.EncodeToString(val)
+	case string, bool:
+		return val
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return fmt.Sprintf("%d", rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fmt.Sprintf("%d", rv.Uint())
+	case reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(b), rv)
+			return "0x" + hex.EncodeToString(b)
+		}
+		fallthrough
+	case reflect.Slice:
+		items := make([]interface{}, rv.Len())
+		for i := range items {
+			items[i] = normalizeEventArg(rv.Index(i).Interface())
+		}
+		return items
+	}
+	return v
+}
+
+// EventRegistry 合约事件注册表。
+// 按 (合约地址, topic0) 匹配日志，使用注册的 ABI 解码为带参数名的 EventRecord。
+type EventRegistry struct {
+	events map[string]map[common.Hash]abi.Event // 小写合约地址 -> topic0 -> 事件
+}
+
+// NewEventRegistry 创建空的事件注册表
+func NewEventRegistry() *EventRegistry {
+	return &EventRegistry{events: make(map[string]map[common.Hash]abi.Event)}
+}
+
+// Register 注册合约 ABI，eventNames 为空时注册 ABI 中的全部事件
+func (r *EventRegistry) Register(contract string, abiJSON string, eventNames ...string) error {
+	if !common.IsHexAddress(contract) {
+		return fmt.Errorf("invalid contract address: %q", contract)
+	}
+	parsed, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return fmt.Errorf("parse abi of %s failed: %w", contract, err)
+	}
+
+	selected := make([]abi.Event, 0, len(parsed.Events))
+	if len(eventNames) == 0 {
+		for _, ev := range parsed.Events {
+			selected = append(selected, ev)
+		}
+	}
+	for _, name := range eventNames {
+		ev, ok := parsed.Events[strings.TrimSpace(name)]
+		if !ok {
+			return fmt.Errorf("event %s not found in abi of %s", name, contract)
+		}
+		selected = append(selected, ev)
+	}
+
+	key := strings.ToLower(common.HexToAddress(contract).Hex())
+	byTopic := r.events[key]
+	if byTopic == nil {
+		byTopic = make(map[common.Hash]abi.Event)
+		r.events[key] = byTopic
+	}
+	for _, ev := range selected {
+		if ev.Anonymous {
+			// 匿名事件没有 topic0，无法按签名匹配
+			continue
+		}
+		byTopic[ev.ID] = ev
+	}
+	return nil
+}
+
+// Empty 是否未注册任何事件
+func (r *EventRegistry) Empty() bool {
+	return r == nil || len(r.events) == 0
+}
+
+// DecodeLog 解码单条日志，未注册的合约或事件返回 false
+func (r *EventRegistry) DecodeLog(lg *types.Log) (EventRecord, bool, error) {
+	if r.Empty() || lg == nil || lg.Removed || len(lg.Topics) == 0 {
+		return EventRecord{}, false, nil
+	}
+	byTopic := r.events[strings.ToLower(lg.Address.Hex())]
+	ev, ok := byTopic[lg.Topics[0]]
+	if !ok {
+		return EventRecord{}, false, nil
+	}
+
+	args := make(map[string]interface{}, len(ev.Inputs))
+	var indexed abi.Arguments
+	for _, input := range ev.Inputs {
+		if input.Indexed {
+			indexed = append(indexed, input)
+		}
+	}
+	if len(lg.Topics)-1 != len(indexed) {
+		return EventRecord{}, false, fmt.Errorf("log %d of tx %s: %s expects %d indexed topics, got %d",
+			lg.Index, lg.TxHash.Hex(), ev.Sig, len(indexed), len(lg.Topics)-1)
+	}
+	if err := abi.ParseTopicsIntoMap(args, indexed, lg.Topics[1:]); err != nil {
+		return EventRecord{}, false, fmt.Errorf("decode topics of %s failed: %w", ev.Sig, err)
+	}
+	if err := ev.Inputs.UnpackIntoMap(args, lg.Data); err != nil {
+		return EventRecord{}, false, fmt.Errorf("decode data of %s failed: %w", ev.Sig, err)
+	}
+
+	return EventRecord{
+		TxHash:      lg.TxHash.Hex(),
+		LogIndex:    int(lg.Index),
+		BlockNumber: int64(lg.BlockNumber),
+		Contract:    lg.Address.Hex(),
+		Name:        ev.RawName,
+		Signature:   ev.Sig,
+		Args:        args,
+	}, true, nil
+}
+
+// DecodeReceipts 解码区块回执中所有已注册的事件，按日志顺序返回
+func (r *EventRegistry) DecodeReceipts(receipts []*types.Receipt) ([]EventRecord, error) {
+	if r.Empty() {
+		return nil, nil
+	}
+	var records []EventRecord
+	for _, receipt := range receipts {
+		if receipt == nil {
+			continue
+		}
+		for _, lg := range receipt.Logs {
+			record, ok, err := r.DecodeLog(lg)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+			// 部分节点返回的日志不带区块号和交易哈希，以回执为准
+			record.TxHash = receipt.TxHash.Hex()
+			if receipt.BlockNumber != nil {
+				record.BlockNumber = receipt.BlockNumber.Int64()
+			}
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
@@ -0,0 +1,131 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	testBridge = common.HexToAddress("0xb41d6e0000000000000000000000000000000b41")
+	testUser   = common.HexToAddress("0xabc0000000000000000000000000000000000001")
+	testToken  = common.HexToAddress("0xdac17f958d2ee523a2206206994597c13d831ec7")
+)
+
+func newBridgeRegistry(t *testing.T, events ...string) *EventRegistry {
+	t.Helper()
+
+	raw, err := os.ReadFile("testdata/bridge_abi.json")
+	if err != nil {
+		t.Fatalf("read abi fixture failed: %v", err)
+	}
+	r := NewEventRegistry()
+	if err := r.Register(testBridge.Hex(), string(raw), events...); err != nil {
+		t.Fatalf("register abi failed: %v", err)
+	}
+	return r
+}
+
+// bridgeLog 构造桥合约事件日志，data 为按 32 字节拼接的非 indexed 参数
+func bridgeLog(contract common.Address, signature string, index uint, data ...[]byte) *types.Log {
+	lg := &types.Log{
+		Address: contract,
+		Topics: []common.Hash{
+			crypto.Keccak256Hash([]byte(signature)),
+			common.BytesToHash(testUser.Bytes()),
+			common.BytesToHash(testToken.Bytes()),
+		},
+		Index: index,
+	}
+	for _, d := range data {
+		lg.Data = append(lg.Data, common.LeftPadBytes(d, 32)...)
+	}
+	return lg
+}
+
+func TestEventRegistry_DecodesNamedArguments(t *testing.T) {
+	r := newBridgeRegistry(t)
+	amount, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	dest := common.HexToHash("0x01")
+
+	record, ok, err := r.DecodeLog(bridgeLog(testBridge, "Deposit(address,address,uint256,bytes32)", 4, amount.Bytes(), dest.Bytes()))
+	if err != nil || !ok {
+		t.Fatalf("decode deposit failed: ok=%v err=%v", ok, err)
+	}
+	if record.Name != "Deposit" || record.Signature != "Deposit(address,address,uint256,bytes32)" || record.LogIndex != 4 {
+		t.Fatalf("unexpected record: %+v", record)
+	}
+	if record.Args["user"] != testUser || record.Args["amount"].(*big.Int).Cmp(amount) != 0 {
+		t.Fatalf("unexpected args: %+v", record.Args)
+	}
+
+	raw, err := record.ArgsJSON()
+	if err != nil {
+		t.Fatalf("encode args failed: %v", err)
+	}
+	var args map[string]string
+	if err := json.Unmarshal(raw, &args); err != nil {
+		t.Fatalf("args must be a flat string map: %s", raw)
+	}
+	if args["amount"] != amount.String() || args["token"] != testToken.Hex() || args["destination"] != dest.Hex() {
+		t.Fatalf("unexpected args json: %s", raw)
+	}
+}
+
+func TestEventRegistry_IgnoresUnregistered(t *testing.T) {
+	r := newBridgeRegistry(t, "Withdraw")
+
+	// 只注册了 Withdraw
+	if _, ok, _ := r.DecodeLog(bridgeLog(testBridge, "Deposit(address,address,uint256,bytes32)", 0, []byte{1}, []byte{2})); ok {
+		t.Fatal("unregistered event must be ignored")
+	}
+	// 其他合约发出的同签名事件
+	other := common.HexToAddress("0x00000000000000000000000000000000000000ff")
+	if _, ok, _ := r.DecodeLog(bridgeLog(other, "Withdraw(address,address,uint256,uint64)", 0, []byte{1}, []byte{2})); ok {
+		t.Fatal("event from an unregistered contract must be ignored")
+	}
+	if err := NewEventRegistry().Register(testBridge.Hex(), "[]", "Deposit"); err == nil {
+		t.Fatal("expected error for an event missing from the abi")
+	}
+}
+
+func TestParseBlock_EventsOnlyFetchesReceipts(t *testing.T) {
+	target := common.HexToAddress("0xabc0000000000000000000000000000000000001")
+	client, _ := newTestBlock(t, 3, target)
+
+	block, _ := client.BlockByNumber(context.Background(), big.NewInt(100))
+	receipts := []*types.Receipt{{
+		TxHash:      block.Transactions()[0].Hash(),
+		BlockNumber: big.NewInt(100),
+		Logs: []*types.Log{
+			bridgeLog(testBridge, "Withdraw(address,address,uint256,uint64)", 0, big.NewInt(5).Bytes(), big.NewInt(9).Bytes()),
+		},
+	}}
+	client.AddBlock(block, receipts)
+	calls := client.CallCount("")
+
+	parser := NewReceiptParser(client, testChainID, NewTransferFilter())
+	parser.SetEventRegistry(newBridgeRegistry(t))
+	result, err := parser.ParseBlock(context.Background(), 100, BlockParseOptions{Events: true})
+	if err != nil {
+		t.Fatalf("parse block failed: %v", err)
+	}
+	if got := client.CallCount("") - calls; got != 1 {
+		t.Fatalf("events-only parse should fetch receipts once, got %d calls", got)
+	}
+	if len(result.Events) != 1 || result.Events[0].Name != "Withdraw" || result.Events[0].BlockNumber != 100 {
+		t.Fatalf("unexpected events: %+v", result.Events)
+	}
+	if result.Events[0].Args["nonce"] != uint64(9) {
+		t.Fatalf("unexpected nonce arg: %#v", result.Events[0].Args["nonce"])
+	}
+	if len(result.Transfers) != 0 {
+		t.Fatalf("transfers must not be parsed when disabled, got %d", len(result.Transfers))
+	}
+}
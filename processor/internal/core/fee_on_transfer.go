@@ -0,0 +1,108 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"go_bullayer_v1/base/pkg/logger"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// SetBalanceCheckedTokens 设置需要按 balanceOf 差额校验到账金额的代币合约（通常是转账收手续费的代币）
+func (p *ReceiptParser) SetBalanceCheckedTokens(tokens []string) {
+	checked := make(map[string]bool, len(tokens))
+	for _, token := range tokens {
+		token = strings.ToLower(strings.TrimSpace(token))
+		if common.IsHexAddress(token) {
+			checked[token] = true
+		}
+	}
+	p.balanceChecked = checked
+}
+
+// tokenHolding 代币持有关系
+type tokenHolding struct {
+	token  common.Address
+	holder common.Address
+}
+
+// capToReceivedAmounts 按 balanceOf 在区块前后的差额校验手续费代币的实际到账金额。
+// 实际到账 = 区块后余额 - 区块前余额 + 同区块内该地址转出的金额；
+// 到账少于日志金额时，同一地址在该区块的流入转账按比例向下取整扣减，保证入账金额不超过实际到账。
+func (p *ReceiptParser) capToReceivedAmounts(ctx context.Context, blockNumber int64, accepted, all []TransferRecord) error {
+	if len(p.balanceChecked) == 0 || blockNumber == 0 {
+		return nil
+	}
+
+	claimed := make(map[tokenHolding]*big.Int)
+	indexes := make(map[tokenHolding][]int)
+	for i, t := range accepted {
+		if t.AssetType != AssetTypeERC20 || !p.balanceChecked[strings.ToLower(t.TokenAddress)] {
+			continue
+		}
+		amount, ok := new(big.Int).SetString(t.Amount, 10)
+		if !ok {
+			return fmt.Errorf("invalid amount %q of tx %s", t.Amount, t.TxHash)
+		}
+		key := tokenHolding{token: common.HexToAddress(t.TokenAddress), holder: common.HexToAddress(t.To)}
+		if claimed[key] == nil {
+			claimed[key] = new(big.Int)
+		}
+		claimed[key].Add(claimed[key], amount)
+		indexes[key] = append(indexes[key], i)
+	}
+
+	for key, total := range claimed {
+		received, err := p.receivedInBlock(ctx, blockNumber, key, all)
+		if err != nil {
+			return err
+		}
+		if received.Cmp(total) >= 0 {
+			continue
+		}
+		if received.Sign() < 0 {
+			received.SetInt64(0)
+		}
+
+		logger.Info("区块 %d 代币 %s 流入 %s 日志金额 %s，余额差额仅到账 %s，按比例扣减",
+			blockNumber, key.token.Hex(), key.holder.Hex(), total, received)
+		for _, i := range indexes[key] {
+			amount, _ := new(big.Int).SetString(accepted[i].Amount, 10)
+			amount.Mul(amount, received).Quo(amount, total)
+			accepted[i].Amount = amount.String()
+		}
+	}
+	return nil
+}
+
+// receivedInBlock 查询 holder 在区块内实际收到的代币数量
+func (p *ReceiptParser) receivedInBlock(ctx context.Context, blockNumber int64, key tokenHolding, all []TransferRecord) (*big.Int, error) {
+	var before, after *big.Int
+	err := p.withRetry(ctx, func() error {
+		var err error
+		if before, err = p.client.TokenBalanceAt(ctx, key.token, key.holder, big.NewInt(blockNumber-1)); err != nil {
+			return err
+		}
+		after, err = p.client.TokenBalanceAt(ctx, key.token, key.holder, big.NewInt(blockNumber))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query balance of %s on %s failed: %w", key.holder.Hex(), key.token.Hex(), err)
+	}
+
+	received := new(big.Int).Sub(after, before)
+	for _, t := range all {
+		if t.AssetType != AssetTypeERC20 ||
+			common.HexToAddress(t.TokenAddress) != key.token ||
+			common.HexToAddress(t.From) != key.holder {
+			continue
+		}
+		if amount, ok := new(big.Int).SetString(t.Amount, 10); ok {
+			received.Add(received, amount)
+		}
+	}
+	return received, nil
+}
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// callTracerName geth 内置调用追踪器名称
+const callTracerName = "callTracer"
+
+// TxCallTrace 单笔交易的调用追踪结果
+type TxCallTrace struct {
+	TxHash common.Hash `json:"txHash"`
+	Result *CallFrame  `json:"result"`
+}
+
+// CallFrame callTracer 输出的调用帧
+type CallFrame struct {
+	Type  string          `json:"type"`
+	From  common.Address  `json:"from"`
+	To    *common.Address `json:"to,omitempty"`
+	Value *hexutil.Big    `json:"value,omitempty"`
+	Error string          `json:"error,omitempty"`
+	Calls []CallFrame     `json:"calls,omitempty"`
+}
+
+// InternalEventIndex 内部转账的事件序号：按调用帧深度优先顺序从 -2 开始递减，
+// 与 ERC20 日志序号（>=0）和原生转账（-1）区分，保证 (tx_hash, event_index) 唯一
+func InternalEventIndex(ordinal int) int {
+	return NativeEventIndex - 1 - ordinal
+}
+
+// DecodeBlockCallTraces 解析 debug_traceBlockByNumber 的 callTracer 输出
+func DecodeBlockCallTraces(raw []byte) ([]TxCallTrace, error) {
+	var traces []TxCallTrace
+	if err := json.Unmarshal(raw, &traces); err != nil {
+		return nil, fmt.Errorf("decode call traces failed: %w", err)
+	}
+	return traces, nil
+}
+
+// parseInternalETHTransfers 提取合约内部发起的携带 ETH 的调用
+// 顶层调用由原生转账解析覆盖，这里只遍历子调用；失败（回滚）的调用帧及其全部子调用都会被忽略
+func parseInternalETHTransfers(blockNumber int64, traces []TxCallTrace) []TransferRecord {
+	results := make([]TransferRecord, 0)
+	for _, trace := range traces {
+		if trace.Result == nil || trace.Result.Error != "" {
+			continue
+		}
+
+		ordinal := 0
+		var walk func(frames []CallFrame)
+		walk = func(frames []CallFrame) {
+			for _, f := range frames {
+				if f.Error != "" {
+					continue
+				}
+				if carriesETH(f) {
+					results = append(results, TransferRecord{
+						TxHash:      trace.TxHash.Hex(),
+						EventIndex:  InternalEventIndex(ordinal),
+						BlockNumber: blockNumber,
+						From:        f.From.Hex(),
+						To:          f.To.Hex(),
+						Amount:      f.Value.ToInt().String(),
+						AssetType:   AssetTypeInternalETH,
+						TokenSymbol: "ETH",
+					})
+					ordinal++
+				}
+				walk(f.Calls)
+			}
+		}
+		walk(trace.Result.Calls)
+	}
+	return results
+}
+
+// carriesETH 判断调用帧是否实际转移了 ETH
+// DELEGATECALL/STATICCALL 不转移余额，CALLCODE 的余额留在调用方自身，均不计入
+func carriesETH(f CallFrame) bool {
+	if f.To == nil || f.Value == nil || f.Value.ToInt().Sign() <= 0 {
+		return false
+	}
+	switch strings.ToUpper(f.Type) {
+	case "CALL", "SELFDESTRUCT":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,82 @@
+package core
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func readTraceFixture(t *testing.T) []byte {
+	t.Helper()
+
+	raw, err := os.ReadFile("testdata/trace_block_callTracer.json")
+	if err != nil {
+		t.Fatalf("read fixture failed: %v", err)
+	}
+	return raw
+}
+
+func loadTraceFixture(t *testing.T) []TxCallTrace {
+	t.Helper()
+
+	traces, err := DecodeBlockCallTraces(readTraceFixture(t))
+	if err != nil {
+		t.Fatalf("decode fixture failed: %v", err)
+	}
+	return traces
+}
+
+func TestParseInternalETHTransfers(t *testing.T) {
+	got := parseInternalETHTransfers(100, loadTraceFixture(t))
+
+	want := []struct {
+		from, to, amount string
+		eventIndex       int
+	}{
+		// Safe 代理合约转出（DELEGATECALL 帧本身不转移余额）
+		{"0x5afe3855358e112b5647b952709e6165e1c1eeee", "0xabc0000000000000000000000000000000000001", "1000000000000000000", -2},
+		// 批量转账合约，回滚的子调用被忽略
+		{"0xba7c4e2000000000000000000000000000000b07", "0xabc0000000000000000000000000000000000001", "2000000000000000000", -2},
+		{"0xba7c4e2000000000000000000000000000000b07", "0x00000000000000000000000000000000000000ff", "500000000000000000", -3},
+		// 合约自毁转出余额
+		{"0xdead000000000000000000000000000000000d0d", "0xabc0000000000000000000000000000000000001", "10000000000000000", -2},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d internal transfers, got %d: %+v", len(want), len(got), got)
+	}
+	for i, w := range want {
+		r := got[i]
+		if r.From != common.HexToAddress(w.from).Hex() || r.To != common.HexToAddress(w.to).Hex() || r.Amount != w.amount || r.EventIndex != w.eventIndex {
+			t.Fatalf("transfer %d = %+v, want %+v", i, r, w)
+		}
+		if r.AssetType != AssetTypeInternalETH || r.TokenSymbol != "ETH" || r.BlockNumber != 100 {
+			t.Fatalf("unexpected transfer metadata: %+v", r)
+		}
+	}
+}
+
+func TestParseAndFilterByBlock_InternalTransfers(t *testing.T) {
+	target := common.HexToAddress("0xabc0000000000000000000000000000000000001")
+	client, _ := newTestBlock(t, 0, target)
+	client.SetTrace(100, readTraceFixture(t))
+	parser := NewReceiptParser(client, testChainID, NewTransferFilter())
+
+	got, _, err := parser.ParseAndFilterByBlock(context.Background(), 100, []string{target.Hex()}, nil, nil, 2)
+	if err != nil {
+		t.Fatalf("parse block failed: %v", err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("internal transfers must be ignored unless enabled, got %d", len(got))
+	}
+
+	parser.EnableInternalTransfers()
+	got, _, err = parser.ParseAndFilterByBlock(context.Background(), 100, []string{target.Hex()}, nil, nil, 2)
+	if err != nil {
+		t.Fatalf("parse block failed: %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("expected 3 internal deposits to target, got %d: %+v", len(got), got)
+	}
+}
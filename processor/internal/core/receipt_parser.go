@@ -2,10 +2,12 @@ package core
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
 	"strings"
 	"sync"
+	"time"
 
 	"go_bullayer_v1/base/pkg/eth"
 
@@ -17,18 +19,88 @@ import (
 var transferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
 
 // ReceiptParser 票据解析器。
-// 功能：按区块号拉取回执，并发解析交易，再过滤流入目标地址集合的资产转移记录。
+// 功能：按区块号拉取区块和回执，并发解析交易，再过滤流入目标地址集合的资产转移记录。
+// 每个区块只发起两次 RPC：原生 ETH 转账取自区块交易列表，发送方在本地通过签名恢复。
 type ReceiptParser struct {
-	filter *TransferFilter
+	filter         *TransferFilter
+	client         eth.Client
+	signer         types.Signer           // 为空时发送方通过 RPC 查询
+	traceCalls     bool                   // 追踪合约内部转出的 ETH
+	events         *EventRegistry         // 为空时不解码合约事件
+	tokens         *TokenMetadataResolver // 为空时不解析 ERC20 精度
+	balanceChecked map[string]bool        // 按余额差额校验到账金额的代币合约（小写地址）
+	maxRetries     int                    // 单笔交易 RPC 失败后的重试次数
+	retryBackoff   time.Duration          // 首次重试前的等待时间，之后每次翻倍
 }
 
-func NewReceiptParser() *ReceiptParser {
-	return &ReceiptParser{
-		filter: NewTransferFilter(),
+// 单笔交易解析失败时的默认重试策略
+const (
+	defaultMaxRetries   = 3
+	defaultRetryBackoff = 200 * time.Millisecond
+)
+
+// NewReceiptParser 使用指定链客户端和过滤器创建票据解析器
+// chainID 大于 0 时按该链最新的签名规则在本地恢复交易发送方
+func NewReceiptParser(client eth.Client, chainID int64, filter *TransferFilter) *ReceiptParser {
+	if filter == nil {
+		filter = NewTransferFilter()
+	}
+	p := &ReceiptParser{
+		filter:       filter,
+		client:       client,
+		maxRetries:   defaultMaxRetries,
+		retryBackoff: defaultRetryBackoff,
+	}
+	if chainID > 0 {
+		p.signer = types.LatestSignerForChainID(big.NewInt(chainID))
+	}
+	return p
+}
+
+// SetRetryPolicy 设置单笔交易解析失败时的重试次数和首次退避时间
+func (p *ReceiptParser) SetRetryPolicy(maxRetries int, backoff time.Duration) {
+	if maxRetries >= 0 {
+		p.maxRetries = maxRetries
+	}
+	if backoff > 0 {
+		p.retryBackoff = backoff
 	}
 }
 
+// EnableInternalTransfers 启用内部转账解析，每个区块额外调用一次 debug_traceBlockByNumber
+func (p *ReceiptParser) EnableInternalTransfers() {
+	p.traceCalls = true
+}
+
+// SetEventRegistry 设置合约事件注册表，ParseBlock 会按注册表解码回执中的日志
+func (p *ReceiptParser) SetEventRegistry(r *EventRegistry) {
+	p.events = r
+}
+
+// SetTokenMetadata 设置代币元数据解析器，ParseBlock 会为转账记录补充精度和十进制金额
+func (p *ReceiptParser) SetTokenMetadata(r *TokenMetadataResolver) {
+	p.tokens = r
+}
+
+// BlockParseOptions 区块解析选项
+type BlockParseOptions struct {
+	Transfers       bool              // 解析并过滤流入目标地址的转账
+	Events          bool              // 按事件注册表解码合约事件
+	TargetAddresses []string          // 目标地址集合
+	TrackedAssets   []string          // 资产白名单
+	TokenSymbols    map[string]string // ERC20合约地址 -> symbol，例如 {"0xdac17...":"USDT"}
+	Workers         int               // 并发解析交易的 worker 数
+}
+
+// BlockParseResult 单个区块的解析结果
+type BlockParseResult struct {
+	Transfers []TransferRecord   // 通过过滤的流入转账
+	Rejected  []RejectedTransfer // 流入目标地址但未通过币种配置校验的转账
+	Events    []EventRecord      // 已注册的合约事件
+}
+
 // ParseAndFilterByBlock 按区块解析并过滤转账记录。
+// 第二个返回值为流入目标地址但未通过币种配置校验的转账。
 //
 // tokenSymbolsByAddress: ERC20合约地址 -> symbol，例如 {"0xdac17...":"USDT"}
 func (p *ReceiptParser) ParseAndFilterByBlock(
@@ -38,51 +110,120 @@ func (p *ReceiptParser) ParseAndFilterByBlock(
 	trackedAssets []string,
 	tokenSymbolsByAddress map[string]string,
 	workerCount int,
-) ([]TransferRecord, error) {
+) ([]TransferRecord, []RejectedTransfer, error) {
+	result, err := p.ParseBlock(ctx, blockNumber, BlockParseOptions{
+		Transfers:       true,
+		TargetAddresses: targetAddresses,
+		TrackedAssets:   trackedAssets,
+		TokenSymbols:    tokenSymbolsByAddress,
+		Workers:         workerCount,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return result.Transfers, result.Rejected, nil
+}
+
+// ParseBlock 按选项解析区块。
+// 转账和事件共用同一份回执；只解析事件时不拉取完整区块。
+func (p *ReceiptParser) ParseBlock(ctx context.Context, blockNumber int64, opts BlockParseOptions) (*BlockParseResult, error) {
 	if blockNumber < 0 {
 		return nil, fmt.Errorf("invalid blockNumber: %d", blockNumber)
 	}
+	if p.client == nil {
+		return nil, errors.New("eth client is required")
+	}
 
-	receipts, err := eth.BlockReceiptsByNumber(ctx, big.NewInt(blockNumber))
+	result := &BlockParseResult{}
+	decodeEvents := opts.Events && !p.events.Empty()
+	if !opts.Transfers && !decodeEvents {
+		return result, nil
+	}
+
+	number := big.NewInt(blockNumber)
+	receipts, err := p.client.BlockReceiptsByNumber(ctx, number)
 	if err != nil {
 		return nil, fmt.Errorf("query block receipts failed: %w", err)
 	}
 
-	normalizedTokenMap := normalizeTokenSymbolMap(tokenSymbolsByAddress)
+	if decodeEvents {
+		result.Events, err = p.events.DecodeReceipts(receipts)
+		if err != nil {
+			return nil, fmt.Errorf("block %d: %w", blockNumber, err)
+		}
+	}
+	if !opts.Transfers {
+		return result, nil
+	}
+
+	block, err := p.client.BlockByNumber(ctx, number)
+	if err != nil {
+		return nil, fmt.Errorf("query block failed: %w", err)
+	}
+
+	normalizedTokenMap := normalizeTokenSymbolMap(opts.TokenSymbols)
 
 	erc20Transfers := parseERC20TransfersFromReceipts(receipts, normalizedTokenMap)
-	ethTransfers, err := p.parseNativeETHTransfersConcurrently(ctx, receipts, workerCount)
+	ethTransfers, err := p.parseNativeETHTransfersConcurrently(ctx, block, receipts, opts.Workers)
 	if err != nil {
 		return nil, err
 	}
 
-	allTransfers := make([]TransferRecord, 0, len(erc20Transfers)+len(ethTransfers))
+	var internalTransfers []TransferRecord
+	if p.traceCalls {
+		raw, err := p.client.TraceBlockByNumber(ctx, number, callTracerName)
+		if err != nil {
+			return nil, fmt.Errorf("trace block failed: %w", err)
+		}
+		traces, err := DecodeBlockCallTraces(raw)
+		if err != nil {
+			return nil, err
+		}
+		internalTransfers = parseInternalETHTransfers(blockNumber, traces)
+	}
+
+	allTransfers := make([]TransferRecord, 0, len(erc20Transfers)+len(ethTransfers)+len(internalTransfers))
 	allTransfers = append(allTransfers, erc20Transfers...)
 	allTransfers = append(allTransfers, ethTransfers...)
+	allTransfers = append(allTransfers, internalTransfers...)
 
-	return p.filter.FilterIncomingTransfers(targetAddresses, trackedAssets, allTransfers), nil
+	result.Transfers, result.Rejected = p.filter.FilterTransfers(opts.TargetAddresses, opts.TrackedAssets, allTransfers)
+	if err := p.capToReceivedAmounts(ctx, blockNumber, result.Transfers, allTransfers); err != nil {
+		return nil, fmt.Errorf("block %d: %w", blockNumber, err)
+	}
+	// 最小充值金额按扣减后的实际到账金额校验
+	var capped []RejectedTransfer
+	result.Transfers, capped = p.filter.RecheckCoinConfigs(result.Transfers)
+	result.Rejected = append(result.Rejected, capped...)
+	for i := range result.Transfers {
+		if err := p.normalizeAmount(ctx, &result.Transfers[i]); err != nil {
+			return nil, fmt.Errorf("block %d: %w", blockNumber, err)
+		}
+	}
+	for i := range result.Rejected {
+		if err := p.normalizeAmount(ctx, &result.Rejected[i].TransferRecord); err != nil {
+			return nil, fmt.Errorf("block %d: %w", blockNumber, err)
+		}
+	}
+	return result, nil
 }
 
+// parseERC20TransfersFromReceipts 从回执日志中解析 ERC20 转账，失败交易的日志会被忽略
 func parseERC20TransfersFromReceipts(
 	receipts []*types.Receipt,
 	tokenSymbolsByAddress map[string]string,
 ) []TransferRecord {
 	results := make([]TransferRecord, 0, len(receipts))
 	for _, receipt := range receipts {
-		if receipt == nil {
+		if receipt == nil || receipt.Status != types.ReceiptStatusSuccessful {
 			continue
 		}
 		for _, lg := range receipt.Logs {
-			if lg == nil || len(lg.Topics) < 3 || lg.Topics[0] != transferTopic {
-				continue
-			}
-			if len(lg.Data) != 32 {
+			from, to, amount, ok := decodeTransferLog(lg)
+			if !ok {
 				continue
 			}
 
-			from := topicToAddress(lg.Topics[1])
-			to := topicToAddress(lg.Topics[2])
-			amount := new(big.Int).SetBytes(lg.Data)
 			tokenAddress := strings.ToLower(lg.Address.Hex())
 			symbol := tokenSymbolsByAddress[tokenAddress]
 			if symbol == "" {
@@ -92,9 +233,10 @@ func parseERC20TransfersFromReceipts(
 
 			results = append(results, TransferRecord{
 				TxHash:       receipt.TxHash.Hex(),
+				EventIndex:   int(lg.Index),
 				BlockNumber:  int64(receipt.BlockNumber.Uint64()),
-				From:         from,
-				To:           to,
+				From:         from.Hex(),
+				To:           to.Hex(),
 				Amount:       amount.String(),
 				AssetType:    AssetTypeERC20,
 				TokenAddress: lg.Address.Hex(),
@@ -105,11 +247,50 @@ func parseERC20TransfersFromReceipts(
 	return results
 }
 
+// decodeTransferLog 解析 Transfer(address,address,uint256) 日志。
+// 标准实现为 3 个 topic 加 32 字节 data；部分早期代币未给 from/to 加 indexed，地址按顺序编码在 data 中。
+// 4 个 topic 的是 ERC721 的 Transfer（tokenId 为 indexed），不属于同质化代币转账。
+func decodeTransferLog(lg *types.Log) (from, to common.Address, amount *big.Int, ok bool) {
+	if lg == nil || len(lg.Topics) == 0 || len(lg.Topics) > 3 || lg.Topics[0] != transferTopic {
+		return common.Address{}, common.Address{}, nil, false
+	}
+
+	words := make([]common.Hash, 0, 3)
+	words = append(words, lg.Topics[1:]...)
+	inData := 3 - len(words)
+	if len(lg.Data) != inData*32 {
+		return common.Address{}, common.Address{}, nil, false
+	}
+	for i := 0; i < inData; i++ {
+		words = append(words, common.BytesToHash(lg.Data[i*32:(i+1)*32]))
+	}
+
+	// 地址参数左侧 12 字节必须为 0，否则不是该事件的合法编码
+	for _, w := range words[:2] {
+		if new(big.Int).SetBytes(w[:12]).Sign() != 0 {
+			return common.Address{}, common.Address{}, nil, false
+		}
+	}
+	return common.BytesToAddress(words[0][12:]), common.BytesToAddress(words[1][12:]), new(big.Int).SetBytes(words[2][:]), true
+}
+
+// parseNativeETHTransfersConcurrently 从区块交易列表中解析原生 ETH 转账
+// 发送方恢复（ecrecover）是 CPU 密集操作，由 worker 并发执行；结果按交易在区块内的顺序返回。
+// 回执状态为失败的交易没有转移余额，直接跳过。
+// 任意一笔交易重试后仍解析失败时返回区块级错误，调用方不得推进该区块的游标。
 func (p *ReceiptParser) parseNativeETHTransfersConcurrently(
 	ctx context.Context,
+	block *types.Block,
 	receipts []*types.Receipt,
 	workerCount int,
 ) ([]TransferRecord, error) {
+	if block == nil {
+		return nil, nil
+	}
+	reverted, err := revertedTransactions(block, receipts)
+	if err != nil {
+		return nil, err
+	}
 	if workerCount <= 0 {
 		workerCount = 8
 	}
@@ -117,82 +298,138 @@ func (p *ReceiptParser) parseNativeETHTransfersConcurrently(
 		workerCount = 64
 	}
 
-	type parseResult struct {
-		record TransferRecord
-		ok     bool
-		err    error
-	}
-
-	jobs := make(chan *types.Receipt)
-	results := make(chan parseResult, len(receipts))
+	txs := block.Transactions()
+	records := make([]TransferRecord, len(txs))
+	matched := make([]bool, len(txs))
+	errs := make([]error, len(txs))
 
+	jobs := make(chan int)
 	var wg sync.WaitGroup
 	for i := 0; i < workerCount; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			for receipt := range jobs {
-				if receipt == nil {
+			for idx := range jobs {
+				if reverted[idx] {
 					continue
 				}
-				r, ok, err := parseETHTransferByReceipt(ctx, receipt)
-				results <- parseResult{record: r, ok: ok, err: err}
+				err := p.withRetry(ctx, func() error {
+					r, ok, err := p.parseETHTransfer(ctx, block, txs[idx], idx)
+					if err != nil {
+						return err
+					}
+					records[idx], matched[idx] = r, ok
+					return nil
+				})
+				if err != nil {
+					errs[idx] = fmt.Errorf("parse tx %s failed: %w", txs[idx].Hash().Hex(), err)
+				}
 			}
 		}()
 	}
 
-	go func() {
-		for _, receipt := range receipts {
-			select {
-			case <-ctx.Done():
-				close(jobs)
-				wg.Wait()
-				close(results)
-				return
-			case jobs <- receipt:
-			}
+feed:
+	for idx := range txs {
+		select {
+		case <-ctx.Done():
+			break feed
+		case jobs <- idx:
 		}
-		close(jobs)
-		wg.Wait()
-		close(results)
-	}()
+	}
+	close(jobs)
+	wg.Wait()
 
-	records := make([]TransferRecord, 0, len(receipts))
-	for result := range results {
-		if result.err != nil {
-			continue
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	failed := make([]error, 0)
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+	if len(failed) > 0 {
+		return nil, fmt.Errorf("block %d: %d of %d transactions failed to parse: %w",
+			block.NumberU64(), len(failed), len(txs), errors.Join(failed...))
+	}
+
+	results := make([]TransferRecord, 0, len(txs))
+	for idx, ok := range matched {
+		if ok {
+			results = append(results, records[idx])
 		}
-		if result.ok {
-			records = append(records, result.record)
+	}
+	return results, nil
+}
+
+// revertedTransactions 按回执标记执行失败的交易，回执必须与区块交易一一对应
+func revertedTransactions(block *types.Block, receipts []*types.Receipt) ([]bool, error) {
+	txs := block.Transactions()
+	if len(receipts) != len(txs) {
+		return nil, fmt.Errorf("block %d: got %d receipts for %d transactions", block.NumberU64(), len(receipts), len(txs))
+	}
+
+	reverted := make([]bool, len(txs))
+	for i, receipt := range receipts {
+		if receipt == nil || receipt.TxHash != txs[i].Hash() {
+			return nil, fmt.Errorf("block %d: receipt %d does not match transaction %s", block.NumberU64(), i, txs[i].Hash().Hex())
 		}
+		reverted[i] = receipt.Status != types.ReceiptStatusSuccessful
 	}
+	return reverted, nil
+}
 
-	if err := ctx.Err(); err != nil {
-		return nil, err
+// withRetry 执行 fn，失败时按指数退避重试 maxRetries 次，返回最后一次的错误
+func (p *ReceiptParser) withRetry(ctx context.Context, fn func() error) error {
+	backoff := p.retryBackoff
+	var err error
+	for attempt := 0; attempt <= p.maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+		if err = fn(); err == nil {
+			return nil
+		}
 	}
-	return records, nil
+	return err
 }
 
-func parseETHTransferByReceipt(
+// parseETHTransfer 解析单笔交易中的原生 ETH 转账
+// 无法在本地恢复发送方的交易类型（或未配置链ID）回退到 RPC 查询
+func (p *ReceiptParser) parseETHTransfer(
 	ctx context.Context,
-	receipt *types.Receipt,
+	block *types.Block,
+	tx *types.Transaction,
+	index int,
 ) (TransferRecord, bool, error) {
-	tx, err := eth.TransactionByHash(ctx, receipt.TxHash)
-	if err != nil {
-		return TransferRecord{}, false, err
-	}
 	if tx == nil || tx.To() == nil || tx.Value() == nil || tx.Value().Sign() <= 0 {
 		return TransferRecord{}, false, nil
 	}
 
-	from, err := eth.TransactionSender(ctx, tx, receipt.BlockHash, uint(receipt.TransactionIndex))
-	if err != nil {
-		return TransferRecord{}, false, err
+	var (
+		from common.Address
+		err  error
+	)
+	if p.signer != nil {
+		from, err = types.Sender(p.signer, tx)
+	}
+	if p.signer == nil || err != nil {
+		from, err = p.client.TransactionSender(ctx, tx, block.Hash(), uint(index))
+		if err != nil {
+			return TransferRecord{}, false, err
+		}
 	}
 
 	record := TransferRecord{
-		TxHash:      receipt.TxHash.Hex(),
-		BlockNumber: int64(receipt.BlockNumber.Uint64()),
+		TxHash:      tx.Hash().Hex(),
+		EventIndex:  NativeEventIndex,
+		BlockNumber: block.Number().Int64(),
 		From:        from.Hex(),
 		To:          tx.To().Hex(),
 		Amount:      tx.Value().String(),
@@ -202,10 +439,6 @@ func parseETHTransferByReceipt(
 	return record, true, nil
 }
 
-func topicToAddress(topic common.Hash) string {
-	return common.BytesToAddress(topic.Bytes()[12:]).Hex()
-}
-
 func normalizeTokenSymbolMap(m map[string]string) map[string]string {
 	if len(m) == 0 {
 		return map[string]string{}
@@ -0,0 +1,329 @@
+package core
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"go_bullayer_v1/base/pkg/eth"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+const testChainID = 11155111
+
+// newTestBlock 构造包含 n 笔已签名 ETH 转账的区块（高度 100），每隔 3 笔转入 target
+func newTestBlock(t testing.TB, n int, target common.Address) (*eth.FakeClient, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("generate key failed: %v", err)
+	}
+	client := eth.NewFakeClient(testChainID)
+	client.AddBlock(signedTransferBlock(t, key, n, target))
+	return client, key
+}
+
+// signedTransferBlock 构造区块及回执，每次调用都生成新的交易对象
+func signedTransferBlock(t testing.TB, key *ecdsa.PrivateKey, n int, target common.Address) (*types.Block, []*types.Receipt) {
+	t.Helper()
+
+	signer := types.LatestSignerForChainID(big.NewInt(testChainID))
+	other := common.HexToAddress("0x00000000000000000000000000000000000000ff")
+
+	txs := make([]*types.Transaction, 0, n)
+	for i := 0; i < n; i++ {
+		to := other
+		if i%3 == 0 {
+			to = target
+		}
+		tx, err := types.SignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   big.NewInt(testChainID),
+			Nonce:     uint64(i),
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(2),
+			Gas:       21000,
+			To:        &to,
+			Value:     big.NewInt(int64(i + 1)),
+		})
+		if err != nil {
+			t.Fatalf("sign tx failed: %v", err)
+		}
+		txs = append(txs, tx)
+	}
+
+	header := &types.Header{Number: big.NewInt(100)}
+	block := types.NewBlock(header, &types.Body{Transactions: txs}, nil, trie.NewStackTrie(nil))
+
+	receipts := make([]*types.Receipt, 0, n)
+	for i, tx := range txs {
+		receipts = append(receipts, &types.Receipt{
+			Status:           types.ReceiptStatusSuccessful,
+			TxHash:           tx.Hash(),
+			BlockHash:        block.Hash(),
+			BlockNumber:      block.Number(),
+			TransactionIndex: uint(i),
+		})
+	}
+	return block, receipts
+}
+
+func TestParseAndFilterByBlock_BlockLevelFetch(t *testing.T) {
+	target := common.HexToAddress("0xabc0000000000000000000000000000000000001")
+	client, key := newTestBlock(t, 300, target)
+	parser := NewReceiptParser(client, testChainID, NewTransferFilter())
+
+	got, _, err := parser.ParseAndFilterByBlock(context.Background(), 100, []string{target.Hex()}, nil, nil, 8)
+	if err != nil {
+		t.Fatalf("parse block failed: %v", err)
+	}
+	if calls := client.CallCount(""); calls != 2 {
+		t.Fatalf("expected 2 RPC calls for a 300-tx block, got %d", calls)
+	}
+	if len(got) != 100 {
+		t.Fatalf("expected 100 transfers to target, got %d", len(got))
+	}
+
+	from := crypto.PubkeyToAddress(key.PublicKey).Hex()
+	for i, r := range got {
+		if r.From != from {
+			t.Fatalf("sender not recovered locally: %s", r.From)
+		}
+		if r.Amount != big.NewInt(int64(i*3+1)).String() {
+			t.Fatalf("transfer %d out of block order: %+v", i, r)
+		}
+	}
+}
+
+func TestParseAndFilterByBlock_SenderFallbackWithoutChainID(t *testing.T) {
+	target := common.HexToAddress("0xabc0000000000000000000000000000000000001")
+	client, _ := newTestBlock(t, 9, target)
+	parser := NewReceiptParser(client, 0, NewTransferFilter())
+
+	got, _, err := parser.ParseAndFilterByBlock(context.Background(), 100, []string{target.Hex()}, nil, nil, 2)
+	if err != nil {
+		t.Fatalf("parse block failed: %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("expected 3 transfers, got %d", len(got))
+	}
+	if calls := client.CallCount(""); calls != 2+9 {
+		t.Fatalf("expected one sender lookup per transfer, got %d calls", calls)
+	}
+}
+
+// BenchmarkParseAndFilterByBlock 对比本地恢复发送方与逐笔 RPC 查询发送方的调用次数
+func BenchmarkParseAndFilterByBlock(b *testing.B) {
+	target := common.HexToAddress("0xabc0000000000000000000000000000000000001")
+
+	for _, bc := range []struct {
+		name    string
+		chainID int64
+	}{
+		{name: "local-sender", chainID: testChainID},
+		{name: "rpc-sender", chainID: 0},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			client, key := newTestBlock(b, 300, target)
+			parser := NewReceiptParser(client, bc.chainID, NewTransferFilter())
+
+			for i := 0; i < b.N; i++ {
+				// 每轮使用新的交易对象，避免命中交易内部的发送方缓存
+				b.StopTimer()
+				client.AddBlock(signedTransferBlock(b, key, 300, target))
+				calls := client.CallCount("")
+				b.StartTimer()
+
+				if _, _, err := parser.ParseAndFilterByBlock(context.Background(), 100, nil, nil, nil, 8); err != nil {
+					b.Fatal(err)
+				}
+				b.ReportMetric(float64(client.CallCount("")-calls), "rpc/op")
+			}
+		})
+	}
+}
+
+func newFlakyParser(t *testing.T, failures, maxRetries int) (*ReceiptParser, common.Address) {
+	target := common.HexToAddress("0xabc0000000000000000000000000000000000001")
+	client, _ := newTestBlock(t, 6, target)
+	client.InjectError("TransactionSender", failures, errors.New("connection reset by peer"))
+
+	parser := NewReceiptParser(client, 0, NewTransferFilter())
+	parser.SetRetryPolicy(maxRetries, time.Millisecond)
+	return parser, target
+}
+
+func TestParseAndFilterByBlock_RetriesIntermittentErrors(t *testing.T) {
+	parser, target := newFlakyParser(t, 2, 3)
+
+	got, _, err := parser.ParseAndFilterByBlock(context.Background(), 100, []string{target.Hex()}, nil, nil, 4)
+	if err != nil {
+		t.Fatalf("expected retries to recover, got %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 transfers after retry, got %d", len(got))
+	}
+}
+
+func TestParseAndFilterByBlock_SurfacesPersistentErrors(t *testing.T) {
+	parser, target := newFlakyParser(t, -1, 2)
+
+	got, _, err := parser.ParseAndFilterByBlock(context.Background(), 100, []string{target.Hex()}, nil, nil, 4)
+	if err == nil {
+		t.Fatalf("expected block-level error, got %d transfers", len(got))
+	}
+	if !strings.Contains(err.Error(), "6 of 6 transactions failed") || !strings.Contains(err.Error(), "connection reset") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func transferLog(token common.Address, topics int, from, to common.Address, amount int64, index uint) *types.Log {
+	words := []common.Hash{
+		common.BytesToHash(from.Bytes()),
+		common.BytesToHash(to.Bytes()),
+		common.BigToHash(big.NewInt(amount)),
+	}
+	lg := &types.Log{Address: token, Topics: []common.Hash{transferTopic}, Index: index}
+	for i, w := range words {
+		if i < topics-1 {
+			lg.Topics = append(lg.Topics, w)
+		} else {
+			lg.Data = append(lg.Data, w.Bytes()...)
+		}
+	}
+	return lg
+}
+
+func TestDecodeTransferLog_Variants(t *testing.T) {
+	token := common.HexToAddress("0xdac17f958d2ee523a2206206994597c13d831ec7")
+	from := common.HexToAddress("0x00000000000000000000000000000000000000ff")
+	to := common.HexToAddress("0xabc0000000000000000000000000000000000001")
+
+	for _, topics := range []int{3, 2, 1} {
+		gotFrom, gotTo, amount, ok := decodeTransferLog(transferLog(token, topics, from, to, 42, 0))
+		if !ok || gotFrom != from || gotTo != to || amount.Int64() != 42 {
+			t.Fatalf("%d-topic transfer not decoded: ok=%v from=%s to=%s amount=%v", topics, ok, gotFrom.Hex(), gotTo.Hex(), amount)
+		}
+	}
+
+	// ERC721 Transfer：tokenId 也是 indexed
+	nft := transferLog(token, 3, from, to, 0, 0)
+	nft.Topics = append(nft.Topics, common.BigToHash(big.NewInt(7)))
+	nft.Data = nil
+	if _, _, _, ok := decodeTransferLog(nft); ok {
+		t.Fatal("ERC721 transfer must be ignored")
+	}
+
+	// data 中的地址参数高位不为 0
+	dirty := transferLog(token, 1, from, to, 1, 0)
+	dirty.Data[0] = 0x01
+	if _, _, _, ok := decodeTransferLog(dirty); ok {
+		t.Fatal("malformed address word must be ignored")
+	}
+}
+
+func TestParseAndFilterByBlock_SkipsRevertedTransactions(t *testing.T) {
+	target := common.HexToAddress("0xabc0000000000000000000000000000000000001")
+	token := common.HexToAddress("0xdac17f958d2ee523a2206206994597c13d831ec7")
+	key, _ := crypto.GenerateKey()
+	block, receipts := signedTransferBlock(t, key, 4, target)
+
+	// 交易 0 转入 target 但执行失败，其中的代币转账日志同样无效
+	receipts[0].Status = types.ReceiptStatusFailed
+	receipts[0].Logs = []*types.Log{transferLog(token, 3, target, target, 5, 0)}
+	receipts[3].Logs = []*types.Log{transferLog(token, 1, common.Address{}, target, 9, 1)}
+	client := eth.NewFakeClient(testChainID)
+	client.AddBlock(block, receipts)
+
+	parser := NewReceiptParser(client, testChainID, NewTransferFilter())
+	got, _, err := parser.ParseAndFilterByBlock(context.Background(), 100, []string{target.Hex()},
+		[]string{"ETH", "USDT"}, map[string]string{token.Hex(): "USDT"}, 2)
+	if err != nil {
+		t.Fatalf("parse block failed: %v", err)
+	}
+	if len(got) != 2 || got[0].AssetType != AssetTypeERC20 || got[0].Amount != "9" || got[1].Amount != "4" {
+		t.Fatalf("expected unindexed token transfer and tx 3 only, got %+v", got)
+	}
+}
+
+func TestParseAndFilterByBlock_CapsFeeOnTransferAmounts(t *testing.T) {
+	target := common.HexToAddress("0xabc0000000000000000000000000000000000001")
+	other := common.HexToAddress("0x00000000000000000000000000000000000000ff")
+	token := common.HexToAddress("0xfee0000000000000000000000000000000000fee")
+	key, _ := crypto.GenerateKey()
+	block, receipts := signedTransferBlock(t, key, 3, other)
+
+	// 日志记录流入 600 + 400、转出 100，扣除 3% 手续费后余额只增加 870
+	receipts[0].Logs = []*types.Log{transferLog(token, 3, other, target, 600, 0)}
+	receipts[1].Logs = []*types.Log{transferLog(token, 3, other, target, 400, 1)}
+	receipts[2].Logs = []*types.Log{transferLog(token, 3, target, other, 100, 2)}
+	client := eth.NewFakeClient(testChainID)
+	client.AddBlock(block, receipts)
+	client.SetTokenBalance(token, target, 99, big.NewInt(5000))
+	client.SetTokenBalance(token, target, 100, big.NewInt(5870))
+
+	parser := NewReceiptParser(client, testChainID, NewTransferFilter())
+	parse := func() []TransferRecord {
+		got, _, err := parser.ParseAndFilterByBlock(context.Background(), 100, []string{target.Hex()},
+			[]string{"FEE"}, map[string]string{token.Hex(): "FEE"}, 2)
+		if err != nil {
+			t.Fatalf("parse block failed: %v", err)
+		}
+		return got
+	}
+
+	if got := parse(); len(got) != 2 || got[0].Amount != "600" || got[1].Amount != "400" {
+		t.Fatalf("amounts must be taken from logs unless the token is checked, got %+v", got)
+	}
+
+	parser.SetBalanceCheckedTokens([]string{token.Hex()})
+	got := parse()
+	if len(got) != 2 || got[0].Amount != "582" || got[1].Amount != "388" {
+		t.Fatalf("expected amounts capped to 970 received, got %+v", got)
+	}
+	if calls := client.CallCount("TokenBalanceAt"); calls != 2 {
+		t.Fatalf("expected one balance lookup before and after the block, got %d", calls)
+	}
+}
+
+func TestParseAndFilterByBlock_ChecksMinDepositAfterCap(t *testing.T) {
+	target := common.HexToAddress("0xabc0000000000000000000000000000000000001")
+	other := common.HexToAddress("0x00000000000000000000000000000000000000ff")
+	token := common.HexToAddress("0xfee0000000000000000000000000000000000fee")
+	key, _ := crypto.GenerateKey()
+	block, receipts := signedTransferBlock(t, key, 2, other)
+
+	// 日志流入 600 + 400，扣除 3% 手续费后实际到账 582 + 388
+	receipts[0].Logs = []*types.Log{transferLog(token, 3, other, target, 600, 0)}
+	receipts[1].Logs = []*types.Log{transferLog(token, 3, other, target, 400, 1)}
+	client := eth.NewFakeClient(testChainID)
+	client.AddBlock(block, receipts)
+	client.SetTokenBalance(token, target, 99, big.NewInt(0))
+	client.SetTokenBalance(token, target, 100, big.NewInt(970))
+
+	filter := NewTransferFilterWithCoinConfigs(staticCoinConfigs{
+		"FEE": {Coin: "FEE", MinDeposit: "390", Enabled: true},
+	})
+	parser := NewReceiptParser(client, testChainID, filter)
+	parser.SetBalanceCheckedTokens([]string{token.Hex()})
+
+	got, rejected, err := parser.ParseAndFilterByBlock(context.Background(), 100, []string{target.Hex()},
+		[]string{"FEE"}, map[string]string{token.Hex(): "FEE"}, 2)
+	if err != nil {
+		t.Fatalf("parse block failed: %v", err)
+	}
+	if len(got) != 1 || got[0].Amount != "582" {
+		t.Fatalf("expected only the 582 transfer accepted, got %+v", got)
+	}
+	if len(rejected) != 1 || rejected[0].Amount != "388" || rejected[0].Reason != RejectReasonBelowMinDeposit {
+		t.Fatalf("expected capped 388 transfer rejected below min deposit, got %+v", rejected)
+	}
+}
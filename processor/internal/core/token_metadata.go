@@ -0,0 +1,147 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"go_bullayer_v1/base/pkg/eth"
+	"go_bullayer_v1/base/pkg/utils"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// NativeDecimals 原生 ETH 精度
+const NativeDecimals = 18
+
+// symbolSelector symbol() 方法选择器
+var symbolSelector = []byte{0x95, 0xd8, 0x9b, 0x41}
+
+// TokenMetadata ERC20 代币元数据
+type TokenMetadata struct {
+	Symbol   string
+	Decimals int
+}
+
+// TokenMetadataResolver 通过 eth_call 查询并缓存代币的 decimals() 和 symbol()。
+// 配置覆盖优先于链上查询，用于 symbol 非标准或需要固定精度的代币。
+type TokenMetadataResolver struct {
+	client    eth.Client
+	mu        sync.RWMutex
+	cache     map[common.Address]TokenMetadata
+	overrides map[common.Address]TokenMetadata
+}
+
+// NewTokenMetadataResolver 创建代币元数据解析器
+func NewTokenMetadataResolver(client eth.Client) *TokenMetadataResolver {
+	return &TokenMetadataResolver{
+		client:    client,
+		cache:     make(map[common.Address]TokenMetadata),
+		overrides: make(map[common.Address]TokenMetadata),
+	}
+}
+
+// SetOverride 覆盖代币元数据；symbol 为空时 symbol 仍从链上查询，decimals 小于 0 时精度仍从链上查询
+func (r *TokenMetadataResolver) SetOverride(token string, symbol string, decimals int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	addr := common.HexToAddress(token)
+	override := TokenMetadata{Symbol: strings.ToUpper(strings.TrimSpace(symbol)), Decimals: decimals}
+	if old, ok := r.overrides[addr]; ok && old == override {
+		return
+	}
+	r.overrides[addr] = override
+	delete(r.cache, addr)
+}
+
+// Resolve 返回代币元数据，首次查询后缓存
+func (r *TokenMetadataResolver) Resolve(ctx context.Context, token string) (TokenMetadata, error) {
+	addr := common.HexToAddress(token)
+
+	r.mu.RLock()
+	meta, ok := r.cache[addr]
+	override, hasOverride := r.overrides[addr]
+	r.mu.RUnlock()
+	if ok {
+		return meta, nil
+	}
+
+	if !hasOverride {
+		override = TokenMetadata{Decimals: -1}
+	}
+	meta = override
+	if meta.Decimals < 0 {
+		decimals, err := eth.ERC20Decimals(ctx, r.client, addr)
+		if err != nil {
+			return TokenMetadata{}, err
+		}
+		meta.Decimals = decimals
+	}
+	if meta.Symbol == "" {
+		// symbol 只用于展示和日志，查询失败时用合约地址占位
+		symbol, err := r.querySymbol(ctx, addr)
+		if err != nil {
+			symbol = strings.ToLower(addr.Hex())
+		}
+		meta.Symbol = symbol
+	}
+
+	r.mu.Lock()
+	r.cache[addr] = meta
+	r.mu.Unlock()
+	return meta, nil
+}
+
+// querySymbol 兼容返回 string 和 bytes32（例如 MKR）的实现
+func (r *TokenMetadataResolver) querySymbol(ctx context.Context, token common.Address) (string, error) {
+	out, err := r.client.CallContract(ctx, token, symbolSelector, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var symbol string
+	switch {
+	case len(out) == 32:
+		symbol = string(bytes.TrimRight(out, "\x00"))
+	case len(out) >= 64:
+		offset := new(big.Int).SetBytes(out[:32])
+		if !offset.IsInt64() || offset.Int64()+32 > int64(len(out)) {
+			return "", fmt.Errorf("invalid symbol() offset of %s", token.Hex())
+		}
+		start := offset.Int64()
+		length := new(big.Int).SetBytes(out[start : start+32])
+		if !length.IsInt64() || start+32+length.Int64() > int64(len(out)) {
+			return "", fmt.Errorf("invalid symbol() length of %s", token.Hex())
+		}
+		symbol = string(out[start+32 : start+32+length.Int64()])
+	default:
+		return "", fmt.Errorf("symbol() of %s returned %d bytes", token.Hex(), len(out))
+	}
+	return strings.ToUpper(strings.TrimSpace(symbol)), nil
+}
+
+// normalizeAmount 为转账记录补充精度和十进制金额，ERC20 精度按合约解析，未设置解析器时保持为空
+func (p *ReceiptParser) normalizeAmount(ctx context.Context, r *TransferRecord) error {
+	decimals := NativeDecimals
+	if r.AssetType == AssetTypeERC20 {
+		if p.tokens == nil {
+			return nil
+		}
+		meta, err := p.tokens.Resolve(ctx, r.TokenAddress)
+		if err != nil {
+			return err
+		}
+		decimals = meta.Decimals
+	}
+
+	amount, err := utils.FormatUnits(r.Amount, decimals)
+	if err != nil {
+		return fmt.Errorf("format amount of tx %s failed: %w", r.TxHash, err)
+	}
+	r.Decimals = decimals
+	r.DecimalAmount = amount
+	return nil
+}
@@ -0,0 +1,116 @@
+package core
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"go_bullayer_v1/base/pkg/eth"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// abiString 按 ABI 编码动态 string 返回值
+func abiString(s string) []byte {
+	out := common.LeftPadBytes(big.NewInt(32).Bytes(), 32)
+	out = append(out, common.LeftPadBytes(big.NewInt(int64(len(s))).Bytes(), 32)...)
+	return append(out, common.RightPadBytes([]byte(s), (len(s)+31)/32*32)...)
+}
+
+func TestTokenMetadataResolver_Resolve(t *testing.T) {
+	usdt := common.HexToAddress("0x7169d38820dfd117c3fa1f22a697dba58d90ba06")
+	mkr := common.HexToAddress("0x9f8f72aa9304c8b593d555f12ef6589cc3a579a2")
+	client := eth.NewFakeClient(testChainID)
+	client.SetCallResult(usdt, eth.ERC20DecimalsSelector, common.LeftPadBytes([]byte{6}, 32))
+	client.SetCallResult(usdt, symbolSelector, abiString("usdt"))
+	client.SetCallResult(mkr, eth.ERC20DecimalsSelector, common.LeftPadBytes([]byte{18}, 32))
+	client.SetCallResult(mkr, symbolSelector, common.RightPadBytes([]byte("MKR"), 32))
+
+	r := NewTokenMetadataResolver(client)
+	ctx := context.Background()
+	for _, tc := range []struct {
+		token common.Address
+		want  TokenMetadata
+	}{
+		{usdt, TokenMetadata{Symbol: "USDT", Decimals: 6}},
+		{mkr, TokenMetadata{Symbol: "MKR", Decimals: 18}},
+		{usdt, TokenMetadata{Symbol: "USDT", Decimals: 6}},
+	} {
+		got, err := r.Resolve(ctx, tc.token.Hex())
+		if err != nil {
+			t.Fatalf("resolve %s failed: %v", tc.token.Hex(), err)
+		}
+		if got != tc.want {
+			t.Fatalf("resolve %s: expected %+v, got %+v", tc.token.Hex(), tc.want, got)
+		}
+	}
+	if calls := client.CallCount("CallContract"); calls != 4 {
+		t.Fatalf("metadata must be cached after the first lookup, got %d calls", calls)
+	}
+}
+
+func TestTokenMetadataResolver_Overrides(t *testing.T) {
+	token := common.HexToAddress("0x1c7d4b196cb0c7b01d743fbc6116a902379c7238")
+	client := eth.NewFakeClient(testChainID)
+	client.SetCallResult(token, eth.ERC20DecimalsSelector, common.LeftPadBytes([]byte{6}, 32))
+
+	r := NewTokenMetadataResolver(client)
+	r.SetOverride(token.Hex(), "usdc", -1)
+	got, err := r.Resolve(context.Background(), token.Hex())
+	if err != nil {
+		t.Fatalf("resolve failed: %v", err)
+	}
+	if got.Symbol != "USDC" || got.Decimals != 6 {
+		t.Fatalf("expected overridden symbol with on-chain decimals, got %+v", got)
+	}
+
+	r.SetOverride(token.Hex(), "USDC", 8)
+	if got, _ := r.Resolve(context.Background(), token.Hex()); got.Decimals != 8 {
+		t.Fatalf("configured decimals must win, got %+v", got)
+	}
+	if calls := client.CallCount("CallContract"); calls != 1 {
+		t.Fatalf("overridden fields must not be queried, got %d calls", calls)
+	}
+
+	if _, err := NewTokenMetadataResolver(client).Resolve(context.Background(), common.Address{}.Hex()); err == nil {
+		t.Fatal("expected error for token without decimals()")
+	}
+}
+
+func TestParseAndFilterByBlock_NormalizesAmounts(t *testing.T) {
+	target := common.HexToAddress("0xabc0000000000000000000000000000000000001")
+	other := common.HexToAddress("0x00000000000000000000000000000000000000ff")
+	usdt := common.HexToAddress("0x7169d38820dfd117c3fa1f22a697dba58d90ba06")
+	key, _ := crypto.GenerateKey()
+	block, receipts := signedTransferBlock(t, key, 2, target)
+	receipts[1].Logs = []*types.Log{transferLog(usdt, 3, other, target, 1500000, 0)}
+
+	client := eth.NewFakeClient(testChainID)
+	client.AddBlock(block, receipts)
+	client.SetCallResult(usdt, eth.ERC20DecimalsSelector, common.LeftPadBytes([]byte{6}, 32))
+
+	parser := NewReceiptParser(client, testChainID, NewTransferFilter())
+	parser.SetTokenMetadata(NewTokenMetadataResolver(client))
+	got, _, err := parser.ParseAndFilterByBlock(context.Background(), 100, []string{target.Hex()},
+		[]string{"ETH", "USDT"}, map[string]string{usdt.Hex(): "USDT"}, 2)
+	if err != nil {
+		t.Fatalf("parse block failed: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 transfers, got %+v", got)
+	}
+	for _, r := range got {
+		switch r.AssetType {
+		case AssetTypeERC20:
+			if r.Amount != "1500000" || r.Decimals != 6 || r.DecimalAmount != "1.5" {
+				t.Fatalf("unexpected USDT amount: %+v", r)
+			}
+		case AssetTypeETH:
+			if r.Decimals != NativeDecimals || r.DecimalAmount != "0.000000000000000001" {
+				t.Fatalf("unexpected ETH amount: %+v", r)
+			}
+		}
+	}
+}
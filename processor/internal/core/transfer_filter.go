@@ -9,29 +9,51 @@ import (
 type AssetType string
 
 const (
-	AssetTypeETH   AssetType = "ETH"
-	AssetTypeERC20 AssetType = "ERC20"
+	AssetTypeETH         AssetType = "ETH"
+	AssetTypeERC20       AssetType = "ERC20"
+	AssetTypeInternalETH AssetType = "INTERNAL_ETH" // 合约内部调用转出的 ETH（通过 callTracer 追踪）
 )
 
+// NativeEventIndex 原生 ETH 转账的事件序号
+const NativeEventIndex = -1
+
 // TransferRecord 统一转账记录结构
 type TransferRecord struct {
-	TxHash       string
-	BlockNumber  int64
-	From         string
-	To           string
-	Amount       string
-	AssetType    AssetType
-	TokenAddress string // 原生 ETH 可为空
-	TokenSymbol  string // 例如 ETH/USDT/BTC/WBTC
+	TxHash        string
+	EventIndex    int // ERC20 为日志在区块内的序号，原生 ETH 为 -1，内部转账见 InternalEventIndex
+	BlockNumber   int64
+	From          string
+	To            string
+	Amount        string // 最小单位的原始金额
+	AssetType     AssetType
+	TokenAddress  string // 原生 ETH 可为空
+	TokenSymbol   string // 例如 ETH/USDT/BTC/WBTC
+	Decimals      int    // 代币精度，未解析时为 0
+	DecimalAmount string // 按 Decimals 换算后的十进制金额，未解析时为空
 }
 
 // TransferFilter 按地址集合和资产白名单筛选“流入”交易。
-type TransferFilter struct{}
+// 设置了币种配置源时，还会按 coin_configs 的启用状态和最小充值金额校验。
+type TransferFilter struct {
+	coinConfigs CoinConfigSource
+	internal    map[string]struct{} // 内部钱包地址，从这些地址转入的不是用户充值
+}
 
 func NewTransferFilter() *TransferFilter {
 	return &TransferFilter{}
 }
 
+// NewTransferFilterWithCoinConfigs 创建按币种配置校验的过滤器
+func NewTransferFilterWithCoinConfigs(source CoinConfigSource) *TransferFilter {
+	return &TransferFilter{coinConfigs: source}
+}
+
+// SetInternalAddresses 设置内部钱包地址（热钱包、归集 gas 钱包等），
+// 从这些地址转入目标地址的转账（例如归集前的 gas 补充）不作为充值
+func (f *TransferFilter) SetInternalAddresses(addresses []string) {
+	f.internal = makeSet(addresses)
+}
+
 // DefaultTrackedAssets 默认资产白名单
 func DefaultTrackedAssets() []string {
 	return []string{"ETH", "USDT", "BTC", "WBTC"}
@@ -42,13 +64,23 @@ func (f *TransferFilter) FilterIncomingTransfers(
 	trackedAssets []string,
 	transfers []TransferRecord,
 ) []TransferRecord {
+	accepted, _ := f.FilterTransfers(targetAddresses, trackedAssets, transfers)
+	return accepted
+}
+
+// FilterTransfers 筛选流入交易，同时返回流入目标地址但未通过币种配置校验的交易及原因
+func (f *TransferFilter) FilterTransfers(
+	targetAddresses []string,
+	trackedAssets []string,
+	transfers []TransferRecord,
+) ([]TransferRecord, []RejectedTransfer) {
 	if len(targetAddresses) == 0 || len(transfers) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	addressSet := makeSet(targetAddresses)
 	if len(addressSet) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	if len(trackedAssets) == 0 {
@@ -56,33 +88,78 @@ func (f *TransferFilter) FilterIncomingTransfers(
 	}
 	assetSet := makeSet(trackedAssets)
 	if len(assetSet) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	results := make([]TransferRecord, 0, len(transfers))
+	var rejected []RejectedTransfer
 	for _, t := range transfers {
 		to := normalize(t.To)
 		if _, ok := addressSet[to]; !ok {
 			continue
 		}
+		if _, ok := f.internal[normalize(t.From)]; ok {
+			logger.Info("内部钱包转账，不作为充值: %+v", t)
+			continue
+		}
 
-		symbol := normalize(t.TokenSymbol)
+		symbol := transferSymbol(t)
 		if symbol == "" {
-			if t.AssetType == AssetTypeETH {
-				symbol = "eth"
-			} else {
-				continue
-			}
+			continue
 		}
-
 		if _, ok := assetSet[symbol]; !ok {
 			continue
 		}
+		if reason := f.coinConfigReason(symbol, t); reason != "" {
+			logger.Info("交易未通过币种配置校验(%s): %+v", reason, t)
+			rejected = append(rejected, RejectedTransfer{TransferRecord: t, Reason: reason})
+			continue
+		}
 		logger.Info("过滤到交易: %+v", t)
 		results = append(results, t)
 	}
 
-	return results
+	return results, rejected
+}
+
+// RecheckCoinConfigs 金额调整后（例如手续费代币按实际到账扣减）按币种配置重新校验已通过的转账，
+// 返回仍然通过的转账和新增的拒绝记录
+func (f *TransferFilter) RecheckCoinConfigs(transfers []TransferRecord) ([]TransferRecord, []RejectedTransfer) {
+	if f.coinConfigs == nil {
+		return transfers, nil
+	}
+	results := make([]TransferRecord, 0, len(transfers))
+	var rejected []RejectedTransfer
+	for _, t := range transfers {
+		if reason := f.coinConfigReason(transferSymbol(t), t); reason != "" {
+			logger.Info("交易按实际到账金额未通过币种配置校验(%s): %+v", reason, t)
+			rejected = append(rejected, RejectedTransfer{TransferRecord: t, Reason: reason})
+			continue
+		}
+		results = append(results, t)
+	}
+	return results, rejected
+}
+
+// coinConfigReason 按币种配置校验转账，未设置配置源或币种未配置时视为通过
+func (f *TransferFilter) coinConfigReason(symbol string, t TransferRecord) string {
+	if f.coinConfigs == nil {
+		return ""
+	}
+	cfg, ok := f.coinConfigs.CoinConfig(strings.ToUpper(symbol))
+	if !ok {
+		return ""
+	}
+	return checkCoinConfig(cfg, t)
+}
+
+// transferSymbol 转账的小写币种符号，未解析符号的原生 ETH 记为 eth，其他未知资产返回空
+func transferSymbol(t TransferRecord) string {
+	symbol := normalize(t.TokenSymbol)
+	if symbol == "" && t.AssetType == AssetTypeETH {
+		return "eth"
+	}
+	return symbol
 }
 
 func makeSet(values []string) map[string]struct{} {
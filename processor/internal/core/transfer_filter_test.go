@@ -64,3 +64,65 @@ func TestFilterIncomingTransfers_UseDefaultAssets(t *testing.T) {
 		t.Fatalf("expected 3 records with default assets, got %d", len(got))
 	}
 }
+
+// staticCoinConfigs 固定的币种配置源
+type staticCoinConfigs map[string]CoinConfig
+
+func (s staticCoinConfigs) CoinConfig(coin string) (CoinConfig, bool) {
+	cfg, ok := s[coin]
+	return cfg, ok
+}
+
+func TestFilterTransfers_EnforceCoinConfigs(t *testing.T) {
+	filter := NewTransferFilterWithCoinConfigs(staticCoinConfigs{
+		"ETH":  {Coin: "ETH", MinDeposit: "0.01", Decimals: 18, Enabled: true},
+		"USDT": {Coin: "USDT", MinDeposit: "10", Decimals: 6, Enabled: true},
+		"WBTC": {Coin: "WBTC", MinDeposit: "0", Decimals: 8, Enabled: false},
+	})
+
+	to := "0xabc0000000000000000000000000000000000001"
+	input := []TransferRecord{
+		{TxHash: "0x1", To: to, AssetType: AssetTypeETH, TokenSymbol: "ETH", Amount: "10000000000000000"},
+		{TxHash: "0x2", To: to, AssetType: AssetTypeETH, TokenSymbol: "ETH", Amount: "9999999999999999"},
+		{TxHash: "0x3", To: to, AssetType: AssetTypeERC20, TokenSymbol: "USDT", Amount: "9999999"},
+		{TxHash: "0x4", To: to, AssetType: AssetTypeERC20, TokenSymbol: "WBTC", Amount: "100000000"},
+		{TxHash: "0x5", To: to, AssetType: AssetTypeERC20, TokenSymbol: "USDT", Amount: "not-a-number"},
+		{TxHash: "0x6", To: to, AssetType: AssetTypeERC20, TokenSymbol: "BTC", Amount: "1"},
+	}
+
+	accepted, rejected := filter.FilterTransfers([]string{to}, nil, input)
+	if len(accepted) != 2 || accepted[0].TxHash != "0x1" || accepted[1].TxHash != "0x6" {
+		t.Fatalf("unexpected accepted transfers: %+v", accepted)
+	}
+
+	want := map[string]string{
+		"0x2": RejectReasonBelowMinDeposit,
+		"0x3": RejectReasonBelowMinDeposit,
+		"0x4": RejectReasonCoinDisabled,
+		"0x5": RejectReasonInvalidAmount,
+	}
+	if len(rejected) != len(want) {
+		t.Fatalf("expected %d rejected transfers, got %+v", len(want), rejected)
+	}
+	for _, r := range rejected {
+		if want[r.TxHash] != r.Reason {
+			t.Fatalf("tx %s rejected with %q, want %q", r.TxHash, r.Reason, want[r.TxHash])
+		}
+	}
+}
+
+func TestFilterTransfers_SkipInternalSenders(t *testing.T) {
+	filter := NewTransferFilter()
+	filter.SetInternalAddresses([]string{"0xFEE0000000000000000000000000000000000001"})
+
+	target := "0xabc0000000000000000000000000000000000001"
+	input := []TransferRecord{
+		{TxHash: "0x1", From: "0xfee0000000000000000000000000000000000001", To: target, AssetType: AssetTypeETH, TokenSymbol: "ETH"},
+		{TxHash: "0x2", From: "0x1230000000000000000000000000000000000001", To: target, AssetType: AssetTypeETH, TokenSymbol: "ETH"},
+	}
+
+	got, rejected := filter.FilterTransfers([]string{target}, []string{"ETH"}, input)
+	if len(got) != 1 || got[0].TxHash != "0x2" || len(rejected) != 0 {
+		t.Fatalf("expected only the external transfer, got %+v rejected %+v", got, rejected)
+	}
+}
@@ -0,0 +1,169 @@
+package processor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"go_bullayer_v1/base/pkg/db"
+	"go_bullayer_v1/base/pkg/eth"
+	"go_bullayer_v1/base/pkg/logger"
+	"go_bullayer_v1/processor/internal/config"
+	"go_bullayer_v1/processor/internal/store"
+)
+
+// BackfillProgress 回填进度
+type BackfillProgress struct {
+	From       int64
+	To         int64
+	Height     int64 // 已完整写入的最高区块
+	Deposits   int   // 本次运行写入的充值记录数（含已存在的幂等写入）
+	Rejections int   // 本次运行记录的拒绝转账数
+}
+
+// Percent 已完成的百分比
+func (p BackfillProgress) Percent() float64 {
+	total := p.To - p.From + 1
+	if total <= 0 {
+		return 100
+	}
+	return float64(p.Height-p.From+1) * 100 / float64(total)
+}
+
+// Backfill 历史区块回填。
+// 复用区块处理任务的解析和入库逻辑重新扫描 [From, To]，充值按 (chain_id, tx_hash, event_index) 幂等写入；
+// 进度保存在独立的 backfill_<from>_<to> 游标中，中断后以相同区间重新运行即可续跑，不影响实时游标和重组检测窗口。
+type Backfill struct {
+	processor *BlockProcessor
+	cursor    string
+	from      int64
+	to        int64
+	next      int64
+}
+
+// NewBackfill 创建回填任务并加载续跑进度
+func NewBackfill(ctx context.Context, cfg config.Config, db *sql.DB, chain eth.Client, from, to int64) (*Backfill, error) {
+	if from < 0 || to < from {
+		return nil, fmt.Errorf("回填区间无效: [%d, %d]", from, to)
+	}
+	if db == nil {
+		return nil, errors.New("回填需要配置数据库")
+	}
+	if chain == nil {
+		return nil, errors.New("未初始化链客户端，请检查 Chain.RPCURL 配置")
+	}
+
+	b := &Backfill{
+		processor: newBlockProcessor(cfg, db, chain),
+		cursor:    fmt.Sprintf("backfill_%d_%d", from, to),
+		from:      from,
+		to:        to,
+		next:      from,
+	}
+
+	if err := b.processor.initEvents(); err != nil {
+		return nil, err
+	}
+
+	cursor, found, err := store.LoadCursor(ctx, db, cfg.Chain.ChainID, b.cursor)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		b.next = cursor.Height + 1
+		logger.Info("已加载回填游标 %s，从高度 %d 继续", b.cursor, b.next)
+	}
+
+	if err := b.processor.refreshCoinConfigs(ctx); err != nil {
+		return nil, err
+	}
+	if err := b.processor.refreshDepositAddresses(ctx); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// CursorName 回填游标名称
+func (b *Backfill) CursorName() string {
+	return b.cursor
+}
+
+// Run 按 BlockProcessor.BatchSize 并发解析区块并按高度顺序写入，每批完成后回调 report
+func (b *Backfill) Run(ctx context.Context, report func(BackfillProgress)) error {
+	progress := BackfillProgress{From: b.from, To: b.to, Height: b.next - 1}
+	if b.next > b.to {
+		logger.Info("回填区间 [%d, %d] 已完成", b.from, b.to)
+		if report != nil {
+			report(progress)
+		}
+		return nil
+	}
+
+	latest, err := b.processor.fetchLatestHeight(ctx)
+	if err != nil {
+		return err
+	}
+	// 回填不写入区块哈希窗口，无法检测重组，只允许处理已达到确认数的区块
+	confirmed := latest - max(b.processor.config.Chain.Confirmations, 0)
+	if b.to > confirmed {
+		return fmt.Errorf("回填终点 %d 超过已确认高度 %d（最新高度 %d，确认数 %d）",
+			b.to, confirmed, latest, b.processor.config.Chain.Confirmations)
+	}
+
+	batchSize := b.processor.config.BlockProcessor.BatchSize
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+	for h := b.next; h <= b.to; {
+		end := min(h+batchSize-1, b.to)
+		results, fetchErr := b.processor.fetchBlocks(ctx, h, end)
+
+		for _, result := range results {
+			if err := b.commit(ctx, result); err != nil {
+				return err
+			}
+			progress.Height = result.height
+			progress.Deposits += len(result.transfers)
+			progress.Rejections += len(result.rejected)
+		}
+		b.next = progress.Height + 1
+		if report != nil {
+			report(progress)
+		}
+		if fetchErr != nil {
+			return fetchErr
+		}
+		h = end + 1
+	}
+
+	logger.Info("回填区间 [%d, %d] 完成，写入充值 %d 条，拒绝 %d 条", b.from, b.to, progress.Deposits, progress.Rejections)
+	return nil
+}
+
+// commit 在同一事务内写入区块结果并推进回填游标，不写入区块哈希窗口
+func (b *Backfill) commit(ctx context.Context, result *blockResult) error {
+	p := b.processor
+	cursor := store.BlockCursor{
+		ChainID:   p.config.Chain.ChainID,
+		Name:      b.cursor,
+		Height:    result.height,
+		BlockHash: result.hash,
+	}
+	err := db.WithTx(ctx, p.db, func(tx *sql.Tx) error {
+		if err := p.saveDeposits(ctx, tx, result.transfers); err != nil {
+			return err
+		}
+		if err := p.saveRejections(ctx, tx, result.rejected); err != nil {
+			return err
+		}
+		if err := p.dispatchEvents(ctx, tx, result.events); err != nil {
+			return err
+		}
+		return store.SaveCursor(ctx, tx, cursor)
+	})
+	if err != nil {
+		return fmt.Errorf("回填区块 %d 失败: %w", result.height, err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestBackfill_ResumesFromOwnCursorWithoutTouchingLiveState(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock failed: %v", err)
+	}
+	defer db.Close()
+
+	cfg := newTestConfig(16)
+	cfg.BlockProcessor.BatchSize = 2
+	chain := newFakeChain(21)
+
+	mock.ExpectQuery("SELECT height, block_hash FROM block_cursors").
+		WithArgs(cfg.Chain.ChainID, "backfill_10_15").
+		WillReturnRows(sqlmock.NewRows([]string{"height", "block_hash"}).AddRow(12, chain.hash(12)))
+	mock.ExpectQuery("SELECT coin, coin_address, min_deposit, status FROM coin_configs").
+		WillReturnRows(sqlmock.NewRows([]string{"coin", "coin_address", "min_deposit", "status"}))
+	mock.ExpectQuery("SELECT id, account_id, address, derivation_index FROM deposit_addresses").
+		WithArgs(int64(0)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "address", "derivation_index"}))
+
+	// 只推进回填游标，不写区块哈希窗口、不刷新确认数
+	for h := int64(13); h <= 15; h++ {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO block_cursors").
+			WithArgs(cfg.Chain.ChainID, "backfill_10_15", h, chain.hash(h)).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+	}
+
+	b, err := NewBackfill(context.Background(), cfg, db, chain, 10, 15)
+	if err != nil {
+		t.Fatalf("create backfill failed: %v", err)
+	}
+
+	var reports []BackfillProgress
+	if err := b.Run(context.Background(), func(p BackfillProgress) { reports = append(reports, p) }); err != nil {
+		t.Fatalf("backfill failed: %v", err)
+	}
+	if len(reports) != 2 || reports[0].Height != 14 || reports[1].Height != 15 || reports[1].Percent() != 100 {
+		t.Fatalf("unexpected progress reports: %+v", reports)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewBackfill_RejectsInvalidRange(t *testing.T) {
+	if _, err := NewBackfill(context.Background(), newTestConfig(16), nil, newFakeChain(1), 20, 10); err == nil {
+		t.Fatal("expected error for from > to")
+	}
+}
+
+func TestBackfill_RejectsUnconfirmedBlocks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock failed: %v", err)
+	}
+	defer db.Close()
+
+	cfg := newTestConfig(16)
+	cfg.Chain.Confirmations = 6
+	chain := newFakeChain(21) // 最新高度 20，已确认高度 14
+
+	mock.ExpectQuery("SELECT height, block_hash FROM block_cursors").
+		WithArgs(cfg.Chain.ChainID, "backfill_10_15").
+		WillReturnRows(sqlmock.NewRows([]string{"height", "block_hash"}))
+	mock.ExpectQuery("SELECT coin, coin_address, min_deposit, status FROM coin_configs").
+		WillReturnRows(sqlmock.NewRows([]string{"coin", "coin_address", "min_deposit", "status"}))
+	mock.ExpectQuery("SELECT id, account_id, address, derivation_index FROM deposit_addresses").
+		WithArgs(int64(0)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "address", "derivation_index"}))
+
+	b, err := NewBackfill(context.Background(), cfg, db, chain, 10, 15)
+	if err != nil {
+		t.Fatalf("create backfill failed: %v", err)
+	}
+	if err := b.Run(context.Background(), nil); err == nil {
+		t.Fatal("expected error for unconfirmed end height")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
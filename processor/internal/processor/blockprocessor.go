@@ -2,37 +2,133 @@ package processor
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"math/big"
 	"sync"
 	"time"
 
+	"go_bullayer_v1/base/pkg/db"
 	"go_bullayer_v1/base/pkg/eth"
 	"go_bullayer_v1/base/pkg/logger"
 	"go_bullayer_v1/processor/internal/config"
 	"go_bullayer_v1/processor/internal/core"
+	"go_bullayer_v1/processor/internal/store"
+
+	"github.com/ethereum/go-ethereum/core/types"
 )
 
+// cursorName 区块处理游标名称
+const cursorName = "block_processor"
+
 // BlockProcessor 区块处理任务
 // 负责追踪链上区块高度并解析区块数据
 type BlockProcessor struct {
-	config         config.Config
-	mu             sync.Mutex
-	currentHeight  int64
-	mockLatestHead int64
+	config           config.Config
+	db               *sql.DB
+	chain            eth.Client
+	window           *blockWindow
+	coinConfigs      *coinConfigCache
+	depositAddresses *depositAddressSet
+	parser           *core.ReceiptParser
+	tokens           *core.TokenMetadataResolver // chain 为空时为空
+	events           *core.EventRegistry         // 未开启 ParseEvent 或未配置合约时为空
+	eventHandlers    []eventHandlerEntry
+	mu               sync.Mutex
+	currentHeight    int64
+	currentHash      string
+}
+
+// blockResult 单个区块的解析结果，随游标一起提交
+type blockResult struct {
+	height     int64
+	hash       string
+	parentHash string
+	header     *types.Header
+	transfers  []core.TransferRecord
+	rejected   []core.RejectedTransfer
+	events     []core.EventRecord
 }
 
 // NewBlockProcessor 创建区块处理任务
-func NewBlockProcessor(cfg config.Config) *BlockProcessor {
+// db 为空时游标只保存在内存中，重启后从 Chain.StartHeight 重新开始；chain 不能为空，
+// 本地调试请使用 Chain.Mode=simulate 提供的模拟链
+func NewBlockProcessor(ctx context.Context, cfg config.Config, db *sql.DB, chain eth.Client) (*BlockProcessor, error) {
+	if chain == nil {
+		return nil, errors.New("未初始化链客户端，请检查 Chain.RPCURL 配置")
+	}
+	p := newBlockProcessor(cfg, db, chain)
+	startHeight := p.currentHeight
+	if err := p.initEvents(); err != nil {
+		return nil, err
+	}
+
+	if db == nil {
+		logger.Info("未配置数据库，区块游标仅保存在内存中，起始高度 %d", startHeight)
+		return p, nil
+	}
+
+	cursor, found, err := store.LoadCursor(ctx, db, cfg.Chain.ChainID, cursorName)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		p.currentHeight = cursor.Height
+		p.currentHash = cursor.BlockHash
+		logger.Info("已加载区块游标，从高度 %d(%s) 继续处理", cursor.Height, cursor.BlockHash)
+	} else {
+		logger.Info("未找到区块游标，从配置起始高度 %d 开始处理", startHeight)
+	}
+
+	if err := p.loadBlockWindow(ctx); err != nil {
+		return nil, err
+	}
+	if err := p.refreshCoinConfigs(ctx); err != nil {
+		return nil, err
+	}
+	if err := p.refreshDepositAddresses(ctx); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// newBlockProcessor 按配置构建区块处理任务，不加载持久化状态
+func newBlockProcessor(cfg config.Config, db *sql.DB, chain eth.Client) *BlockProcessor {
 	startHeight := cfg.Chain.StartHeight
 	if startHeight < 0 {
 		startHeight = 0
 	}
 
-	return &BlockProcessor{
-		config:         cfg,
-		currentHeight:  startHeight,
-		mockLatestHead: startHeight + 50,
+	coinConfigs := newCoinConfigCache()
+	filter := core.NewTransferFilterWithCoinConfigs(coinConfigs)
+	filter.SetInternalAddresses(cfg.BlockProcessor.InternalAddresses)
+	parser := core.NewReceiptParser(chain, cfg.Chain.ChainID, filter)
+	parser.SetRetryPolicy(cfg.BlockProcessor.ParseRetries,
+		time.Duration(cfg.BlockProcessor.ParseRetryDelay)*time.Millisecond)
+	if cfg.BlockProcessor.TraceInternal {
+		parser.EnableInternalTransfers()
+	}
+	parser.SetBalanceCheckedTokens(cfg.BlockProcessor.FeeOnTransferTokens)
+
+	p := &BlockProcessor{
+		config:           cfg,
+		db:               db,
+		chain:            chain,
+		window:           newBlockWindow(cfg.Chain.ReorgWindow),
+		coinConfigs:      coinConfigs,
+		depositAddresses: newDepositAddressSet(),
+		parser:           parser,
+		currentHeight:    startHeight,
+	}
+	if chain != nil {
+		p.tokens = core.NewTokenMetadataResolver(chain)
+		for addr, symbol := range cfg.BlockProcessor.TokenContracts {
+			p.overrideTokenMetadata(addr, symbol)
+		}
+		parser.SetTokenMetadata(p.tokens)
 	}
+	return p
 }
 
 // Name 返回任务名称
@@ -42,18 +138,25 @@ func (p *BlockProcessor) Name() string {
 
 // Execute 执行区块追踪和解析逻辑
 func (p *BlockProcessor) Execute(ctx context.Context) error {
+	if err := p.refreshCoinConfigs(ctx); err != nil {
+		return err
+	}
+	if err := p.refreshDepositAddresses(ctx); err != nil {
+		return err
+	}
+
 	latestHeight, err := p.fetchLatestHeight(ctx)
 	if err != nil {
 		return err
 	}
 
+	// 扫描到链上最新高度，未达到确认数的充值先记为待确认，确认数在每轮结束时刷新
 	p.mu.Lock()
 	fromHeight := p.currentHeight + 1
-	safeHeight := latestHeight - p.config.Chain.Confirmations
-	if safeHeight < fromHeight {
+	if latestHeight < fromHeight {
 		p.mu.Unlock()
-		logger.Info("暂无可处理区块，当前=%d, 链上=%d, 安全高度=%d", p.currentHeight, latestHeight, safeHeight)
-		return nil
+		logger.Info("暂无可处理区块，当前=%d, 链上=%d", p.currentHeight, latestHeight)
+		return p.updateConfirmations(ctx, latestHeight)
 	}
 
 	maxPerRound := p.config.Chain.MaxBlocksPerRound
@@ -61,31 +164,117 @@ func (p *BlockProcessor) Execute(ctx context.Context) error {
 		maxPerRound = 20
 	}
 
-	toHeight := safeHeight
+	toHeight := latestHeight
 	limitHeight := p.currentHeight + maxPerRound
 	if toHeight > limitHeight {
 		toHeight = limitHeight
 	}
 	p.mu.Unlock()
 
-	for h := fromHeight; h <= toHeight; h++ {
+	// 每批并发拉取并解析 BatchSize 个区块，再按高度顺序逐个提交，游标不会越过未提交的区块
+	batchSize := p.config.BlockProcessor.BatchSize
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+	for h := fromHeight; h <= toHeight; {
+		end := min(h+batchSize-1, toHeight)
+		results, fetchErr := p.fetchBlocks(ctx, h, end)
+
+		ancestor, reorged, err := p.commitInOrder(ctx, results)
+		if err != nil {
+			return err
+		}
+		if reorged {
+			// 从共同祖先的下一个区块开始重新处理主链，本批剩余结果作废
+			h = ancestor + 1
+			continue
+		}
+		if fetchErr != nil {
+			return fetchErr
+		}
+		h = end + 1
+	}
+
+	logger.Info("区块处理完成，已更新到高度 %d", toHeight)
+	return p.updateConfirmations(ctx, latestHeight)
+}
+
+// fetchBlocks 并发拉取并解析 [from, to] 区间的区块
+// 返回按高度排列的连续结果；某个区块失败时只返回它之前的结果以及该区块的错误
+func (p *BlockProcessor) fetchBlocks(ctx context.Context, from, to int64) ([]*blockResult, error) {
+	n := int(to - from + 1)
+	results := make([]*blockResult, n)
+	errs := make([]error, n)
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				results[idx], errs[idx] = p.fetchBlock(ctx, from+int64(idx))
+			}
+		}()
+	}
+
+feed:
+	for idx := range results {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
-		default:
+			break feed
+		case jobs <- idx:
 		}
+	}
+	close(jobs)
+	wg.Wait()
 
-		if err := p.parseBlock(ctx, h); err != nil {
-			return err
+	for idx := range results {
+		if errs[idx] != nil {
+			return results[:idx], errs[idx]
+		}
+		if results[idx] == nil {
+			return results[:idx], ctx.Err()
 		}
 	}
+	return results, nil
+}
 
-	p.mu.Lock()
-	p.currentHeight = toHeight
-	p.mu.Unlock()
+// fetchBlock 查询区块头并解析区块交易
+func (p *BlockProcessor) fetchBlock(ctx context.Context, height int64) (*blockResult, error) {
+	header, err := p.chain.HeaderByNumber(ctx, big.NewInt(height))
+	if err != nil {
+		return nil, fmt.Errorf("查询区块头 %d 失败: %w", height, err)
+	}
 
-	logger.Info("区块处理完成，已更新到高度 %d", toHeight)
-	return nil
+	result := &blockResult{
+		height:     height,
+		hash:       header.Hash().Hex(),
+		parentHash: header.ParentHash.Hex(),
+		header:     header,
+	}
+	if err := p.parseBlock(ctx, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// commitInOrder 按高度顺序检测重组并提交区块结果
+// 检测到重组时完成回滚并返回共同祖先，调用方需丢弃剩余结果从祖先的下一个区块重新处理
+func (p *BlockProcessor) commitInOrder(ctx context.Context, results []*blockResult) (int64, bool, error) {
+	for _, result := range results {
+		ancestor, reorged, err := p.detectReorg(ctx, result.header)
+		if err != nil {
+			return 0, false, err
+		}
+		if reorged {
+			return ancestor, true, nil
+		}
+		if err := p.commitBlock(ctx, result); err != nil {
+			return 0, false, err
+		}
+	}
+	return 0, false, nil
 }
 
 // fetchLatestHeight 获取链上最新区块高度
@@ -96,60 +285,109 @@ func (p *BlockProcessor) fetchLatestHeight(ctx context.Context) (int64, error) {
 	default:
 	}
 
-	if eth.IsInitialized() {
-		latest, err := eth.LatestBlockNumber(ctx)
-		if err != nil {
-			return 0, err
-		}
-		return int64(latest), nil
+	latest, err := p.chain.LatestBlockNumber(ctx)
+	if err != nil {
+		return 0, err
 	}
-
-	// 降级路径：未初始化 ETH 客户端时使用本地模拟高度，保障流程可运行。
-	p.mu.Lock()
-	defer p.mu.Unlock()
-
-	p.mockLatestHead += 3
-	return p.mockLatestHead, nil
+	return int64(latest), nil
 }
 
-// parseBlock 解析指定高度区块数据
-func (p *BlockProcessor) parseBlock(ctx context.Context, height int64) error {
+// parseBlock 解析指定高度区块数据，结果写入 result
+func (p *BlockProcessor) parseBlock(ctx context.Context, result *blockResult) error {
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
 	default:
 	}
 
+	height := result.height
 	logger.Info("开始解析区块 %d", height)
 
-	if p.config.BlockProcessor.ParseTx {
-		receiptParser := core.NewReceiptParser()
-		incomingTransfers, err := receiptParser.ParseAndFilterByBlock(
-			ctx,
-			height,
-			p.config.BlockProcessor.TargetAddresses,
-			p.config.BlockProcessor.TrackedAssets,
-			p.config.BlockProcessor.TokenContracts,
-			p.config.BlockProcessor.ParseWorkers,
-		)
-		if err != nil {
-			return err
-		}
-		logger.Info("区块 %d 命中流入交易 %d 条", height, len(incomingTransfers))
+	tokenContracts, trackedAssets := p.tokenRegistry()
+	parseTx := p.config.BlockProcessor.ParseTx
+	if parseTx && p.db != nil && len(trackedAssets) == 0 {
+		// coin_configs 为空时不回退到默认白名单，避免未配置的币种被入账
+		logger.Error("coin_configs 未配置任何币种，跳过区块 %d 交易解析", height)
+		parseTx = false
 	}
 
-	if p.config.BlockProcessor.ParseEvent {
-		logger.Info("解析区块 %d 事件日志", height)
+	parsed, err := p.parser.ParseBlock(ctx, height, core.BlockParseOptions{
+		Transfers:       parseTx,
+		Events:          p.config.BlockProcessor.ParseEvent,
+		TargetAddresses: p.targetAddresses(),
+		TrackedAssets:   trackedAssets,
+		TokenSymbols:    tokenContracts,
+		Workers:         p.config.BlockProcessor.ParseWorkers,
+	})
+	if err != nil {
+		return err
+	}
+	if parseTx {
+		logger.Info("区块 %d 命中流入交易 %d 条，未通过币种规则 %d 条", height, len(parsed.Transfers), len(parsed.Rejected))
+	}
+	if len(parsed.Events) > 0 {
+		logger.Info("区块 %d 解码合约事件 %d 条", height, len(parsed.Events))
 	}
+	result.transfers = parsed.Transfers
+	result.rejected = parsed.Rejected
+	result.events = parsed.Events
 
-	// TODO: 在这里落库并更新业务索引
-	time.Sleep(20 * time.Millisecond)
 	logger.Info("区块 %d 解析完成", height)
 	return nil
 }
 
+// commitBlock 提交区块解析结果并推进游标
+// 配置了数据库时，区块结果与游标在同一事务内写入，保证重启后不重不漏
+func (p *BlockProcessor) commitBlock(ctx context.Context, result *blockResult) error {
+	if p.db != nil {
+		cursor := store.BlockCursor{
+			ChainID:   p.config.Chain.ChainID,
+			Name:      cursorName,
+			Height:    result.height,
+			BlockHash: result.hash,
+		}
+		blockHash := store.BlockHash{
+			Height:     result.height,
+			Hash:       result.hash,
+			ParentHash: result.parentHash,
+		}
+		err := db.WithTx(ctx, p.db, func(tx *sql.Tx) error {
+			if err := p.saveDeposits(ctx, tx, result.transfers); err != nil {
+				return err
+			}
+			if err := p.saveRejections(ctx, tx, result.rejected); err != nil {
+				return err
+			}
+			if err := p.dispatchEvents(ctx, tx, result.events); err != nil {
+				return err
+			}
+			if result.hash != "" {
+				if err := store.SaveBlockHash(ctx, tx, cursor.ChainID, blockHash); err != nil {
+					return err
+				}
+				if err := store.PruneBlockHashes(ctx, tx, cursor.ChainID, result.height-p.window.size+1); err != nil {
+					return err
+				}
+			}
+			return store.SaveCursor(ctx, tx, cursor)
+		})
+		if err != nil {
+			return fmt.Errorf("提交区块 %d 失败: %w", result.height, err)
+		}
+	} else if err := p.dispatchEvents(ctx, nil, result.events); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.window.put(result.height, result.hash)
+	p.currentHeight = result.height
+	p.currentHash = result.hash
+	p.mu.Unlock()
+	return nil
+}
+
 func (p *BlockProcessor) String() string {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	return fmt.Sprintf("current_height=%d", p.currentHeight)
+	return fmt.Sprintf("current_height=%d current_hash=%s", p.currentHeight, p.currentHash)
 }
@@ -0,0 +1,176 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"go_bullayer_v1/base/pkg/eth"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestExecute_ScansUnconfirmedBlocksAndRefreshesConfirmations(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock failed: %v", err)
+	}
+	defer db.Close()
+
+	cfg := newTestConfig(16)
+	cfg.Chain.Confirmations = 12
+	cfg.Chain.StartHeight = 18
+	chain := newFakeChain(21)
+	p := newBlockProcessor(cfg, db, chain)
+
+	mock.ExpectQuery("SELECT coin, coin_address, min_deposit, status FROM coin_configs").
+		WillReturnRows(sqlmock.NewRows([]string{"coin", "coin_address", "min_deposit", "status"}))
+	mock.ExpectQuery("SELECT id, account_id, address, derivation_index FROM deposit_addresses").
+		WithArgs(int64(0)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "address", "derivation_index"}))
+	for h := int64(19); h <= 20; h++ {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO block_hashes").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("DELETE FROM block_hashes").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT INTO block_cursors").
+			WithArgs(cfg.Chain.ChainID, cursorName, h, chain.hash(h)).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+	}
+	mock.ExpectExec("UPDATE transactions SET confirmations").
+		WithArgs(int64(20), cfg.Chain.ChainID, 1, 0, int64(20)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT id, account_id, coin, amount FROM transactions").
+		WithArgs(cfg.Chain.ChainID, 1, 0, int64(12), creditBatchSize).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "coin", "amount"}))
+
+	if err := p.Execute(context.Background()); err != nil {
+		t.Fatalf("execute failed: %v", err)
+	}
+	if p.currentHeight != 20 {
+		t.Fatalf("expected to scan up to unconfirmed head 20, got %d", p.currentHeight)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExecute_DoesNotAdvancePastPartiallyParsedBlock(t *testing.T) {
+	cfg := newTestConfig(16)
+	cfg.BlockProcessor.ParseTx = true
+	cfg.BlockProcessor.TargetAddresses = []string{"0xabc0000000000000000000000000000000000001"}
+	cfg.BlockProcessor.ParseRetries = 2
+	cfg.BlockProcessor.ParseRetryDelay = 1
+
+	// 区块 1 包含一笔无法在本地恢复发送方的交易，且发送方查询始终失败
+	chain := newFakeChain(6)
+	to := common.HexToAddress("0xabc0000000000000000000000000000000000001")
+	tx := types.NewTx(&types.LegacyTx{To: &to, Value: big.NewInt(1), Gas: 21000})
+	chain.AddBlock(types.NewBlockWithHeader(chain.headers[1]).WithBody(types.Body{Transactions: types.Transactions{tx}}),
+		[]*types.Receipt{{Status: types.ReceiptStatusSuccessful, TxHash: tx.Hash()}})
+	chain.InjectError("TransactionSender", -1, errors.New("rpc timeout"))
+
+	p := newBlockProcessor(cfg, nil, chain)
+
+	err := p.Execute(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "rpc timeout") {
+		t.Fatalf("expected block parse error, got %v", err)
+	}
+	if p.currentHeight != 0 {
+		t.Fatalf("cursor must stay before the failed block, got %d", p.currentHeight)
+	}
+}
+
+func TestNewBlockProcessor_RequiresChainClient(t *testing.T) {
+	if _, err := NewBlockProcessor(context.Background(), newTestConfig(16), nil, nil); err == nil {
+		t.Fatal("expected error when no chain client is configured")
+	}
+}
+
+func TestExecute_SimulatedChain(t *testing.T) {
+	target := "0xabc0000000000000000000000000000000000001"
+	cfg := newTestConfig(16)
+	cfg.Chain.StartHeight = 100
+	cfg.BlockProcessor.ParseTx = true
+	cfg.BlockProcessor.TargetAddresses = []string{target}
+
+	chain, err := eth.NewSimulatedChain(eth.SimulationConfig{
+		ChainID:       cfg.Chain.ChainID,
+		Seed:          7,
+		Genesis:       100,
+		BlocksPerPoll: 5,
+		Recipients:    []common.Address{common.HexToAddress(target)},
+	})
+	if err != nil {
+		t.Fatalf("create simulated chain failed: %v", err)
+	}
+
+	p, err := NewBlockProcessor(context.Background(), cfg, nil, chain)
+	if err != nil {
+		t.Fatalf("create block processor failed: %v", err)
+	}
+	if err := p.Execute(context.Background()); err != nil {
+		t.Fatalf("execute failed: %v", err)
+	}
+	if p.currentHeight != 105 {
+		t.Fatalf("expected cursor at simulated head 105, got %s", p)
+	}
+
+	result := &blockResult{height: 101}
+	if err := p.parseBlock(context.Background(), result); err != nil {
+		t.Fatalf("parse block failed: %v", err)
+	}
+	if len(result.transfers) == 0 {
+		t.Fatal("expected simulated deposits to the target address")
+	}
+}
+
+func TestExecute_ParallelCatchUpCommitsInHeightOrder(t *testing.T) {
+	cfg := newTestConfig(64)
+	cfg.BlockProcessor.BatchSize = 8
+	chain := newFakeChain(51)
+	p := newBlockProcessor(cfg, nil, chain)
+
+	if err := p.Execute(context.Background()); err != nil {
+		t.Fatalf("execute failed: %v", err)
+	}
+	if p.currentHeight != 50 || p.currentHash != chain.hash(50) {
+		t.Fatalf("unexpected cursor after catch-up: %s", p)
+	}
+	for h := int64(1); h <= 50; h++ {
+		if got, ok := p.window.get(h); !ok || got != chain.hash(h) {
+			t.Fatalf("block %d missing from window after ordered commit", h)
+		}
+	}
+}
+
+func TestExecute_ParallelCatchUpStopsBeforeFailedBlock(t *testing.T) {
+	cfg := newTestConfig(64)
+	cfg.BlockProcessor.BatchSize = 8
+	cfg.BlockProcessor.ParseTx = true
+	cfg.BlockProcessor.TargetAddresses = []string{"0xabc0000000000000000000000000000000000001"}
+	cfg.BlockProcessor.ParseRetries = 0
+
+	// 区块 13 解析失败，同批次中更高的区块即使解析成功也不得提交
+	chain := newFakeChain(30)
+	to := common.HexToAddress("0xabc0000000000000000000000000000000000001")
+	tx := types.NewTx(&types.LegacyTx{To: &to, Value: big.NewInt(1), Gas: 21000})
+	chain.AddBlock(types.NewBlockWithHeader(chain.headers[13]).WithBody(types.Body{Transactions: types.Transactions{tx}}),
+		[]*types.Receipt{{Status: types.ReceiptStatusSuccessful, TxHash: tx.Hash()}})
+	chain.InjectError("TransactionSender", -1, errors.New("rpc timeout"))
+
+	p := newBlockProcessor(cfg, nil, chain)
+	if err := p.Execute(context.Background()); err == nil {
+		t.Fatal("expected block 13 parse error")
+	}
+	if p.currentHeight != 12 {
+		t.Fatalf("cursor must stop right before the failed block, got %s", p)
+	}
+	if _, ok := p.window.get(14); ok {
+		t.Fatal("blocks after the failed block must not be committed")
+	}
+}
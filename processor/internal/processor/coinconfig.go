@@ -0,0 +1,135 @@
+package processor
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go_bullayer_v1/base/pkg/common"
+	"go_bullayer_v1/base/pkg/logger"
+	"go_bullayer_v1/processor/internal/core"
+	"go_bullayer_v1/processor/internal/store"
+)
+
+// defaultCoinConfigRefreshInterval 未配置时 coin_configs 的重新加载间隔
+const defaultCoinConfigRefreshInterval = 60 * time.Second
+
+// coinConfigCache coin_configs 的内存快照，实现 core.CoinConfigSource
+// 快照整体替换，解析过程中读取到的始终是同一版本的配置
+type coinConfigCache struct {
+	mu            sync.RWMutex
+	configs       map[string]core.CoinConfig
+	tokenSymbols  map[string]string // ERC20 合约地址 -> 币种符号
+	trackedAssets []string
+	loadedAt      time.Time
+}
+
+func newCoinConfigCache() *coinConfigCache {
+	return &coinConfigCache{configs: make(map[string]core.CoinConfig)}
+}
+
+// CoinConfig 按币种符号查询配置
+func (c *coinConfigCache) CoinConfig(coin string) (core.CoinConfig, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	cfg, ok := c.configs[strings.ToUpper(coin)]
+	return cfg, ok
+}
+
+// tokens 返回代币合约映射和跟踪的币种列表，调用方不得修改返回值
+func (c *coinConfigCache) tokens() (map[string]string, []string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tokenSymbols, c.trackedAssets
+}
+
+// replace 整体替换快照，并据此重建代币合约映射和跟踪币种
+// 禁用的币种仍然跟踪，流入转账由过滤器拒绝并记录原因
+func (c *coinConfigCache) replace(configs map[string]core.CoinConfig, loadedAt time.Time) {
+	tokenSymbols := make(map[string]string)
+	trackedAssets := make([]string, 0, len(configs))
+	for coin, cfg := range configs {
+		trackedAssets = append(trackedAssets, coin)
+		if cfg.CoinAddress != "" {
+			tokenSymbols[strings.ToLower(cfg.CoinAddress)] = coin
+		}
+	}
+	sort.Strings(trackedAssets)
+
+	c.mu.Lock()
+	c.configs = configs
+	c.tokenSymbols = tokenSymbols
+	c.trackedAssets = trackedAssets
+	c.loadedAt = loadedAt
+	c.mu.Unlock()
+}
+
+func (c *coinConfigCache) lastLoaded() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.loadedAt
+}
+
+// tokenRegistry 返回本轮解析使用的代币合约映射和跟踪币种
+// 配置数据库时以 coin_configs 为准，新增 ERC20 只需插入一行配置；未配置数据库时使用 YAML 中的静态配置
+func (p *BlockProcessor) tokenRegistry() (map[string]string, []string) {
+	if p.db == nil {
+		return p.config.BlockProcessor.TokenContracts, p.config.BlockProcessor.TrackedAssets
+	}
+	return p.coinConfigs.tokens()
+}
+
+// refreshCoinConfigs 到达刷新间隔时重新加载 coin_configs
+// 首次加载失败返回错误，避免在没有币种规则的情况下记账；之后的失败沿用旧快照
+func (p *BlockProcessor) refreshCoinConfigs(ctx context.Context) error {
+	if p.db == nil {
+		return nil
+	}
+
+	interval := time.Duration(p.config.BlockProcessor.CoinConfigRefreshInterval) * time.Second
+	if interval <= 0 {
+		interval = defaultCoinConfigRefreshInterval
+	}
+	loadedAt := p.coinConfigs.lastLoaded()
+	now := time.Now()
+	if !loadedAt.IsZero() && now.Sub(loadedAt) < interval {
+		return nil
+	}
+
+	rows, err := store.LoadCoinConfigs(ctx, p.db)
+	if err != nil {
+		if loadedAt.IsZero() {
+			return err
+		}
+		logger.Error("重新加载币种配置失败，继续使用旧配置: %v", err)
+		return nil
+	}
+
+	configs := make(map[string]core.CoinConfig, len(rows))
+	for _, row := range rows {
+		coin := strings.ToUpper(strings.TrimSpace(row.Coin))
+		decimals, err := p.coinDecimals(ctx, coin, row.CoinAddress)
+		if err != nil {
+			// 精度未知时不能换算金额，沿用旧快照中同一合约的配置，没有则跳过该币种
+			if old, ok := p.coinConfigs.CoinConfig(coin); ok && strings.EqualFold(old.CoinAddress, row.CoinAddress) {
+				logger.Error("币种 %s 精度解析失败，沿用旧配置: %v", coin, err)
+				decimals = old.Decimals
+			} else {
+				logger.Error("币种 %s 精度解析失败，跳过该币种: %v", coin, err)
+				continue
+			}
+		}
+		configs[coin] = core.CoinConfig{
+			Coin:        coin,
+			CoinAddress: row.CoinAddress,
+			MinDeposit:  row.MinDeposit,
+			Decimals:    decimals,
+			Enabled:     row.Status != common.CoinStatusDisabled,
+		}
+	}
+	p.coinConfigs.replace(configs, now)
+	logger.Info("已加载币种配置 %d 条", len(configs))
+	return nil
+}
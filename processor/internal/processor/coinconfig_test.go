@@ -0,0 +1,111 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go_bullayer_v1/base/pkg/eth"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestRefreshCoinConfigs_HotReload(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock failed: %v", err)
+	}
+	defer db.Close()
+
+	cfg := newTestConfig(16)
+	cfg.BlockProcessor.CoinConfigRefreshInterval = 60
+	cfg.BlockProcessor.TokenDecimals = map[string]int{"usdt": 6}
+	p := newBlockProcessor(cfg, db, nil)
+
+	columns := []string{"coin", "coin_address", "min_deposit", "status"}
+	mock.ExpectQuery("SELECT coin, coin_address, min_deposit, status FROM coin_configs").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("ETH", nil, "0.010000000000000000", 1).
+			AddRow("USDT", "0xdac17f958d2ee523a2206206994597c13d831ec7", "10.000000000000000000", 1))
+	if err := p.refreshCoinConfigs(context.Background()); err != nil {
+		t.Fatalf("initial load failed: %v", err)
+	}
+	usdt, ok := p.coinConfigs.CoinConfig("usdt")
+	if !ok || !usdt.Enabled || usdt.Decimals != 6 {
+		t.Fatalf("unexpected usdt config: %+v", usdt)
+	}
+	tokens, assets := p.tokenRegistry()
+	if tokens["0xdac17f958d2ee523a2206206994597c13d831ec7"] != "USDT" || len(tokens) != 1 {
+		t.Fatalf("unexpected token contracts: %v", tokens)
+	}
+	if len(assets) != 2 || assets[0] != "ETH" || assets[1] != "USDT" {
+		t.Fatalf("unexpected tracked assets: %v", assets)
+	}
+
+	// 刷新间隔内不重新查询
+	if err := p.refreshCoinConfigs(context.Background()); err != nil {
+		t.Fatalf("refresh within interval failed: %v", err)
+	}
+
+	// 间隔到达后加载新配置，USDT 被禁用
+	p.coinConfigs.replace(p.coinConfigs.configs, time.Now().Add(-time.Minute))
+	mock.ExpectQuery("SELECT coin, coin_address, min_deposit, status FROM coin_configs").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("USDT", "0xdac17f958d2ee523a2206206994597c13d831ec7", "10.000000000000000000", 2))
+	if err := p.refreshCoinConfigs(context.Background()); err != nil {
+		t.Fatalf("reload failed: %v", err)
+	}
+	if usdt, _ := p.coinConfigs.CoinConfig("USDT"); usdt.Enabled {
+		t.Fatal("expected usdt to be disabled after reload")
+	}
+	if _, ok := p.coinConfigs.CoinConfig("ETH"); ok {
+		t.Fatal("removed coin must not survive reload")
+	}
+	// 禁用的币种仍然跟踪，由过滤器拒绝并记录原因
+	if _, assets := p.tokenRegistry(); len(assets) != 1 || assets[0] != "USDT" {
+		t.Fatalf("unexpected tracked assets after reload: %v", assets)
+	}
+
+	// 重新加载失败时沿用旧快照
+	p.coinConfigs.replace(p.coinConfigs.configs, time.Now().Add(-time.Minute))
+	mock.ExpectQuery("SELECT coin, coin_address, min_deposit, status FROM coin_configs").
+		WillReturnError(errors.New("connection refused"))
+	if err := p.refreshCoinConfigs(context.Background()); err != nil {
+		t.Fatalf("reload failure must keep old snapshot, got %v", err)
+	}
+	if _, ok := p.coinConfigs.CoinConfig("USDT"); !ok {
+		t.Fatal("old snapshot lost after failed reload")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRefreshCoinConfigs_SkipsCoinWithUnknownDecimals(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock failed: %v", err)
+	}
+	defer db.Close()
+
+	// 模拟节点上没有部署 DAI 合约，decimals() 查询失败
+	p := newBlockProcessor(newTestConfig(16), db, eth.NewFakeClient(11155111))
+	mock.ExpectQuery("SELECT coin, coin_address, min_deposit, status FROM coin_configs").
+		WillReturnRows(sqlmock.NewRows([]string{"coin", "coin_address", "min_deposit", "status"}).
+			AddRow("ETH", nil, "0.010000000000000000", 1).
+			AddRow("DAI", "0x6b175474e89094c44da98b954eedeac495271d0f", "1.000000000000000000", 1))
+	if err := p.refreshCoinConfigs(context.Background()); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if _, ok := p.coinConfigs.CoinConfig("DAI"); ok {
+		t.Fatal("coin with unknown decimals must be skipped")
+	}
+	tokens, assets := p.tokenRegistry()
+	if len(tokens) != 0 || len(assets) != 1 || assets[0] != "ETH" {
+		t.Fatalf("unexpected registry: %v %v", tokens, assets)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,58 @@
+package processor
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"go_bullayer_v1/base/pkg/db"
+	"go_bullayer_v1/base/pkg/logger"
+	"go_bullayer_v1/processor/internal/store"
+)
+
+// creditBatchSize 每轮最多入账的充值笔数
+const creditBatchSize = 500
+
+// updateConfirmations 按链上最新高度刷新待确认充值的确认数，
+// 并为确认数达到 Chain.Confirmations 的充值入账
+func (p *BlockProcessor) updateConfirmations(ctx context.Context, latestHeight int64) error {
+	if p.db == nil {
+		return nil
+	}
+
+	if err := store.UpdateDepositConfirmations(ctx, p.db, p.config.Chain.ChainID, latestHeight); err != nil {
+		return err
+	}
+	return p.creditConfirmedDeposits(ctx)
+}
+
+// creditConfirmedDeposits 逐笔入账已确认的充值
+// 每笔充值的状态变更与用户资产增加在同一事务内完成，进程在任意步骤崩溃都不会重复或遗漏入账
+func (p *BlockProcessor) creditConfirmedDeposits(ctx context.Context) error {
+	deposits, err := store.ListCreditableDeposits(ctx, p.db, p.config.Chain.ChainID, p.config.Chain.Confirmations, creditBatchSize)
+	if err != nil {
+		return err
+	}
+
+	credited := 0
+	for _, d := range deposits {
+		var ok bool
+		err := db.WithTx(ctx, p.db, func(tx *sql.Tx) error {
+			var err error
+			ok, err = store.CreditDeposit(ctx, tx, d)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("充值 %d 入账失败: %w", d.ID, err)
+		}
+		if ok {
+			credited++
+			logger.Info("充值 %d 已入账：账户=%d, 币种=%s, 金额=%s", d.ID, d.AccountID, d.Coin, d.Amount)
+		}
+	}
+
+	if credited > 0 {
+		logger.Info("%d 笔充值达到 %d 个确认并完成入账", credited, p.config.Chain.Confirmations)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go_bullayer_v1/base/pkg/db"
+	"go_bullayer_v1/base/pkg/logger"
+	"go_bullayer_v1/base/pkg/utils"
+	"go_bullayer_v1/processor/internal/core"
+	"go_bullayer_v1/processor/internal/store"
+)
+
+// defaultTokenDecimals 未配置精度时使用的默认精度（与 ETH 一致）
+const defaultTokenDecimals = 18
+
+// saveDeposits 将区块内命中的流入转账写入 transactions 表
+// 转入账户专属充值地址的转账按接收方归属；转入配置中共用地址的转账沿用发送方匹配 accounts.address，
+// 无法归属的转账会被跳过
+func (p *BlockProcessor) saveDeposits(ctx context.Context, q db.Querier, transfers []core.TransferRecord) error {
+	for _, t := range transfers {
+		accountID, found := p.depositAddresses.accountID(t.To)
+		if !found {
+			var err error
+			accountID, found, err = store.FindAccountIDByAddress(ctx, q, t.From)
+			if err != nil {
+				return err
+			}
+		}
+		if !found {
+			logger.Info("充值发送方 %s 未注册账户，跳过交易 %s", t.From, t.TxHash)
+			continue
+		}
+
+		// transactions.amount 为 DECIMAL(36,18)，换算全程使用整数运算
+		amount, err := utils.ScaleToDecimal(t.Amount, p.tokenDecimals(t), 36, 18)
+		if err != nil {
+			return fmt.Errorf("交易 %s 金额换算失败: %w", t.TxHash, err)
+		}
+
+		deposit := store.Deposit{
+			ChainID:     p.config.Chain.ChainID,
+			TxHash:      t.TxHash,
+			EventIndex:  t.EventIndex,
+			BlockNumber: t.BlockNumber,
+			AccountID:   accountID,
+			Coin:        strings.ToUpper(t.TokenSymbol),
+			Amount:      amount,
+			From:        t.From,
+			To:          t.To,
+		}
+		if t.AssetType == core.AssetTypeERC20 {
+			deposit.CoinAddress = t.TokenAddress
+		}
+		if err := store.UpsertDeposit(ctx, q, deposit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveRejections 记录流入充值地址但未通过币种规则校验的转账，便于客服排查
+func (p *BlockProcessor) saveRejections(ctx context.Context, q db.Querier, rejected []core.RejectedTransfer) error {
+	for _, r := range rejected {
+		amount, err := utils.ScaleToDecimal(r.Amount, p.tokenDecimals(r.TransferRecord), 36, 18)
+		if err != nil {
+			return fmt.Errorf("交易 %s 金额换算失败: %w", r.TxHash, err)
+		}
+
+		rejection := store.DepositRejection{
+			TxHash:      r.TxHash,
+			EventIndex:  r.EventIndex,
+			BlockNumber: r.BlockNumber,
+			Coin:        strings.ToUpper(r.TokenSymbol),
+			Amount:      amount,
+			From:        r.From,
+			To:          r.To,
+			Reason:      r.Reason,
+		}
+		if r.AssetType == core.AssetTypeERC20 {
+			rejection.CoinAddress = r.TokenAddress
+		}
+		if err := store.SaveDepositRejection(ctx, q, rejection); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tokenDecimals 返回转账资产的精度。
+// 解析时已按合约 decimals() 补充精度的记录直接使用，否则取自 BlockProcessor.TokenDecimals 配置
+func (p *BlockProcessor) tokenDecimals(t core.TransferRecord) int {
+	if t.AssetType == core.AssetTypeETH || t.AssetType == core.AssetTypeInternalETH {
+		return defaultTokenDecimals
+	}
+	if t.DecimalAmount != "" {
+		return t.Decimals
+	}
+	return p.symbolDecimals(t.TokenSymbol)
+}
+
+// symbolDecimals 按币种符号返回精度，未配置时默认 18
+func (p *BlockProcessor) symbolDecimals(symbol string) int {
+	if decimals, ok := p.configuredDecimals(symbol); ok {
+		return decimals
+	}
+	return defaultTokenDecimals
+}
+
+// configuredDecimals 按币种符号查询 BlockProcessor.TokenDecimals 配置
+func (p *BlockProcessor) configuredDecimals(symbol string) (int, bool) {
+	for s, decimals := range p.config.BlockProcessor.TokenDecimals {
+		if strings.EqualFold(s, symbol) {
+			return decimals, true
+		}
+	}
+	return 0, false
+}
+
+// overrideTokenMetadata 以配置的币种符号和精度覆盖链上元数据，未配置精度时仍通过 decimals() 查询
+func (p *BlockProcessor) overrideTokenMetadata(address, symbol string) {
+	decimals, ok := p.configuredDecimals(symbol)
+	if !ok {
+		decimals = -1
+	}
+	p.tokens.SetOverride(address, symbol, decimals)
+}
+
+// coinDecimals 返回币种的链上精度：原生币按配置或默认精度，ERC20 按合约 decimals() 解析，配置了 TokenDecimals 时以配置为准
+func (p *BlockProcessor) coinDecimals(ctx context.Context, coin, address string) (int, error) {
+	if address == "" {
+		return p.symbolDecimals(coin), nil
+	}
+	if p.tokens == nil {
+		if decimals, ok := p.configuredDecimals(coin); ok {
+			return decimals, nil
+		}
+		return 0, fmt.Errorf("未配置币种 %s 的精度且无法查询合约", coin)
+	}
+	p.overrideTokenMetadata(address, coin)
+	meta, err := p.tokens.Resolve(ctx, address)
+	if err != nil {
+		return 0, fmt.Errorf("查询代币 %s(%s) 精度失败: %w", coin, address, err)
+	}
+	return meta.Decimals, nil
+}
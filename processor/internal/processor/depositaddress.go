@@ -0,0 +1,168 @@
+package processor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"go_bullayer_v1/base/pkg/db"
+	"go_bullayer_v1/base/pkg/hdwallet"
+	"go_bullayer_v1/base/pkg/logger"
+	"go_bullayer_v1/processor/internal/config"
+	"go_bullayer_v1/processor/internal/store"
+)
+
+// defaultAddressBatchSize 每轮最多分配的充值地址数量
+const defaultAddressBatchSize = 100
+
+// DepositAddressProcessor 充值地址分配任务
+// 为尚未分配地址的账户按 xpub 派生专属充值地址，服务端不持有私钥
+type DepositAddressProcessor struct {
+	config config.Config
+	db     *sql.DB
+	xpub   *hdwallet.XPub
+}
+
+// NewDepositAddressProcessor 创建充值地址分配任务
+func NewDepositAddressProcessor(cfg config.Config, db *sql.DB) (*DepositAddressProcessor, error) {
+	if db == nil {
+		return nil, errors.New("充值地址分配任务需要配置数据库")
+	}
+	xpub, err := hdwallet.ParseXPub(cfg.DepositAddress.XPub)
+	if err != nil {
+		return nil, fmt.Errorf("解析 DepositAddress.XPub 失败: %w", err)
+	}
+	return &DepositAddressProcessor{config: cfg, db: db, xpub: xpub}, nil
+}
+
+// Name 返回任务名称
+func (p *DepositAddressProcessor) Name() string {
+	return "充值地址分配任务"
+}
+
+// Execute 为新账户分配充值地址
+// 同一批次在一个事务内分配，派生序号连续递增，地址与序号一一对应
+func (p *DepositAddressProcessor) Execute(ctx context.Context) error {
+	batchSize := p.config.DepositAddress.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultAddressBatchSize
+	}
+
+	accountIDs, err := store.ListAccountsWithoutDepositAddress(ctx, p.db, batchSize)
+	if err != nil {
+		return err
+	}
+	if len(accountIDs) == 0 {
+		return nil
+	}
+
+	err = db.WithTx(ctx, p.db, func(tx *sql.Tx) error {
+		index, err := store.NextDerivationIndex(ctx, tx)
+		if err != nil {
+			return err
+		}
+		for _, accountID := range accountIDs {
+			address, err := p.xpub.DeriveAddress(index)
+			if err != nil {
+				return err
+			}
+			err = store.InsertDepositAddress(ctx, tx, store.DepositAddress{
+				AccountID:       accountID,
+				Address:         address,
+				DerivationIndex: index,
+			})
+			if err != nil {
+				return err
+			}
+			index++
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("分配充值地址失败: %w", err)
+	}
+
+	logger.Info("已为 %d 个账户分配充值地址", len(accountIDs))
+	return nil
+}
+
+// depositAddressSet 已分配充值地址的内存索引，按 id 增量刷新
+type depositAddressSet struct {
+	mu        sync.RWMutex
+	lastID    int64
+	accounts  map[string]int64 // 小写地址 -> 账户ID
+	addresses []string
+}
+
+func newDepositAddressSet() *depositAddressSet {
+	return &depositAddressSet{accounts: make(map[string]int64)}
+}
+
+// accountID 按充值地址查询所属账户
+func (s *depositAddressSet) accountID(address string) (int64, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	id, ok := s.accounts[strings.ToLower(strings.TrimSpace(address))]
+	return id, ok
+}
+
+// list 返回全部充值地址，调用方不得修改返回值
+func (s *depositAddressSet) list() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.addresses
+}
+
+func (s *depositAddressSet) add(addresses []store.DepositAddress) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 追加时复制切片，已交给解析流程的旧切片不受影响
+	list := make([]string, len(s.addresses), len(s.addresses)+len(addresses))
+	copy(list, s.addresses)
+	for _, a := range addresses {
+		s.accounts[strings.ToLower(a.Address)] = a.AccountID
+		list = append(list, a.Address)
+		if a.ID > s.lastID {
+			s.lastID = a.ID
+		}
+	}
+	s.addresses = list
+}
+
+// refreshDepositAddresses 增量加载新分配的充值地址
+func (p *BlockProcessor) refreshDepositAddresses(ctx context.Context) error {
+	if p.db == nil {
+		return nil
+	}
+
+	p.depositAddresses.mu.RLock()
+	lastID := p.depositAddresses.lastID
+	p.depositAddresses.mu.RUnlock()
+
+	addresses, err := store.LoadDepositAddresses(ctx, p.db, lastID)
+	if err != nil {
+		return err
+	}
+	if len(addresses) > 0 {
+		p.depositAddresses.add(addresses)
+		logger.Info("已加载新分配的充值地址 %d 个", len(addresses))
+	}
+	return nil
+}
+
+// targetAddresses 返回本轮解析监听的地址：配置中的静态地址与账户充值地址
+func (p *BlockProcessor) targetAddresses() []string {
+	static := p.config.BlockProcessor.TargetAddresses
+	deposit := p.depositAddresses.list()
+	if len(deposit) == 0 {
+		return static
+	}
+
+	targets := make([]string, 0, len(static)+len(deposit))
+	targets = append(targets, static...)
+	return append(targets, deposit...)
+}
@@ -0,0 +1,93 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"go_bullayer_v1/base/pkg/hdwallet"
+	"go_bullayer_v1/processor/internal/core"
+	"go_bullayer_v1/processor/internal/store"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestDepositAddressProcessor_AllocatesSequentialAddresses(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock failed: %v", err)
+	}
+	defer db.Close()
+
+	xpub, err := hdwallet.XPubFromMnemonic(testMnemonic, "")
+	if err != nil {
+		t.Fatalf("export xpub failed: %v", err)
+	}
+	cfg := newTestConfig(16)
+	cfg.DepositAddress.XPub = xpub
+	p, err := NewDepositAddressProcessor(cfg, db)
+	if err != nil {
+		t.Fatalf("create processor failed: %v", err)
+	}
+
+	mock.ExpectQuery("SELECT a.account_id FROM accounts a").
+		WithArgs(defaultAddressBatchSize).
+		WillReturnRows(sqlmock.NewRows([]string{"account_id"}).AddRow(int64(7)).AddRow(int64(9)))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT COALESCE\\(MAX\\(derivation_index\\) \\+ 1, 0\\) FROM deposit_addresses").
+		WillReturnRows(sqlmock.NewRows([]string{"next"}).AddRow(0))
+	mock.ExpectExec("INSERT INTO deposit_addresses").
+		WithArgs(int64(7), "0x9858EfFD232B4033E47d90003D41EC34EcaEda94", uint32(0)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO deposit_addresses").
+		WithArgs(int64(9), "0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0", uint32(1)).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+
+	if err := p.Execute(context.Background()); err != nil {
+		t.Fatalf("execute failed: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSaveDeposits_AttributesByDepositAddress(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock failed: %v", err)
+	}
+	defer db.Close()
+
+	p := newBlockProcessor(newTestConfig(16), db, nil)
+	p.depositAddresses.add([]store.DepositAddress{
+		{ID: 1, AccountID: 7, Address: "0x9858EfFD232B4033E47d90003D41EC34EcaEda94"},
+	})
+
+	// 从交易所热钱包转入专属地址：按接收方归属，不查询发送方
+	mock.ExpectExec("INSERT INTO transactions").
+		WithArgs(int64(11155111), int64(100), "0xtx", -1, 1, int64(7), "ETH", nil, "1", "0xexchange",
+			"0x9858effd232b4033e47d90003d41ec34ecaeda94", 0, 3, 3, 3).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = p.saveDeposits(context.Background(), db, []core.TransferRecord{{
+		TxHash:      "0xtx",
+		EventIndex:  core.NativeEventIndex,
+		BlockNumber: 100,
+		From:        "0xexchange",
+		To:          "0x9858effd232b4033e47d90003d41ec34ecaeda94",
+		Amount:      "1000000000000000000",
+		AssetType:   core.AssetTypeETH,
+		TokenSymbol: "ETH",
+	}})
+	if err != nil {
+		t.Fatalf("save deposits failed: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+	if targets := p.targetAddresses(); len(targets) != 1 {
+		t.Fatalf("deposit address must be watched, got %v", targets)
+	}
+}
@@ -0,0 +1,158 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"go_bullayer_v1/base/pkg/db"
+	"go_bullayer_v1/base/pkg/logger"
+	"go_bullayer_v1/processor/internal/config"
+	"go_bullayer_v1/processor/internal/core"
+	"go_bullayer_v1/processor/internal/store"
+)
+
+// EventHandler 合约事件处理器。
+// 在区块结果所在的事务内按日志顺序调用，返回错误时整个区块回滚并在下一轮重试，实现需保证幂等；
+// 未配置数据库时 q 为 nil。
+type EventHandler interface {
+	HandleEvent(ctx context.Context, q db.Querier, event core.EventRecord) error
+}
+
+// EventHandlerFunc 函数形式的事件处理器
+type EventHandlerFunc func(ctx context.Context, q db.Querier, event core.EventRecord) error
+
+// HandleEvent 调用 f
+func (f EventHandlerFunc) HandleEvent(ctx context.Context, q db.Querier, event core.EventRecord) error {
+	return f(ctx, q, event)
+}
+
+// EventRollbacker 可选接口，处理器有额外的持久化状态时实现，链重组回滚时在同一事务内调用
+type EventRollbacker interface {
+	RollbackAbove(ctx context.Context, q db.Querier, height int64) error
+}
+
+// eventHandlerEntry 已注册的处理器，eventName 为空时处理全部事件
+type eventHandlerEntry struct {
+	eventName string
+	handler   EventHandler
+}
+
+// RegisterEventHandler 为指定事件名注册处理器，eventName 为空时处理全部已注册合约的事件。
+// 需要在任务开始执行前调用。
+func (p *BlockProcessor) RegisterEventHandler(eventName string, h EventHandler) {
+	p.eventHandlers = append(p.eventHandlers, eventHandlerEntry{eventName: eventName, handler: h})
+}
+
+// dispatchEvents 将区块内的事件分发给匹配的处理器
+func (p *BlockProcessor) dispatchEvents(ctx context.Context, q db.Querier, events []core.EventRecord) error {
+	for _, event := range events {
+		for _, entry := range p.eventHandlers {
+			if entry.eventName != "" && entry.eventName != event.Name {
+				continue
+			}
+			if err := entry.handler.HandleEvent(ctx, q, event); err != nil {
+				return fmt.Errorf("处理合约事件 %s(%s#%d) 失败: %w", event.Name, event.TxHash, event.LogIndex, err)
+			}
+		}
+	}
+	return nil
+}
+
+// rollbackEvents 回滚共同祖先以上区块的事件数据
+func (p *BlockProcessor) rollbackEvents(ctx context.Context, q db.Querier, height int64) error {
+	if p.events.Empty() {
+		return nil
+	}
+	deleted, err := store.DeleteContractEventsAbove(ctx, q, p.config.Chain.ChainID, height)
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		logger.Error("链重组删除孤块合约事件 %d 条", deleted)
+	}
+	for _, entry := range p.eventHandlers {
+		if r, ok := entry.handler.(EventRollbacker); ok {
+			if err := r.RollbackAbove(ctx, q, height); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// saveContractEvent 默认处理器：配置数据库时将事件写入 contract_events 表
+func (p *BlockProcessor) saveContractEvent(ctx context.Context, q db.Querier, event core.EventRecord) error {
+	args, err := event.ArgsJSON()
+	if err != nil {
+		return fmt.Errorf("编码事件参数失败: %w", err)
+	}
+	return store.SaveContractEvent(ctx, q, store.ContractEvent{
+		ChainID:         p.config.Chain.ChainID,
+		TxHash:          event.TxHash,
+		LogIndex:        event.LogIndex,
+		BlockNumber:     event.BlockNumber,
+		ContractAddress: event.Contract,
+		EventName:       event.Name,
+		Signature:       event.Signature,
+		Args:            args,
+	})
+}
+
+// initEvents 加载事件注册表；配置数据库时默认将事件写入 contract_events
+func (p *BlockProcessor) initEvents() error {
+	if !p.config.BlockProcessor.ParseEvent || len(p.config.BlockProcessor.Events) == 0 {
+		return nil
+	}
+	registry, err := loadEventRegistry(p.config.BlockProcessor.Events)
+	if err != nil {
+		return err
+	}
+	p.events = registry
+	p.parser.SetEventRegistry(registry)
+	if p.db != nil {
+		p.RegisterEventHandler("", EventHandlerFunc(p.saveContractEvent))
+	}
+	logger.Info("已加载 %d 个合约的事件 ABI", len(p.config.BlockProcessor.Events))
+	return nil
+}
+
+// loadEventRegistry 按 BlockProcessor.Events 读取 ABI 文件并构建事件注册表
+func loadEventRegistry(contracts []config.EventContract) (*core.EventRegistry, error) {
+	registry := core.NewEventRegistry()
+	for _, c := range contracts {
+		raw, err := os.ReadFile(c.ABIFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取合约 %s 的 ABI 文件失败: %w", c.Contract, err)
+		}
+		abiJSON, err := extractABI(raw)
+		if err != nil {
+			return nil, fmt.Errorf("解析 ABI 文件 %s 失败: %w", c.ABIFile, err)
+		}
+		if err := registry.Register(c.Contract, abiJSON, c.Events...); err != nil {
+			return nil, err
+		}
+	}
+	return registry, nil
+}
+
+// extractABI 兼容纯 ABI 数组和 Hardhat/Foundry 编译产物（{"abi": [...]}）
+func extractABI(raw []byte) (string, error) {
+	trimmed := strings.TrimSpace(string(raw))
+	if strings.HasPrefix(trimmed, "[") {
+		return trimmed, nil
+	}
+
+	var artifact struct {
+		ABI json.RawMessage `json:"abi"`
+	}
+	if err := json.Unmarshal(raw, &artifact); err != nil {
+		return "", err
+	}
+	if len(artifact.ABI) == 0 {
+		return "", fmt.Errorf("缺少 abi 字段")
+	}
+	return string(artifact.ABI), nil
+}
@@ -0,0 +1,83 @@
+package processor
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"go_bullayer_v1/base/pkg/db"
+	"go_bullayer_v1/processor/internal/config"
+	"go_bullayer_v1/processor/internal/core"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const testBridge = "0xB41D6e0000000000000000000000000000000b41"
+
+func newEventTestConfig() config.Config {
+	cfg := newTestConfig(16)
+	cfg.BlockProcessor.ParseEvent = true
+	cfg.BlockProcessor.Events = []config.EventContract{{
+		Contract: testBridge,
+		ABIFile:  "../core/testdata/bridge_abi.json",
+	}}
+	return cfg
+}
+
+func TestCommitBlock_DispatchesEventsInBlockTransaction(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock failed: %v", err)
+	}
+	defer conn.Close()
+
+	p := newBlockProcessor(newEventTestConfig(), conn, newFakeChain(1))
+	if err := p.initEvents(); err != nil {
+		t.Fatalf("init events failed: %v", err)
+	}
+	var withdrawals []core.EventRecord
+	p.RegisterEventHandler("Withdraw", EventHandlerFunc(func(ctx context.Context, q db.Querier, e core.EventRecord) error {
+		withdrawals = append(withdrawals, e)
+		return nil
+	}))
+
+	events := []core.EventRecord{
+		{TxHash: "0x01", LogIndex: 0, BlockNumber: 5, Contract: testBridge, Name: "Deposit",
+			Signature: "Deposit(address,address,uint256,bytes32)", Args: map[string]interface{}{"amount": big.NewInt(7)}},
+		{TxHash: "0x01", LogIndex: 1, BlockNumber: 5, Contract: testBridge, Name: "Withdraw",
+			Signature: "Withdraw(address,address,uint256,uint64)", Args: map[string]interface{}{"user": common.HexToAddress("0x01")}},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO contract_events").
+		WithArgs(int64(11155111), "0x01", 0, int64(5), testBridge, "Deposit", "Deposit(address,address,uint256,bytes32)", `{"amount":"7"}`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO contract_events").
+		WithArgs(int64(11155111), "0x01", 1, int64(5), testBridge, "Withdraw", "Withdraw(address,address,uint256,uint64)",
+			`{"user":"0x0000000000000000000000000000000000000001"}`).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectExec("INSERT INTO block_hashes").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("DELETE FROM block_hashes").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO block_cursors").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	if err := p.commitBlock(context.Background(), &blockResult{height: 5, hash: "0x05", events: events}); err != nil {
+		t.Fatalf("commit block failed: %v", err)
+	}
+	if len(withdrawals) != 1 || withdrawals[0].LogIndex != 1 {
+		t.Fatalf("withdraw handler must receive only Withdraw events, got %+v", withdrawals)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInitEvents_RejectsMissingABIFile(t *testing.T) {
+	cfg := newEventTestConfig()
+	cfg.BlockProcessor.Events[0].ABIFile = "testdata/missing.json"
+
+	if err := newBlockProcessor(cfg, nil, newFakeChain(1)).initEvents(); err == nil {
+		t.Fatal("expected error for a missing abi file")
+	}
+}
@@ -0,0 +1,46 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+
+	"go_bullayer_v1/base/pkg/db"
+	"go_bullayer_v1/base/pkg/eth"
+	"go_bullayer_v1/processor/internal/store"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// nonceManager 热钱包 nonce 管理。
+// 已分配的 nonce 持久化在 wallet_nonces 表中，与领取提现、记录广播在同一事务内提交，
+// 多实例或重启后不会重复分配；节点 pending nonce 更大时（系统外发送过交易）以节点为准
+type nonceManager struct {
+	sender     eth.TxSender
+	chainID    int64
+	address    common.Address
+	chainNonce uint64 // 本轮开始时节点返回的 pending nonce
+}
+
+func newNonceManager(sender eth.TxSender, chainID int64, address common.Address) *nonceManager {
+	return &nonceManager{sender: sender, chainID: chainID, address: address}
+}
+
+// sync 每轮分配前刷新节点的 pending nonce
+func (m *nonceManager) sync(ctx context.Context) error {
+	nonce, err := m.sender.PendingNonceAt(ctx, m.address)
+	if err != nil {
+		return fmt.Errorf("查询热钱包 nonce 失败: %w", err)
+	}
+	m.chainNonce = nonce
+	return nil
+}
+
+// reserve 在事务 q 内分配下一个 nonce
+func (m *nonceManager) reserve(ctx context.Context, q db.Querier) (uint64, error) {
+	return store.ReserveNonce(ctx, q, m.chainID, m.address.Hex(), m.chainNonce)
+}
+
+// release 交易未能广播时归还 nonce
+func (m *nonceManager) release(ctx context.Context, q db.Querier, nonce uint64) error {
+	return store.ReleaseNonce(ctx, q, m.chainID, m.address.Hex(), nonce)
+}
@@ -0,0 +1,182 @@
+package processor
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/big"
+
+	"go_bullayer_v1/base/pkg/db"
+	"go_bullayer_v1/base/pkg/logger"
+	"go_bullayer_v1/processor/internal/store"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// defaultReorgWindow 默认保留的最近区块哈希数量
+const defaultReorgWindow = 64
+
+// blockWindow 最近已处理区块的哈希窗口
+type blockWindow struct {
+	size   int64
+	hashes map[int64]string
+}
+
+func newBlockWindow(size int64) *blockWindow {
+	if size <= 0 {
+		size = defaultReorgWindow
+	}
+	return &blockWindow{size: size, hashes: make(map[int64]string, size)}
+}
+
+// put 记录区块哈希，并淘汰窗口以外的旧高度
+func (w *blockWindow) put(height int64, hash string) {
+	if hash == "" {
+		return
+	}
+	w.hashes[height] = hash
+	for h := range w.hashes {
+		if h <= height-w.size {
+			delete(w.hashes, h)
+		}
+	}
+}
+
+func (w *blockWindow) get(height int64) (string, bool) {
+	hash, ok := w.hashes[height]
+	return hash, ok
+}
+
+// truncateAbove 删除 height 以上的哈希
+func (w *blockWindow) truncateAbove(height int64) {
+	for h := range w.hashes {
+		if h > height {
+			delete(w.hashes, h)
+		}
+	}
+}
+
+// loadBlockWindow 从数据库加载最近的区块哈希窗口
+func (p *BlockProcessor) loadBlockWindow(ctx context.Context) error {
+	fromHeight := p.currentHeight - p.window.size + 1
+	hashes, err := store.LoadBlockHashes(ctx, p.db, p.config.Chain.ChainID, fromHeight)
+	if err != nil {
+		return err
+	}
+	for _, h := range hashes {
+		if h.Height <= p.currentHeight {
+			p.window.put(h.Height, h.Hash)
+		}
+	}
+	// 兼容没有哈希窗口的旧游标
+	p.window.put(p.currentHeight, p.currentHash)
+	return nil
+}
+
+// detectReorg 检查新区块的父哈希是否与本地记录一致
+// 不一致时回滚到共同祖先并返回祖先高度，调用方需从祖先的下一个区块重新处理
+func (p *BlockProcessor) detectReorg(ctx context.Context, header *types.Header) (ancestor int64, reorged bool, err error) {
+	height := header.Number.Int64()
+	storedParent, ok := p.window.get(height - 1)
+	if !ok || storedParent == header.ParentHash.Hex() {
+		return 0, false, nil
+	}
+
+	logger.Error("检测到链重组：区块 %d 的父哈希 %s 与本地记录 %s 不一致",
+		height, header.ParentHash.Hex(), storedParent)
+
+	ancestor, err = p.findCommonAncestor(ctx, height-1)
+	if err != nil {
+		return 0, false, err
+	}
+
+	event := store.ReorgEvent{
+		ChainID:        p.config.Chain.ChainID,
+		DetectedHeight: height,
+		CommonAncestor: ancestor,
+		Depth:          height - 1 - ancestor,
+		OldHash:        storedParent,
+		NewHash:        header.ParentHash.Hex(),
+	}
+	if err := p.rollbackTo(ctx, event); err != nil {
+		return 0, false, err
+	}
+	return ancestor, true, nil
+}
+
+// findCommonAncestor 从 fromHeight 向下回溯，找到本地记录与主链哈希一致的最高区块
+func (p *BlockProcessor) findCommonAncestor(ctx context.Context, fromHeight int64) (int64, error) {
+	for h := fromHeight; h >= 0; h-- {
+		stored, ok := p.window.get(h)
+		if !ok {
+			break
+		}
+
+		canonical, err := p.chain.HeaderByNumber(ctx, big.NewInt(h))
+		if err != nil {
+			return 0, fmt.Errorf("回溯查询区块头 %d 失败: %w", h, err)
+		}
+		if canonical.Hash().Hex() == stored {
+			return h, nil
+		}
+	}
+	return 0, fmt.Errorf("链重组深度超过哈希窗口 %d，无法找到共同祖先，需要人工处理", p.window.size)
+}
+
+// rollbackTo 回滚到共同祖先：扣回孤块中已入账的充值并标记为已回滚、删除孤块哈希、回退游标并记录审计
+func (p *BlockProcessor) rollbackTo(ctx context.Context, event store.ReorgEvent) error {
+	ancestorHash, _ := p.window.get(event.CommonAncestor)
+
+	if p.db != nil {
+		err := db.WithTx(ctx, p.db, func(tx *sql.Tx) error {
+			debited, shortfalls, err := store.DebitCreditedDepositsAbove(ctx, tx, event.ChainID, event.CommonAncestor)
+			if err != nil {
+				return err
+			}
+			if debited > 0 {
+				logger.Error("链重组扣回已入账充值 %d 笔", debited)
+			}
+			for _, s := range shortfalls {
+				logger.Error("链重组扣回充值 %d 时账户 %d 的 %s 可用余额不足，缺口 %s 已记录待人工处理",
+					s.TransactionID, s.AccountID, s.Coin, s.Amount)
+			}
+
+			reverted, err := store.RevertDepositsAbove(ctx, tx, event.ChainID, event.CommonAncestor)
+			if err != nil {
+				return err
+			}
+			event.RevertedCount = reverted
+
+			if err := p.rollbackEvents(ctx, tx, event.CommonAncestor); err != nil {
+				return err
+			}
+
+			if err := store.DeleteBlockHashesAbove(ctx, tx, event.ChainID, event.CommonAncestor); err != nil {
+				return err
+			}
+			cursor := store.BlockCursor{
+				ChainID:   event.ChainID,
+				Name:      cursorName,
+				Height:    event.CommonAncestor,
+				BlockHash: ancestorHash,
+			}
+			if err := store.SaveCursor(ctx, tx, cursor); err != nil {
+				return err
+			}
+			return store.InsertReorgEvent(ctx, tx, event)
+		})
+		if err != nil {
+			return fmt.Errorf("链重组回滚失败: %w", err)
+		}
+	}
+
+	p.mu.Lock()
+	p.window.truncateAbove(event.CommonAncestor)
+	p.currentHeight = event.CommonAncestor
+	p.currentHash = ancestorHash
+	p.mu.Unlock()
+
+	logger.Error("链重组回滚完成：共同祖先=%d, 回滚深度=%d, 回滚交易=%d 条",
+		event.CommonAncestor, event.Depth, event.RevertedCount)
+	return nil
+}
@@ -0,0 +1,176 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"go_bullayer_v1/base/pkg/eth"
+	"go_bullayer_v1/processor/internal/config"
+	"go_bullayer_v1/processor/internal/store"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// fakeChain 基于 eth.FakeClient 的伪造链，支持模拟分叉
+type fakeChain struct {
+	*eth.FakeClient
+	headers []*types.Header
+}
+
+func newFakeChain(length int) *fakeChain {
+	c := &fakeChain{FakeClient: eth.NewFakeClient(11155111)}
+	c.extend(length, 0)
+	return c
+}
+
+// extend 在链尾追加 n 个区块，fork 用于区分不同分支的区块哈希
+func (c *fakeChain) extend(n int, fork byte) {
+	for i := 0; i < n; i++ {
+		parent := common.Hash{}
+		if len(c.headers) > 0 {
+			parent = c.headers[len(c.headers)-1].Hash()
+		}
+		header := &types.Header{
+			Number:     big.NewInt(int64(len(c.headers))),
+			ParentHash: parent,
+			Extra:      []byte{fork},
+		}
+		c.headers = append(c.headers, header)
+		c.AddBlock(types.NewBlockWithHeader(header), nil)
+	}
+}
+
+// reorgFrom 丢弃 height 及以上的区块，并用新分支替换为 n 个区块
+func (c *fakeChain) reorgFrom(height int64, n int, fork byte) {
+	c.headers = c.headers[:height]
+	c.Truncate(uint64(height))
+	c.extend(n, fork)
+}
+
+func (c *fakeChain) hash(height int64) string {
+	return c.headers[height].Hash().Hex()
+}
+
+func newTestConfig(window int64) config.Config {
+	var cfg config.Config
+	cfg.Chain.ChainID = 11155111
+	cfg.Chain.MaxBlocksPerRound = 100
+	cfg.Chain.ReorgWindow = window
+	return cfg
+}
+
+func TestExecute_ReorgRollsBackToCommonAncestor(t *testing.T) {
+	chain := newFakeChain(21)
+	p := newBlockProcessor(newTestConfig(16), nil, chain)
+
+	if err := p.Execute(context.Background()); err != nil {
+		t.Fatalf("first execute failed: %v", err)
+	}
+	if p.currentHeight != 20 || p.currentHash != chain.hash(20) {
+		t.Fatalf("unexpected cursor after first execute: %s", p)
+	}
+
+	// 区块 16-20 被新分支替换，新链高度为 23
+	oldHash16 := chain.hash(16)
+	chain.reorgFrom(16, 8, 1)
+
+	if err := p.Execute(context.Background()); err != nil {
+		t.Fatalf("execute after reorg failed: %v", err)
+	}
+	if p.currentHeight != 23 || p.currentHash != chain.hash(23) {
+		t.Fatalf("expected cursor at canonical head 23, got %s", p)
+	}
+	for h := int64(16); h <= 23; h++ {
+		stored, ok := p.window.get(h)
+		if !ok || stored != chain.hash(h) {
+			t.Fatalf("window hash at %d not canonical: %s", h, stored)
+		}
+	}
+	if stored, _ := p.window.get(16); stored == oldHash16 {
+		t.Fatal("orphaned block hash still in window")
+	}
+}
+
+func TestExecute_ReorgDeeperThanWindow(t *testing.T) {
+	chain := newFakeChain(21)
+	p := newBlockProcessor(newTestConfig(4), nil, chain)
+
+	if err := p.Execute(context.Background()); err != nil {
+		t.Fatalf("first execute failed: %v", err)
+	}
+
+	chain.reorgFrom(10, 12, 1)
+
+	err := p.Execute(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "共同祖先") {
+		t.Fatalf("expected reorg depth error, got %v", err)
+	}
+	if p.currentHeight != 20 {
+		t.Fatalf("cursor must not move on unresolved reorg, got %d", p.currentHeight)
+	}
+}
+
+func TestRollbackTo_PersistsRevertAndAudit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock failed: %v", err)
+	}
+	defer db.Close()
+
+	p := newBlockProcessor(newTestConfig(16), db, newFakeChain(13))
+	for h := int64(10); h <= 12; h++ {
+		p.window.put(h, fmt.Sprintf("0x%d", h))
+	}
+	p.currentHeight = 12
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, account_id, coin, amount FROM transactions").
+		WithArgs(int64(11155111), 1, 1, int64(10)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "coin", "amount"}).
+			AddRow(int64(5), int64(7), "ETH", "1.500000000000000000"))
+	mock.ExpectQuery("SELECT GREATEST").
+		WithArgs("1.500000000000000000", int64(7), "ETH").
+		WillReturnRows(sqlmock.NewRows([]string{"shortfall"}).AddRow("0.000000000000000000"))
+	mock.ExpectExec("UPDATE user_assets SET total = total - LEAST").
+		WithArgs("1.500000000000000000", "1.500000000000000000", int64(7), "ETH").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE transactions SET status").
+		WithArgs(3, int64(11155111), 1, int64(10), 3).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("DELETE FROM block_hashes").
+		WithArgs(int64(11155111), int64(10)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("INSERT INTO block_cursors").
+		WithArgs(int64(11155111), cursorName, int64(10), "0x10").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO chain_reorgs").
+		WithArgs(int64(11155111), int64(13), int64(10), int64(2), "0x12", "0xnew", int64(2)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err = p.rollbackTo(context.Background(), store.ReorgEvent{
+		ChainID:        11155111,
+		DetectedHeight: 13,
+		CommonAncestor: 10,
+		Depth:          2,
+		OldHash:        "0x12",
+		NewHash:        "0xnew",
+	})
+	if err != nil {
+		t.Fatalf("rollback failed: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+	if p.currentHeight != 10 || p.currentHash != "0x10" {
+		t.Fatalf("unexpected cursor after rollback: %s", p)
+	}
+	if _, ok := p.window.get(11); ok {
+		t.Fatal("orphaned heights must be removed from window")
+	}
+}
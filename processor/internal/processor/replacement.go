@@ -0,0 +1,156 @@
+package processor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/big"
+
+	basecommon "go_bullayer_v1/base/pkg/common"
+	"go_bullayer_v1/base/pkg/db"
+	"go_bullayer_v1/base/pkg/eth"
+	"go_bullayer_v1/base/pkg/logger"
+	"go_bullayer_v1/processor/internal/store"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// 卡住交易替换的默认参数
+const (
+	defaultStuckAfter      = 300
+	defaultFeeBumpPercent  = 15
+	minFeeBumpPercent      = 10 // geth 交易池接受替换交易的最小涨幅
+	defaultMaxReplacements = 3
+)
+
+// replaceIfStuck 最近一次广播超过 StuckAfter 秒仍未打包时，以相同 nonce 广播手续费更高的交易。
+// 加价替换达到 MaxReplacements 次或提现记录已无法构造交易时，改为发送 0 金额自转账取消原交易；每次替换都记录在 withdrawal_attempts 中
+func (p *WithdrawalProcessor) replaceIfStuck(ctx context.Context, w store.Withdrawal, attempts []store.WithdrawalAttempt) error {
+	last, speedUps := lastLiveAttempt(attempts)
+	if last == nil || last.ID == 0 {
+		return nil
+	}
+	stuckAfter := p.config.Withdrawal.StuckAfter
+	if stuckAfter <= 0 {
+		stuckAfter = defaultStuckAfter
+	}
+	if last.AgeSeconds < stuckAfter {
+		return nil
+	}
+
+	maxReplacements := p.config.Withdrawal.MaxReplacements
+	if maxReplacements <= 0 {
+		maxReplacements = defaultMaxReplacements
+	}
+	kind := basecommon.AttemptKindSpeedUp
+	if last.Kind == basecommon.AttemptKindCancel || speedUps >= maxReplacements {
+		kind = basecommon.AttemptKindCancel
+	}
+
+	tipCap, feeCap, err := p.bumpedFees(ctx, last)
+	if err != nil {
+		return err
+	}
+	if maxFee := eth.MaxFeePerGas(p.config.Withdrawal.MaxFeePerGasGwei); maxFee != nil && feeCap.Cmp(maxFee) > 0 {
+		logger.Error("提现 %d 交易 %s 已卡住 %d 秒，替换所需手续费超过 MaxFeePerGasGwei，等待人工处理",
+			w.ID, last.TxHash, last.AgeSeconds)
+		return nil
+	}
+
+	var inner *types.DynamicFeeTx
+	if kind == basecommon.AttemptKindSpeedUp {
+		inner, err = p.prepareTx(ctx, w)
+		if errors.Is(err, errInvalidWithdrawal) {
+			// 已广播的交易仍可能被打包，不能直接标记失败；改为取消，由打包的交易决定最终状态
+			logger.Error("提现 %d 无法重新构造交易，改为取消: %v", w.ID, err)
+			kind = basecommon.AttemptKindCancel
+		} else if err != nil {
+			return err
+		}
+	}
+	if kind == basecommon.AttemptKindCancel {
+		inner = &types.DynamicFeeTx{
+			ChainID: p.signer.ChainID(),
+			Gas:     params.TxGas,
+			To:      &p.from,
+			Value:   new(big.Int),
+		}
+	}
+	tx, err := p.signTx(inner, last.Nonce, tipCap, feeCap)
+	if err != nil {
+		return err
+	}
+
+	var attemptID int64
+	err = db.WithTx(ctx, p.db, func(q *sql.Tx) error {
+		var err error
+		attemptID, err = p.recordAttempt(ctx, q, w.ID, tx, kind)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := p.sender.SendTransaction(ctx, tx); err != nil {
+		// 原交易仍有效，继续等待打包，下一轮重新尝试替换
+		logger.Error("替换提现 %d 交易 %s 失败: %v", w.ID, last.TxHash, err)
+		return store.SetWithdrawalAttemptStatus(ctx, p.db, attemptID, basecommon.AttemptStatusDropped)
+	}
+	action := "加价替换"
+	if kind == basecommon.AttemptKindCancel {
+		action = "取消"
+	}
+	logger.Info("提现 %d 交易 %s 已卡住 %d 秒，%s为 %s（nonce %d，maxFeePerGas %s）",
+		w.ID, last.TxHash, last.AgeSeconds, action, tx.Hash().Hex(), tx.Nonce(), tx.GasFeeCap())
+	return nil
+}
+
+// lastLiveAttempt 返回最近一次成功广播的尝试及已加价替换的次数
+func lastLiveAttempt(attempts []store.WithdrawalAttempt) (*store.WithdrawalAttempt, int) {
+	var last *store.WithdrawalAttempt
+	speedUps := 0
+	for i := range attempts {
+		a := &attempts[i]
+		if a.Status == basecommon.AttemptStatusDropped {
+			continue
+		}
+		if a.Kind == basecommon.AttemptKindSpeedUp {
+			speedUps++
+		}
+		last = a
+	}
+	return last, speedUps
+}
+
+// bumpedFees 在上一次广播的手续费基础上按 FeeBumpPercent 上调，且不低于当前建议手续费
+func (p *WithdrawalProcessor) bumpedFees(ctx context.Context, last *store.WithdrawalAttempt) (tipCap, feeCap *big.Int, err error) {
+	bump := p.config.Withdrawal.FeeBumpPercent
+	if bump <= 0 {
+		bump = defaultFeeBumpPercent
+	}
+	bump = max(bump, minFeeBumpPercent)
+
+	suggestedTip, suggestedFee, err := eth.SuggestFees(ctx, p.sender)
+	if err != nil {
+		return nil, nil, err
+	}
+	tipCap = bigMax(bumpFee(last.GasTipCap, bump), suggestedTip)
+	feeCap = bigMax(bumpFee(last.GasFeeCap, bump), suggestedFee)
+	feeCap = bigMax(feeCap, tipCap)
+	return tipCap, feeCap, nil
+}
+
+// bumpFee fee * (100 + percent) / 100，向上取整，保证满足交易池的最小涨幅
+func bumpFee(fee uint64, percent int64) *big.Int {
+	n := new(big.Int).Mul(new(big.Int).SetUint64(fee), big.NewInt(100+percent))
+	n.Add(n, big.NewInt(99))
+	return n.Div(n, big.NewInt(100))
+}
+
+func bigMax(a, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,488 @@
+package processor
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	basecommon "go_bullayer_v1/base/pkg/common"
+	"go_bullayer_v1/base/pkg/db"
+	"go_bullayer_v1/base/pkg/eth"
+	"go_bullayer_v1/base/pkg/logger"
+	"go_bullayer_v1/base/pkg/utils"
+	"go_bullayer_v1/processor/internal/config"
+	"go_bullayer_v1/processor/internal/core"
+	"go_bullayer_v1/processor/internal/store"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// 提现任务默认参数
+const (
+	defaultWithdrawalBatchSize     = 20
+	defaultWithdrawalGasMultiplier = 1.2
+)
+
+// errInvalidWithdrawal 提现记录本身无效（地址、金额或币种配置错误），重试也无法成功
+var errInvalidWithdrawal = errors.New("无效的提现记录")
+
+// WithdrawalProcessor 提现执行任务。
+// 每轮先按回执更新已广播的提现，再领取待处理提现，使用热钱包签名 ETH 转账或 ERC20 transfer 并广播。
+// 状态流转：0-待处理 → 4-处理中（已签名，保存哈希和 nonce 后广播）→ 1-成功 / 2-失败。
+type WithdrawalProcessor struct {
+	config config.Config
+	db     *sql.DB
+	sender eth.TxSender
+	tokens *core.TokenMetadataResolver
+	key    *ecdsa.PrivateKey
+	from   common.Address
+	signer types.Signer
+	nonces *nonceManager
+}
+
+// NewWithdrawalProcessor 创建提现执行任务，从 Withdrawal.KeystoreFile 解密热钱包私钥
+func NewWithdrawalProcessor(ctx context.Context, cfg config.Config, db *sql.DB, chain eth.Client, sender eth.TxSender) (*WithdrawalProcessor, error) {
+	if db == nil {
+		return nil, errors.New("提现执行任务需要配置数据库")
+	}
+	if chain == nil || sender == nil {
+		return nil, errors.New("未初始化链客户端，请检查 Chain.RPCURL 配置")
+	}
+
+	if cfg.Withdrawal.KeystoreFile == "" {
+		return nil, errors.New("未配置 Withdrawal.KeystoreFile")
+	}
+	key, err := eth.LoadKeystore(cfg.Withdrawal.KeystoreFile, cfg.Withdrawal.KeystorePasswordFile)
+	if err != nil {
+		return nil, fmt.Errorf("加载提现热钱包 keystore 失败: %w", err)
+	}
+	chainID, err := sender.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("查询链ID失败: %w", err)
+	}
+	if cfg.Chain.ChainID > 0 && chainID.Int64() != cfg.Chain.ChainID {
+		return nil, fmt.Errorf("广播节点链ID %s 与配置的 Chain.ChainID %d 不一致", chainID, cfg.Chain.ChainID)
+	}
+
+	p := &WithdrawalProcessor{
+		config: cfg,
+		db:     db,
+		sender: sender,
+		tokens: core.NewTokenMetadataResolver(chain),
+		key:    key,
+		from:   crypto.PubkeyToAddress(key.PublicKey),
+		signer: types.LatestSignerForChainID(chainID),
+	}
+	p.nonces = newNonceManager(sender, chainID.Int64(), p.from)
+	logger.Info("提现热钱包地址: %s", p.from.Hex())
+	return p, nil
+}
+
+// Name 返回任务名称
+func (p *WithdrawalProcessor) Name() string {
+	return "提现执行任务"
+}
+
+// From 热钱包地址
+func (p *WithdrawalProcessor) From() common.Address {
+	return p.from
+}
+
+// Execute 确认已广播的提现（卡住的交易按相同 nonce 替换）并广播新的提现
+func (p *WithdrawalProcessor) Execute(ctx context.Context) error {
+	if err := p.confirmWithdrawals(ctx); err != nil {
+		return err
+	}
+	return p.broadcastWithdrawals(ctx)
+}
+
+func (p *WithdrawalProcessor) batchSize() int {
+	if p.config.Withdrawal.BatchSize <= 0 {
+		return defaultWithdrawalBatchSize
+	}
+	return p.config.Withdrawal.BatchSize
+}
+
+// broadcastWithdrawals 领取待处理提现并逐笔签名广播。
+// nonce 由 nonceManager 在领取提现的同一事务内持久化分配；节点明确拒绝交易时提现失败并归还 nonce，避免留下空洞。
+// 其他广播错误下交易可能已经发出，提现保持处理中并保留 nonce，由 confirmWithdrawals 按回执和链上 nonce 对账
+func (p *WithdrawalProcessor) broadcastWithdrawals(ctx context.Context) error {
+	pending, err := store.ListPendingWithdrawals(ctx, p.db, p.batchSize())
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	if err := p.nonces.sync(ctx); err != nil {
+		return err
+	}
+	tipCap, feeCap, err := eth.SuggestFees(ctx, p.sender)
+	if err != nil {
+		return err
+	}
+	if maxFee := eth.MaxFeePerGas(p.config.Withdrawal.MaxFeePerGasGwei); maxFee != nil && feeCap.Cmp(maxFee) > 0 {
+		feeCap = maxFee
+		if tipCap.Cmp(feeCap) > 0 {
+			tipCap = new(big.Int).Set(feeCap)
+		}
+	}
+
+	sent := 0
+	for _, w := range pending {
+		inner, err := p.prepareTx(ctx, w)
+		if errors.Is(err, errInvalidWithdrawal) {
+			logger.Error("提现 %d 无效，标记为失败: %v", w.ID, err)
+			if err := p.failUnsent(ctx, w.ID); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("构造提现 %d 交易失败: %w", w.ID, err)
+		}
+
+		var (
+			tx        *types.Transaction
+			attemptID int64
+			claimed   bool
+		)
+		err = db.WithTx(ctx, p.db, func(q *sql.Tx) error {
+			var err error
+			claimed, err = store.ClaimWithdrawal(ctx, q, w.ID)
+			if err != nil || !claimed {
+				return err
+			}
+			nonce, err := p.nonces.reserve(ctx, q)
+			if err != nil {
+				return err
+			}
+			tx, err = p.signTx(inner, nonce, tipCap, feeCap)
+			if err != nil {
+				return err
+			}
+			attemptID, err = p.recordAttempt(ctx, q, w.ID, tx, basecommon.AttemptKindOriginal)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			logger.Info("提现 %d 已被其他实例领取，跳过", w.ID)
+			continue
+		}
+
+		if err := p.sender.SendTransaction(ctx, tx); err != nil {
+			if !eth.IsTxRejected(err) {
+				logger.Error("广播提现 %d 交易 %s 结果未知，保持处理中等待对账: %v", w.ID, tx.Hash().Hex(), err)
+				return fmt.Errorf("广播提现 %d 结果未知: %w", w.ID, err)
+			}
+			logger.Error("节点拒绝提现 %d 交易 %s: %v", w.ID, tx.Hash().Hex(), err)
+			rbErr := db.WithTx(ctx, p.db, func(q *sql.Tx) error {
+				if err := store.CompleteWithdrawal(ctx, q, store.WithdrawalResult{ID: w.ID, Status: basecommon.TxStatusFailed}); err != nil {
+					return err
+				}
+				if err := store.SetWithdrawalAttemptStatus(ctx, q, attemptID, basecommon.AttemptStatusDropped); err != nil {
+					return err
+				}
+				return p.nonces.release(ctx, q, tx.Nonce())
+			})
+			if rbErr != nil {
+				return rbErr
+			}
+			return fmt.Errorf("广播提现 %d 失败: %w", w.ID, err)
+		}
+		logger.Info("已广播提现 %d: %s %s -> %s，交易 %s，nonce %d", w.ID, w.Amount, w.Coin, w.To, tx.Hash().Hex(), tx.Nonce())
+		sent++
+	}
+
+	if sent > 0 {
+		logger.Info("本轮广播提现 %d 笔", sent)
+	}
+	return nil
+}
+
+// recordAttempt 记录一次广播并把提现的当前交易哈希指向它，需在广播前调用
+func (p *WithdrawalProcessor) recordAttempt(ctx context.Context, q db.Querier, withdrawalID int64, tx *types.Transaction, kind int) (int64, error) {
+	if err := store.SetWithdrawalBroadcast(ctx, q, withdrawalID, p.from.Hex(), tx.Hash().Hex(), tx.Nonce()); err != nil {
+		return 0, err
+	}
+	return store.InsertWithdrawalAttempt(ctx, q, store.WithdrawalAttempt{
+		WithdrawalID: withdrawalID,
+		Nonce:        tx.Nonce(),
+		TxHash:       tx.Hash().Hex(),
+		Kind:         kind,
+		GasTipCap:    tx.GasTipCap().Uint64(),
+		GasFeeCap:    tx.GasFeeCap().Uint64(),
+		Status:       basecommon.AttemptStatusPending,
+	})
+}
+
+// failUnsent 将无法构造交易的提现直接标记为失败
+func (p *WithdrawalProcessor) failUnsent(ctx context.Context, id int64) error {
+	return db.WithTx(ctx, p.db, func(q *sql.Tx) error {
+		claimed, err := store.ClaimWithdrawal(ctx, q, id)
+		if err != nil || !claimed {
+			return err
+		}
+		return store.CompleteWithdrawal(ctx, q, store.WithdrawalResult{ID: id, Status: basecommon.TxStatusFailed})
+	})
+}
+
+// prepareTx 构造未签名的提现交易并估算 gas：原生 ETH 直接转账，ERC20 调用 transfer(to, amount)。
+// nonce 和手续费由调用方在签名时填入
+func (p *WithdrawalProcessor) prepareTx(ctx context.Context, w store.Withdrawal) (*types.DynamicFeeTx, error) {
+	if !common.IsHexAddress(w.To) {
+		return nil, fmt.Errorf("%w: 接收地址 %q 格式错误", errInvalidWithdrawal, w.To)
+	}
+	recipient := common.HexToAddress(w.To)
+
+	decimals := core.NativeDecimals
+	if w.CoinAddress != "" {
+		if !common.IsHexAddress(w.CoinAddress) {
+			return nil, fmt.Errorf("%w: 币种 %s 合约地址 %q 格式错误", errInvalidWithdrawal, w.Coin, w.CoinAddress)
+		}
+		var err error
+		decimals, err = p.tokenDecimals(ctx, w.Coin, w.CoinAddress)
+		if err != nil {
+			return nil, err
+		}
+	}
+	amount, err := utils.ParseUnits(w.Amount, decimals)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidWithdrawal, err)
+	}
+	if amount.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: 金额 %s 必须大于 0", errInvalidWithdrawal, w.Amount)
+	}
+
+	to, value := recipient, amount
+	var data []byte
+	if w.CoinAddress != "" {
+		to, value = common.HexToAddress(w.CoinAddress), new(big.Int)
+		data = eth.ERC20TransferData(recipient, amount)
+	}
+
+	gas, err := p.sender.EstimateGas(ctx, ethereum.CallMsg{From: p.from, To: &to, Value: value, Data: data})
+	if err != nil {
+		return nil, fmt.Errorf("估算 gas 失败: %w", err)
+	}
+	multiplier := p.config.Withdrawal.GasLimitMultiplier
+	if multiplier < 1 {
+		multiplier = defaultWithdrawalGasMultiplier
+	}
+	gas = uint64(float64(gas) * multiplier)
+
+	return &types.DynamicFeeTx{
+		ChainID: p.signer.ChainID(),
+		Gas:     gas,
+		To:      &to,
+		Value:   value,
+		Data:    data,
+	}, nil
+}
+
+// signTx 填入 nonce 和手续费后签名
+func (p *WithdrawalProcessor) signTx(inner *types.DynamicFeeTx, nonce uint64, tipCap, feeCap *big.Int) (*types.Transaction, error) {
+	unsigned := *inner
+	unsigned.Nonce = nonce
+	unsigned.GasTipCap = tipCap
+	unsigned.GasFeeCap = feeCap
+	tx, err := types.SignNewTx(p.key, p.signer, &unsigned)
+	if err != nil {
+		return nil, fmt.Errorf("签名交易失败: %w", err)
+	}
+	return tx, nil
+}
+
+// tokenDecimals ERC20 精度：优先使用 BlockProcessor.TokenDecimals 配置，否则查询合约 decimals()
+func (p *WithdrawalProcessor) tokenDecimals(ctx context.Context, coin, address string) (int, error) {
+	decimals := -1
+	for symbol, d := range p.config.BlockProcessor.TokenDecimals {
+		if strings.EqualFold(symbol, coin) {
+			decimals = d
+		}
+	}
+	p.tokens.SetOverride(address, coin, decimals)
+	meta, err := p.tokens.Resolve(ctx, address)
+	if err != nil {
+		return 0, fmt.Errorf("查询代币 %s 精度失败: %w", coin, err)
+	}
+	return meta.Decimals, nil
+}
+
+// confirmWithdrawals 查询已广播提现各次尝试的回执，达到确认数后更新为成功或失败；
+// 均未打包时，nonce 已在链上确认则按 reconcileNonce 对账，否则最近一次广播超过 StuckAfter 秒时加价替换或取消
+func (p *WithdrawalProcessor) confirmWithdrawals(ctx context.Context) error {
+	processing, err := store.ListProcessingWithdrawals(ctx, p.db, p.batchSize())
+	if err != nil {
+		return err
+	}
+	if len(processing) == 0 {
+		return nil
+	}
+
+	latest, err := p.sender.BlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("查询最新高度失败: %w", err)
+	}
+	// 先于回执查询读取已确认的 nonce，避免交易恰好在两次查询之间打包时被误判
+	confirmedNonce, err := p.confirmedNonce(ctx, latest)
+	if err != nil {
+		return err
+	}
+
+	for _, w := range processing {
+		attempts, err := store.ListWithdrawalAttempts(ctx, p.db, w.ID)
+		if err != nil {
+			return err
+		}
+		if len(attempts) == 0 {
+			// 未记录广播尝试的提现只跟踪当前交易哈希，不做替换
+			attempts = []store.WithdrawalAttempt{{WithdrawalID: w.ID, Nonce: w.Nonce, TxHash: w.TxHash}}
+		}
+
+		mined, receipt, err := p.findMinedAttempt(ctx, attempts)
+		if err != nil {
+			return fmt.Errorf("查询提现 %d 回执失败: %w", w.ID, err)
+		}
+		if receipt == nil {
+			if w.Nonce < confirmedNonce {
+				if err := p.reconcileNonce(ctx, w, attempts, latest); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := p.replaceIfStuck(ctx, w, attempts); err != nil {
+				return err
+			}
+			continue
+		}
+
+		confirmations := int64(latest) - receipt.BlockNumber.Int64() + 1
+		if confirmations < p.config.Withdrawal.Confirmations {
+			continue
+		}
+		if err := p.completeWithdrawal(ctx, w, mined, receipt, confirmations); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// confirmedNonce 热钱包在达到确认数的区块上的 nonce，小于该值的 nonce 均已打包且不会因重组回滚
+func (p *WithdrawalProcessor) confirmedNonce(ctx context.Context, latest uint64) (uint64, error) {
+	depth := uint64(max(p.config.Withdrawal.Confirmations-1, 0))
+	if depth > latest {
+		return 0, nil
+	}
+	nonce, err := p.sender.NonceAt(ctx, p.from, new(big.Int).SetUint64(latest-depth))
+	if err != nil {
+		return 0, fmt.Errorf("查询热钱包已确认 nonce 失败: %w", err)
+	}
+	return nonce, nil
+}
+
+// reconcileNonce 提现的 nonce 已在链上确认，但最近的广播均未找到回执时，逐一核对全部广播（包括广播失败的）：
+// 找到回执则按该交易结算；全部确认不存在说明 nonce 被其他交易占用，提现的交易不会再被打包，提现失败并退回冻结资产
+func (p *WithdrawalProcessor) reconcileNonce(ctx context.Context, w store.Withdrawal, attempts []store.WithdrawalAttempt, latest uint64) error {
+	for _, a := range attempts {
+		receipt, err := p.sender.TransactionReceipt(ctx, common.HexToHash(a.TxHash))
+		if errors.Is(err, ethereum.NotFound) {
+			continue
+		}
+		if eth.IsReceiptNotFound(err) {
+			// 交易索引构建中，无法确认交易不存在，下一轮再核对
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("查询提现 %d 回执失败: %w", w.ID, err)
+		}
+		return p.completeWithdrawal(ctx, w, a, receipt, int64(latest)-receipt.BlockNumber.Int64()+1)
+	}
+
+	err := db.WithTx(ctx, p.db, func(q *sql.Tx) error {
+		if err := store.CompleteWithdrawal(ctx, q, store.WithdrawalResult{ID: w.ID, Status: basecommon.TxStatusFailed}); err != nil {
+			return err
+		}
+		return store.SettleWithdrawalAttempts(ctx, q, w.ID, 0)
+	})
+	if err != nil {
+		return err
+	}
+	logger.Error("提现 %d 的 nonce %d 已被其他交易占用，提现失败并退回冻结资产", w.ID, w.Nonce)
+	return nil
+}
+
+// findMinedAttempt 从最近一次开始查询回执，同一 nonce 最多只有一笔交易被打包
+func (p *WithdrawalProcessor) findMinedAttempt(ctx context.Context, attempts []store.WithdrawalAttempt) (store.WithdrawalAttempt, *types.Receipt, error) {
+	for i := len(attempts) - 1; i >= 0; i-- {
+		a := attempts[i]
+		if a.Status == basecommon.AttemptStatusDropped {
+			continue
+		}
+		receipt, err := p.sender.TransactionReceipt(ctx, common.HexToHash(a.TxHash))
+		if eth.IsReceiptNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return store.WithdrawalAttempt{}, nil, err
+		}
+		return a, receipt, nil
+	}
+	return store.WithdrawalAttempt{}, nil, nil
+}
+
+// completeWithdrawal 按打包的交易更新提现最终状态：取消交易被打包或执行失败时提现失败
+func (p *WithdrawalProcessor) completeWithdrawal(ctx context.Context, w store.Withdrawal, mined store.WithdrawalAttempt, receipt *types.Receipt, confirmations int64) error {
+	result := store.WithdrawalResult{
+		ID:            w.ID,
+		Status:        basecommon.TxStatusSuccess,
+		BlockNumber:   receipt.BlockNumber.Int64(),
+		Gas:           "0",
+		Confirmations: confirmations,
+		TxHash:        mined.TxHash,
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful || mined.Kind == basecommon.AttemptKindCancel {
+		result.Status = basecommon.TxStatusFailed
+	}
+	if receipt.EffectiveGasPrice != nil {
+		fee := new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), receipt.EffectiveGasPrice)
+		gas, err := utils.ScaleToDecimal(fee.String(), core.NativeDecimals, 36, 18)
+		if err != nil {
+			return fmt.Errorf("提现 %d gas 费用换算失败: %w", w.ID, err)
+		}
+		result.Gas = gas
+	}
+
+	err := db.WithTx(ctx, p.db, func(q *sql.Tx) error {
+		if err := store.CompleteWithdrawal(ctx, q, result); err != nil {
+			return err
+		}
+		if mined.ID == 0 {
+			return nil
+		}
+		return store.SettleWithdrawalAttempts(ctx, q, w.ID, mined.ID)
+	})
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case mined.Kind == basecommon.AttemptKindCancel:
+		logger.Error("提现 %d 已被取消交易 %s 替换，区块 %d", w.ID, mined.TxHash, result.BlockNumber)
+	case result.Status == basecommon.TxStatusSuccess:
+		logger.Info("提现 %d 已确认成功，交易 %s，区块 %d", w.ID, mined.TxHash, result.BlockNumber)
+	default:
+		logger.Error("提现 %d 交易 %s 执行失败，区块 %d", w.ID, mined.TxHash, result.BlockNumber)
+	}
+	return nil
+}
@@ -0,0 +1,573 @@
+package processor
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"database/sql/driver"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go_bullayer_v1/base/pkg/eth"
+	"go_bullayer_v1/processor/internal/config"
+	"go_bullayer_v1/processor/internal/store"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+)
+
+// captureArg 匹配任意字符串参数并记录其值
+type captureArg struct{ value *string }
+
+func (c captureArg) Match(v driver.Value) bool {
+	s, ok := v.(string)
+	if ok {
+		*c.value = s
+	}
+	return ok
+}
+
+// newWithdrawalTestEnv 创建持有 100 ETH 的热钱包、模拟后端和 keystore 配置
+func newWithdrawalTestEnv(t *testing.T) (config.Config, *simulated.Backend, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("generate key failed: %v", err)
+	}
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	keyJSON, err := keystore.EncryptKey(&keystore.Key{Address: from, PrivateKey: key},
+		"secret", keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatalf("encrypt key failed: %v", err)
+	}
+	dir := t.TempDir()
+	keyFile, passwordFile := filepath.Join(dir, "hot.json"), filepath.Join(dir, "password")
+	if err := os.WriteFile(keyFile, keyJSON, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(passwordFile, []byte("secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	balance := new(big.Int).Mul(big.NewInt(100), big.NewInt(1e18))
+	backend := simulated.NewBackend(types.GenesisAlloc{from: {Balance: balance}})
+	t.Cleanup(func() { backend.Close() })
+
+	var cfg config.Config
+	cfg.Withdrawal.KeystoreFile = keyFile
+	cfg.Withdrawal.KeystorePasswordFile = passwordFile
+	cfg.Withdrawal.Confirmations = 1
+	return cfg, backend, key
+}
+
+var (
+	processingCols = []string{"id", "account_id", "coin", "coin_address", "amount", "to_address", "tx_hash", "nonce"}
+	pendingCols    = []string{"id", "account_id", "coin", "coin_address", "amount", "to_address"}
+	attemptCols    = []string{"id", "withdrawal_id", "nonce", "tx_hash", "kind", "gas_tip_cap", "gas_fee_cap", "status", "age"}
+)
+
+// sentAttempt 测试中记录的一次广播
+type sentAttempt struct {
+	kind   int
+	hash   string
+	tipCap driver.Value
+	feeCap driver.Value
+}
+
+// captureValue 匹配任意参数并记录其值
+type captureValue struct{ value *driver.Value }
+
+func (c captureValue) Match(v driver.Value) bool {
+	*c.value = v
+	return true
+}
+
+// expectAttempt 期望在事务内记录一次广播，返回用于读取哈希和手续费的记录
+func expectAttempt(mock sqlmock.Sqlmock, from string, withdrawalID int64, kind int) *sentAttempt {
+	a := &sentAttempt{kind: kind}
+	mock.ExpectExec("UPDATE transactions SET from_address").
+		WithArgs(from, captureArg{&a.hash}, int64(0), withdrawalID, 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO withdrawal_attempts").
+		WithArgs(withdrawalID, int64(0), sqlmock.AnyArg(), kind, captureValue{&a.tipCap}, captureValue{&a.feeCap}, 0).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	return a
+}
+
+// expectBroadcastETH 期望领取提现 1（1.5 ETH）、分配 nonce 0 并记录原始广播
+func expectBroadcastETH(mock sqlmock.Sqlmock, from string, recipient common.Address) *sentAttempt {
+	mock.ExpectQuery("SELECT t.id, t.account_id, t.coin, COALESCE\\(t.coin_address, c.coin_address, ''\\), t.amount, t.to_address, t.tx_hash").
+		WillReturnRows(sqlmock.NewRows(processingCols))
+	mock.ExpectQuery("SELECT t.id, t.account_id, t.coin").
+		WithArgs(2, 0, defaultWithdrawalBatchSize).
+		WillReturnRows(sqlmock.NewRows(pendingCols).AddRow(int64(1), int64(7), "ETH", "", "1.5", recipient.Hex()))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE transactions SET status = \\? WHERE id = \\?").
+		WithArgs(4, int64(1), 2, 0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT next_nonce FROM wallet_nonces").
+		WithArgs(int64(1337), from).
+		WillReturnRows(sqlmock.NewRows([]string{"next_nonce"}))
+	mock.ExpectExec("INSERT INTO wallet_nonces").
+		WithArgs(int64(1337), from, int64(1)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	a := expectAttempt(mock, from, 1, 0)
+	mock.ExpectCommit()
+	return a
+}
+
+// expectProcessing 期望查询处理中的提现 1 及其广播记录
+func expectProcessing(mock sqlmock.Sqlmock, recipient common.Address, attempts ...*sentAttempt) {
+	current := attempts[len(attempts)-1]
+	mock.ExpectQuery("SELECT t.id, t.account_id, t.coin, COALESCE\\(t.coin_address, c.coin_address, ''\\), t.amount, t.to_address, t.tx_hash").
+		WillReturnRows(sqlmock.NewRows(processingCols).
+			AddRow(int64(1), int64(7), "ETH", "", "1.5", recipient.Hex(), current.hash, uint64(0)))
+	rows := sqlmock.NewRows(attemptCols)
+	for i, a := range attempts {
+		rows.AddRow(int64(i+1), int64(1), uint64(0), a.hash, a.kind, a.tipCap, a.feeCap, 0, int64(600))
+	}
+	mock.ExpectQuery("SELECT id, withdrawal_id, nonce, tx_hash, kind").
+		WithArgs(int64(1)).
+		WillReturnRows(rows)
+}
+
+func expectNoPending(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("SELECT t.id, t.account_id, t.coin").
+		WillReturnRows(sqlmock.NewRows(pendingCols))
+}
+
+func TestWithdrawalProcessor_BroadcastsAndConfirmsETH(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock failed: %v", err)
+	}
+	defer db.Close()
+
+	cfg, backend, key := newWithdrawalTestEnv(t)
+	client := backend.Client()
+	p, err := NewWithdrawalProcessor(context.Background(), cfg, db, eth.NewFakeClient(1337), client)
+	if err != nil {
+		t.Fatalf("create processor failed: %v", err)
+	}
+	if p.From() != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Fatalf("unexpected hot wallet %s", p.From().Hex())
+	}
+	from := p.From().Hex()
+	recipient := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+
+	// 第一轮：领取、分配 nonce 并广播
+	original := expectBroadcastETH(mock, from, recipient)
+	if err := p.Execute(context.Background()); err != nil {
+		t.Fatalf("first execute failed: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+	backend.Commit()
+
+	// 第二轮：回执成功，更新为成功
+	expectProcessing(mock, recipient, original)
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE transactions SET status = \\?, block_number").
+		WithArgs(1, int64(1), sqlmock.AnyArg(), int64(1), original.hash, int64(1), 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE user_assets u JOIN transactions t .* SET u.total = u.total").
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE withdrawal_attempts SET status = IF").
+		WithArgs(int64(1), 1, 2, int64(1), 0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectNoPending(mock)
+
+	if err := p.Execute(context.Background()); err != nil {
+		t.Fatalf("second execute failed: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := client.BalanceAt(context.Background(), recipient, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := big.NewInt(1.5e18); got.Cmp(want) != 0 {
+		t.Fatalf("recipient balance: expected %s, got %s", want, got)
+	}
+}
+
+// flakySender 广播时返回 err，forward 为 true 时先把交易发给节点，模拟发出后响应超时
+type flakySender struct {
+	eth.TxSender
+	forward bool
+	err     error
+}
+
+func (s *flakySender) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if s.forward {
+		if err := s.TxSender.SendTransaction(ctx, tx); err != nil {
+			return err
+		}
+	}
+	return s.err
+}
+
+func TestWithdrawalProcessor_KeepsProcessingOnUnknownBroadcastError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock failed: %v", err)
+	}
+	defer db.Close()
+
+	cfg, backend, _ := newWithdrawalTestEnv(t)
+	sender := &flakySender{TxSender: backend.Client(), forward: true, err: context.DeadlineExceeded}
+	p, err := NewWithdrawalProcessor(context.Background(), cfg, db, eth.NewFakeClient(1337), sender)
+	if err != nil {
+		t.Fatalf("create processor failed: %v", err)
+	}
+	from := p.From().Hex()
+	recipient := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+
+	// 第一轮：广播超时，不退款也不归还 nonce
+	original := expectBroadcastETH(mock, from, recipient)
+	if err := p.Execute(context.Background()); err == nil {
+		t.Fatal("expected broadcast error")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+	backend.Commit()
+
+	// 第二轮：交易实际已打包，按回执更新为成功
+	sender.err = nil
+	expectProcessing(mock, recipient, original)
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE transactions SET status = \\?, block_number").
+		WithArgs(1, int64(1), sqlmock.AnyArg(), int64(1), original.hash, int64(1), 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE user_assets u JOIN transactions t .* SET u.total = u.total").
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE withdrawal_attempts SET status = IF").
+		WithArgs(int64(1), 1, 2, int64(1), 0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectNoPending(mock)
+	if err := p.Execute(context.Background()); err != nil {
+		t.Fatalf("second execute failed: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWithdrawalProcessor_RefundsRejectedBroadcast(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock failed: %v", err)
+	}
+	defer db.Close()
+
+	cfg, backend, _ := newWithdrawalTestEnv(t)
+	sender := &flakySender{TxSender: backend.Client(), err: errors.New("insufficient funds for gas * price + value")}
+	p, err := NewWithdrawalProcessor(context.Background(), cfg, db, eth.NewFakeClient(1337), sender)
+	if err != nil {
+		t.Fatalf("create processor failed: %v", err)
+	}
+	from := p.From().Hex()
+	recipient := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+
+	// 节点拒绝交易：提现失败、退回冻结资产并归还 nonce
+	expectBroadcastETH(mock, from, recipient)
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE transactions SET status = \\?, block_number").
+		WithArgs(2, nil, "0", int64(0), nil, int64(1), 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE user_assets u JOIN transactions t .* SET u.available = u.available").
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE withdrawal_attempts SET status = \\? WHERE id = \\?").
+		WithArgs(3, int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE wallet_nonces SET next_nonce").
+		WithArgs(int64(0), int64(1337), from, int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := p.Execute(context.Background()); err == nil {
+		t.Fatal("expected broadcast error")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWithdrawalProcessor_FailsWhenNonceTakenByOtherTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock failed: %v", err)
+	}
+	defer db.Close()
+
+	cfg, backend, key := newWithdrawalTestEnv(t)
+	client := backend.Client()
+	sender := &flakySender{TxSender: client, err: context.DeadlineExceeded}
+	p, err := NewWithdrawalProcessor(context.Background(), cfg, db, eth.NewFakeClient(1337), sender)
+	if err != nil {
+		t.Fatalf("create processor failed: %v", err)
+	}
+	from := p.From().Hex()
+	recipient := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+
+	// 第一轮：广播超时且交易未发出
+	original := expectBroadcastETH(mock, from, recipient)
+	if err := p.Execute(context.Background()); err == nil {
+		t.Fatal("expected broadcast error")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 系统外的交易占用了 nonce 0
+	other := common.HexToAddress("0x00000000000000000000000000000000000000c1")
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(big.NewInt(1337)), &types.DynamicFeeTx{
+		ChainID:   big.NewInt(1337),
+		Nonce:     0,
+		GasTipCap: big.NewInt(1e9),
+		GasFeeCap: big.NewInt(100e9),
+		Gas:       21000,
+		To:        &other,
+		Value:     big.NewInt(1),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SendTransaction(context.Background(), tx); err != nil {
+		t.Fatal(err)
+	}
+	backend.Commit()
+
+	// 第二轮：nonce 已确认且提现交易不存在，提现失败并退回冻结资产
+	expectProcessing(mock, recipient, original)
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE transactions SET status = \\?, block_number").
+		WithArgs(2, nil, "0", int64(0), nil, int64(1), 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE user_assets u JOIN transactions t .* SET u.available = u.available").
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE withdrawal_attempts SET status = IF").
+		WithArgs(int64(0), 1, 2, int64(1), 0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectNoPending(mock)
+	if err := p.Execute(context.Background()); err != nil {
+		t.Fatalf("second execute failed: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWithdrawalProcessor_ReplacesStuckTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock failed: %v", err)
+	}
+	defer db.Close()
+
+	cfg, backend, _ := newWithdrawalTestEnv(t)
+	cfg.Withdrawal.MaxReplacements = 1
+	client := backend.Client()
+	p, err := NewWithdrawalProcessor(context.Background(), cfg, db, eth.NewFakeClient(1337), client)
+	if err != nil {
+		t.Fatalf("create processor failed: %v", err)
+	}
+	from := p.From().Hex()
+	recipient := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+	execute := func(round string) {
+		t.Helper()
+		if err := p.Execute(context.Background()); err != nil {
+			t.Fatalf("%s execute failed: %v", round, err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("%s: %v", round, err)
+		}
+	}
+
+	original := expectBroadcastETH(mock, from, recipient)
+	execute("broadcast")
+
+	// 未出块且已超过 StuckAfter：相同 nonce 加价替换
+	expectProcessing(mock, recipient, original)
+	mock.ExpectBegin()
+	speedUp := expectAttempt(mock, from, 1, 1)
+	mock.ExpectCommit()
+	expectNoPending(mock)
+	execute("speed up")
+	if speedUp.hash == original.hash || speedUp.feeCap.(int64) < original.feeCap.(int64)*110/100 {
+		t.Fatalf("replacement must bump fees by at least 10%%: %+v -> %+v", original, speedUp)
+	}
+
+	// 加价次数达到 MaxReplacements：发送 0 金额自转账取消
+	expectProcessing(mock, recipient, original, speedUp)
+	mock.ExpectBegin()
+	cancel := expectAttempt(mock, from, 1, 2)
+	mock.ExpectCommit()
+	expectNoPending(mock)
+	execute("cancel")
+
+	// 取消交易被打包：提现失败，记录实际打包的交易
+	backend.Commit()
+	expectProcessing(mock, recipient, original, speedUp, cancel)
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE transactions SET status = \\?, block_number").
+		WithArgs(2, int64(1), sqlmock.AnyArg(), int64(1), cancel.hash, int64(1), 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE user_assets u JOIN transactions t .* SET u.available = u.available").
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE withdrawal_attempts SET status = IF").
+		WithArgs(int64(3), 1, 2, int64(1), 0).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+	expectNoPending(mock)
+	execute("settle")
+
+	got, err := client.BalanceAt(context.Background(), recipient, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Sign() != 0 {
+		t.Fatalf("cancelled withdrawal must not pay out, recipient has %s", got)
+	}
+}
+
+func TestWithdrawalProcessor_FailsInvalidWithdrawal(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock failed: %v", err)
+	}
+	defer db.Close()
+
+	cfg, backend, _ := newWithdrawalTestEnv(t)
+	p, err := NewWithdrawalProcessor(context.Background(), cfg, db, eth.NewFakeClient(1337), backend.Client())
+	if err != nil {
+		t.Fatalf("create processor failed: %v", err)
+	}
+
+	mock.ExpectQuery("SELECT t.id, t.account_id, t.coin, COALESCE\\(t.coin_address, c.coin_address, ''\\), t.amount, t.to_address, t.tx_hash").
+		WillReturnRows(sqlmock.NewRows(processingCols))
+	mock.ExpectQuery("SELECT t.id, t.account_id, t.coin").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "coin", "coin_address", "amount", "to_address"}).
+			AddRow(int64(3), int64(7), "ETH", "", "1.5", "not-an-address"))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE transactions SET status = \\? WHERE id = \\?").
+		WithArgs(4, int64(3), 2, 0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE transactions SET status = \\?, block_number").
+		WithArgs(2, nil, "0", int64(0), nil, int64(3), 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE user_assets u JOIN transactions t .* SET u.available = u.available").
+		WithArgs(int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := p.Execute(context.Background()); err != nil {
+		t.Fatalf("execute failed: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWithdrawalProcessor_CancelsStuckInvalidWithdrawal(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock failed: %v", err)
+	}
+	defer db.Close()
+
+	cfg, backend, _ := newWithdrawalTestEnv(t)
+	p, err := NewWithdrawalProcessor(context.Background(), cfg, db, eth.NewFakeClient(1337), backend.Client())
+	if err != nil {
+		t.Fatalf("create processor failed: %v", err)
+	}
+	from := p.From().Hex()
+	recipient := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+
+	original := expectBroadcastETH(mock, from, recipient)
+	if err := p.Execute(context.Background()); err != nil {
+		t.Fatalf("broadcast execute failed: %v", err)
+	}
+
+	// 已广播的提现无法重新构造交易：不能中断本轮，也不能直接标记失败，改为发送取消交易
+	mock.ExpectQuery("SELECT t.id, t.account_id, t.coin, COALESCE\\(t.coin_address, c.coin_address, ''\\), t.amount, t.to_address, t.tx_hash").
+		WillReturnRows(sqlmock.NewRows(processingCols).
+			AddRow(int64(1), int64(7), "ETH", "", "1.5", "not-an-address", original.hash, uint64(0)))
+	mock.ExpectQuery("SELECT id, withdrawal_id, nonce, tx_hash, kind").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows(attemptCols).
+			AddRow(int64(1), int64(1), uint64(0), original.hash, original.kind, original.tipCap, original.feeCap, 0, int64(600)))
+	mock.ExpectBegin()
+	expectAttempt(mock, from, 1, 2)
+	mock.ExpectCommit()
+	expectNoPending(mock)
+	if err := p.Execute(context.Background()); err != nil {
+		t.Fatalf("execute failed: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWithdrawalProcessor_BuildsERC20Transfer(t *testing.T) {
+	cfg, backend, _ := newWithdrawalTestEnv(t)
+	token := common.HexToAddress("0x1c7d4b196cb0c7b01d743fbc6116a902379c7238")
+	chain := eth.NewFakeClient(1337)
+	chain.SetCallResult(token, crypto.Keccak256([]byte("decimals()"))[:4], common.LeftPadBytes([]byte{6}, 32))
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock failed: %v", err)
+	}
+	defer db.Close()
+	p, err := NewWithdrawalProcessor(context.Background(), cfg, db, chain, backend.Client())
+	if err != nil {
+		t.Fatalf("create processor failed: %v", err)
+	}
+
+	recipient := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+	inner, err := p.prepareTx(context.Background(), store.Withdrawal{
+		ID: 5, Coin: "USDC", CoinAddress: token.Hex(), Amount: "12.5", To: recipient.Hex(),
+	})
+	if err != nil {
+		t.Fatalf("prepare tx failed: %v", err)
+	}
+	tx, err := p.signTx(inner, 9, big.NewInt(1), big.NewInt(2_000_000_000))
+	if err != nil {
+		t.Fatalf("sign tx failed: %v", err)
+	}
+
+	if *tx.To() != token || tx.Value().Sign() != 0 || tx.Nonce() != 9 {
+		t.Fatalf("unexpected tx envelope: to=%s value=%s nonce=%d", tx.To().Hex(), tx.Value(), tx.Nonce())
+	}
+	data := tx.Data()
+	if len(data) != 68 || common.BytesToAddress(data[4:36]) != recipient ||
+		new(big.Int).SetBytes(data[36:]).Cmp(big.NewInt(12_500_000)) != 0 {
+		t.Fatalf("unexpected transfer calldata: %x", data)
+	}
+
+	if _, err := p.prepareTx(context.Background(), store.Withdrawal{
+		Coin: "USDC", CoinAddress: token.Hex(), Amount: "0.0000001", To: recipient.Hex(),
+	}); err == nil {
+		t.Fatal("expected error for amount below token precision")
+	}
+}
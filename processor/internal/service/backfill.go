@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"go_bullayer_v1/base/pkg/logger"
+	"go_bullayer_v1/processor/internal/config"
+	"go_bullayer_v1/processor/internal/processor"
+)
+
+// RunBackfill 回填 [from, to] 区间的历史区块，完成、出错或 ctx 取消时返回
+// 使用与实时处理相同的数据库和链客户端配置，但不启动任何周期任务
+func RunBackfill(ctx context.Context, cfg config.Config, from, to int64) error {
+	svc, err := newBackfillService(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer svc.cancel()
+	defer svc.closeDB()
+
+	backfill, err := processor.NewBackfill(svc.ctx, cfg, svc.db, svc.chain, from, to)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("开始回填区块 [%d, %d]，游标 %s", from, to, backfill.CursorName())
+	return backfill.Run(svc.ctx, func(p processor.BackfillProgress) {
+		msg := fmt.Sprintf("回填进度 %d/%d (%.1f%%)，写入充值 %d 条，拒绝 %d 条",
+			p.Height, p.To, p.Percent(), p.Deposits, p.Rejections)
+		fmt.Println(msg)
+		logger.Info("%s", msg)
+	})
+}
+
+// newBackfillService 只初始化数据库和链客户端
+func newBackfillService(ctx context.Context, cfg config.Config) (*ProcessorService, error) {
+	ctxWithCancel, cancel := context.WithCancel(ctx)
+	svc := &ProcessorService{
+		ctx:    ctxWithCancel,
+		cancel: cancel,
+		config: cfg,
+	}
+	if err := svc.initDB(); err != nil {
+		cancel()
+		return nil, err
+	}
+	if svc.db == nil {
+		cancel()
+		return nil, fmt.Errorf("回填需要可用的数据库连接")
+	}
+	svc.initETHClient()
+	return svc, nil
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"go_bullayer_v1/base/pkg/eth"
+	"go_bullayer_v1/base/pkg/logger"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// 订阅断开后的重连退避
+const (
+	headResubscribeDelay    = time.Second
+	headResubscribeMaxDelay = time.Minute
+)
+
+// headWatcher 订阅 newHeads，在新区块到达时触发区块处理。
+// 同一高度的重复区块头被忽略，debounce 时间内连续到达的区块头合并为一次触发；
+// 订阅断开期间 subscribed 为 false，由调用方回退到定时轮询，同时按退避间隔重连。
+type headWatcher struct {
+	dial       func(ctx context.Context) (eth.HeadSubscriber, error)
+	debounce   time.Duration
+	trigger    chan struct{} // 容量为 1，处理任务执行期间到达的触发会被合并
+	subscribed atomic.Bool
+	lastHead   uint64
+}
+
+// newHeadWatcher 创建区块头订阅，debounce 为合并触发的时间窗口
+func newHeadWatcher(dial func(ctx context.Context) (eth.HeadSubscriber, error), debounce time.Duration) *headWatcher {
+	return &headWatcher{
+		dial:     dial,
+		debounce: debounce,
+		trigger:  make(chan struct{}, 1),
+	}
+}
+
+// Triggers 返回新区块触发通道
+func (w *headWatcher) Triggers() <-chan struct{} {
+	return w.trigger
+}
+
+// Subscribed 订阅当前是否可用，不可用时调用方应按 Interval 轮询
+func (w *headWatcher) Subscribed() bool {
+	return w.subscribed.Load()
+}
+
+// run 保持订阅直到 ctx 结束，订阅失败或断开后按指数退避重连
+func (w *headWatcher) run(ctx context.Context) {
+	delay := headResubscribeDelay
+	for {
+		err := w.subscribe(ctx)
+		w.subscribed.Store(false)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			// 订阅曾经建立成功，重新从最短退避开始
+			delay = headResubscribeDelay
+		} else {
+			logger.Error("newHeads 订阅失败，%v 后重试，期间回退到定时轮询: %v", delay, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		if err != nil {
+			delay = min(delay*2, headResubscribeMaxDelay)
+		}
+	}
+}
+
+// subscribe 建立一次订阅并消费区块头，订阅建立后断开时返回 nil
+func (w *headWatcher) subscribe(ctx context.Context) error {
+	client, err := w.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	heads := make(chan *types.Header, 16)
+	sub, err := client.SubscribeNewHead(ctx, heads)
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+
+	w.subscribed.Store(true)
+	logger.Info("newHeads 订阅成功，区块处理改为由新区块触发")
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-sub.Err():
+			logger.Error("newHeads 订阅断开，回退到定时轮询: %v", err)
+			return nil
+		case head := <-heads:
+			if head == nil || head.Number == nil {
+				continue
+			}
+			number := head.Number.Uint64()
+			if number <= w.lastHead {
+				// 重复推送或链重组产生的同高度区块头由下一次处理统一检测
+				continue
+			}
+			w.lastHead = number
+			if debounce == nil {
+				debounce = time.After(w.debounce)
+			}
+		case <-debounce:
+			debounce = nil
+			w.fire()
+		}
+	}
+}
+
+// fire 非阻塞地发出一次触发，已有未消费的触发时直接合并
+func (w *headWatcher) fire() {
+	select {
+	case w.trigger <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"go_bullayer_v1/base/pkg/eth"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// fakeHeadNode 内存 newHeads 节点，可推送区块头和主动断开订阅
+type fakeHeadNode struct {
+	mu    sync.Mutex
+	heads chan<- *types.Header
+	drop  chan error
+	dials int
+}
+
+func (n *fakeHeadNode) dial(ctx context.Context) (eth.HeadSubscriber, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.dials++
+	return n, nil
+}
+
+func (n *fakeHeadNode) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.heads = ch
+	n.drop = make(chan error, 1)
+	drop := n.drop
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		select {
+		case err := <-drop:
+			return err
+		case <-quit:
+			return nil
+		}
+	}), nil
+}
+
+func (n *fakeHeadNode) Close() {}
+
+func (n *fakeHeadNode) push(number int64) {
+	n.mu.Lock()
+	ch := n.heads
+	n.mu.Unlock()
+	ch <- &types.Header{Number: big.NewInt(number)}
+}
+
+func (n *fakeHeadNode) disconnect() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.drop <- errors.New("websocket: close 1006")
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func countTriggers(w *headWatcher, within time.Duration) int {
+	n := 0
+	timeout := time.After(within)
+	for {
+		select {
+		case <-w.Triggers():
+			n++
+		case <-timeout:
+			return n
+		}
+	}
+}
+
+func TestHeadWatcher_DebouncesRepeatedHeads(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	node := &fakeHeadNode{}
+	w := newHeadWatcher(node.dial, 20*time.Millisecond)
+	go w.run(ctx)
+	waitFor(t, "subscription", w.Subscribed)
+
+	// 同一高度重复推送、连续多个新块在合并窗口内只触发一次
+	for _, n := range []int64{100, 100, 101, 101, 102} {
+		node.push(n)
+	}
+	if got := countTriggers(w, 100*time.Millisecond); got != 1 {
+		t.Fatalf("expected 1 trigger for a burst of heads, got %d", got)
+	}
+
+	node.push(102)
+	if got := countTriggers(w, 60*time.Millisecond); got != 0 {
+		t.Fatalf("repeated head must not trigger, got %d", got)
+	}
+
+	node.push(103)
+	if got := countTriggers(w, 60*time.Millisecond); got != 1 {
+		t.Fatalf("expected new head to trigger, got %d", got)
+	}
+}
+
+func TestHeadWatcher_FallsBackAndResubscribes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	node := &fakeHeadNode{}
+	w := newHeadWatcher(node.dial, time.Millisecond)
+	go w.run(ctx)
+	waitFor(t, "subscription", w.Subscribed)
+
+	node.disconnect()
+	waitFor(t, "fallback to polling", func() bool { return !w.Subscribed() })
+
+	waitFor(t, "resubscription", w.Subscribed)
+	node.mu.Lock()
+	dials := node.dials
+	node.mu.Unlock()
+	if dials != 2 {
+		t.Fatalf("expected 2 dials, got %d", dials)
+	}
+
+	node.push(200)
+	if got := countTriggers(w, 50*time.Millisecond); got != 1 {
+		t.Fatalf("expected trigger after resubscribe, got %d", got)
+	}
+}
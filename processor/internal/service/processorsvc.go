@@ -1,8 +1,11 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -11,6 +14,9 @@ import (
 	"go_bullayer_v1/base/pkg/logger"
 	"go_bullayer_v1/processor/internal/config"
 	"go_bullayer_v1/processor/internal/processor"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
 )
 
 // ProcessorService 数据处理服务
@@ -20,7 +26,11 @@ type ProcessorService struct {
 	cancel     context.CancelFunc
 	config     config.Config
 	db         *sql.DB
+	chain      eth.Client
+	sender     *ethclient.Client // 提现广播节点，未启用提现时为空
 	processors []processor.Processor
+	heads      *headWatcher        // 未配置 Chain.WSURL 时为空
+	headDriven processor.Processor // 由新区块触发的处理任务
 	wg         sync.WaitGroup
 	mu         sync.Mutex
 }
@@ -34,20 +44,33 @@ func NewProcessorService(ctx context.Context, cfg config.Config) *ProcessorServi
 		config:     cfg,
 		processors: make([]processor.Processor, 0),
 	}
-	svc.initDB()
 	svc.initETHClient()
 	return svc
 }
 
 // Start 启动数据处理服务
-func (s *ProcessorService) Start() {
+// 任一已启用的处理任务初始化失败时返回错误，调用方应终止进程
+func (s *ProcessorService) Start() error {
 	if !s.config.ProcessorEnabled {
 		logger.Info("数据处理服务未启用")
-		return
+		return nil
 	}
 
 	logger.Info("开始启动数据处理服务...")
-	s.registerProcessors()
+	if err := s.initDB(); err != nil {
+		return err
+	}
+	if err := s.registerProcessors(); err != nil {
+		return err
+	}
+
+	if s.heads != nil {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.heads.run(s.ctx)
+		}()
+	}
 
 	for _, p := range s.processors {
 		s.wg.Add(1)
@@ -55,6 +78,7 @@ func (s *ProcessorService) Start() {
 	}
 
 	logger.Info("数据处理服务启动完成，共 %d 个处理任务", len(s.processors))
+	return nil
 }
 
 // Stop 停止数据处理服务
@@ -63,21 +87,29 @@ func (s *ProcessorService) Stop() {
 	s.cancel()
 	s.wg.Wait()
 
-	if s.db != nil {
-		if err := s.db.Close(); err != nil {
-			logger.Error("关闭数据库连接失败: %v", err)
-		} else {
-			logger.Info("数据库连接已关闭")
-		}
+	if s.sender != nil {
+		s.sender.Close()
 	}
-
+	s.closeDB()
 	logger.Info("数据处理服务已停止")
 }
 
-func (s *ProcessorService) initDB() {
+// closeDB 关闭数据库连接
+func (s *ProcessorService) closeDB() {
+	if s.db == nil {
+		return
+	}
+	if err := s.db.Close(); err != nil {
+		logger.Error("关闭数据库连接失败: %v", err)
+	} else {
+		logger.Info("数据库连接已关闭")
+	}
+}
+
+func (s *ProcessorService) initDB() error {
 	if s.config.Database.Host == "" {
 		logger.Info("未配置数据库连接，跳过数据库初始化")
-		return
+		return nil
 	}
 
 	dbConfig := db.DBConfig{
@@ -90,38 +122,152 @@ func (s *ProcessorService) initDB() {
 
 	database, err := db.NewDB(dbConfig)
 	if err != nil {
-		logger.Error("数据库连接初始化失败: %v", err)
-		return
+		// 已配置数据库时不能退化为内存模式，否则游标和处理结果都不会持久化
+		return fmt.Errorf("数据库连接初始化失败: %w", err)
 	}
 
 	s.db = database
 	logger.Info("数据库连接初始化成功")
+	return nil
 }
 
 func (s *ProcessorService) initETHClient() {
-	if err := eth.InitClient(s.config.Chain.RPCURL); err != nil {
-		logger.Error("ETH客户端初始化失败，将使用降级数据源: %v", err)
+	if s.config.Chain.Mode == config.ChainModeSimulate {
+		s.initSimulatedChain()
+		return
+	}
+
+	urls := append([]string{s.config.Chain.RPCURL}, s.config.Chain.RPCURLs...)
+	pool, err := eth.DialPool(urls...)
+	if err != nil {
+		logger.Error("ETH客户端初始化失败: %v", err)
+		return
+	}
+	s.chain = pool
+	logger.Info("ETH客户端初始化成功，RPC 节点 %d 个", len(pool.Health()))
+}
+
+// initSimulatedChain 使用本地确定性模拟链代替 RPC 节点，仅用于开发和演示
+// 模拟转账的接收方取自 TargetAddresses，ERC20 合约取自 TokenContracts
+func (s *ProcessorService) initSimulatedChain() {
+	sim := s.config.Chain.Simulation
+	simCfg := eth.SimulationConfig{
+		ChainID:       s.config.Chain.ChainID,
+		Seed:          sim.Seed,
+		Genesis:       uint64(max(s.config.Chain.StartHeight, 0)),
+		BlocksPerPoll: sim.BlocksPerPoll,
+		TxsPerBlock:   sim.TxsPerBlock,
+	}
+	for _, addr := range s.config.BlockProcessor.TargetAddresses {
+		if common.IsHexAddress(addr) {
+			simCfg.Recipients = append(simCfg.Recipients, common.HexToAddress(addr))
+		}
+	}
+	for addr := range s.config.BlockProcessor.TokenContracts {
+		if common.IsHexAddress(addr) {
+			simCfg.Tokens = append(simCfg.Tokens, common.HexToAddress(addr))
+		}
+	}
+	// map 遍历顺序不固定，排序后保证相同配置生成相同的链
+	sort.Slice(simCfg.Tokens, func(i, j int) bool {
+		return bytes.Compare(simCfg.Tokens[i].Bytes(), simCfg.Tokens[j].Bytes()) < 0
+	})
+
+	chain, err := eth.NewSimulatedChain(simCfg)
+	if err != nil {
+		logger.Error("模拟链初始化失败: %v", err)
+		return
+	}
+	s.chain = chain
+	logger.Info("已启用模拟链模式（种子 %d），区块数据均为本地生成，不连接任何 RPC 节点", sim.Seed)
+}
+
+// initHeadWatcher 配置了 Chain.WSURL 时由 newHeads 订阅触发区块处理，模拟链模式下不订阅
+func (s *ProcessorService) initHeadWatcher(p processor.Processor) {
+	wsURL := s.config.Chain.WSURL
+	if wsURL == "" || s.config.Chain.Mode == config.ChainModeSimulate {
 		return
 	}
-	logger.Info("ETH客户端初始化成功")
+
+	debounce := time.Duration(s.config.Chain.HeadDebounce) * time.Millisecond
+	s.heads = newHeadWatcher(func(ctx context.Context) (eth.HeadSubscriber, error) {
+		return eth.DialHeadSubscriber(ctx, wsURL)
+	}, debounce)
+	s.headDriven = p
+	logger.Info("已启用 newHeads 订阅，合并窗口 %v", debounce)
 }
 
 // registerProcessors 注册所有处理任务
-func (s *ProcessorService) registerProcessors() {
+func (s *ProcessorService) registerProcessors() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	if s.config.BlockProcessor.Enabled {
-		blockProcessor := processor.NewBlockProcessor(s.config)
+		blockProcessor, err := processor.NewBlockProcessor(s.ctx, s.config, s.db, s.chain)
+		if err != nil {
+			return fmt.Errorf("区块追踪解析任务初始化失败: %w", err)
+		}
 		s.processors = append(s.processors, blockProcessor)
 		logger.Info("已注册区块追踪解析任务")
+		s.initHeadWatcher(blockProcessor)
+	}
+
+	if s.config.DepositAddress.Enabled {
+		addressProcessor, err := processor.NewDepositAddressProcessor(s.config, s.db)
+		if err != nil {
+			return fmt.Errorf("充值地址分配任务初始化失败: %w", err)
+		}
+		s.processors = append(s.processors, addressProcessor)
+		logger.Info("已注册充值地址分配任务")
+	}
+
+	if s.config.Withdrawal.Enabled {
+		withdrawalProcessor, err := s.newWithdrawalProcessor()
+		if err != nil {
+			return fmt.Errorf("提现执行任务初始化失败: %w", err)
+		}
+		s.processors = append(s.processors, withdrawalProcessor)
+		logger.Info("已注册提现执行任务")
+	}
+	return nil
+}
+
+// newWithdrawalProcessor 连接广播节点并创建提现执行任务，模拟链模式不支持提现
+func (s *ProcessorService) newWithdrawalProcessor() (*processor.WithdrawalProcessor, error) {
+	if s.config.Chain.Mode == config.ChainModeSimulate {
+		return nil, fmt.Errorf("Chain.Mode=%s 不支持提现", config.ChainModeSimulate)
+	}
+	rpcURL := s.config.Withdrawal.RPCURL
+	if rpcURL == "" {
+		rpcURL = s.config.Chain.RPCURL
+	}
+	if rpcURL == "" && len(s.config.Chain.RPCURLs) > 0 {
+		rpcURL = s.config.Chain.RPCURLs[0]
+	}
+
+	sender, err := eth.DialSender(s.ctx, rpcURL)
+	if err != nil {
+		return nil, err
+	}
+	p, err := processor.NewWithdrawalProcessor(s.ctx, s.config, s.db, s.chain, sender)
+	if err != nil {
+		sender.Close()
+		return nil, err
 	}
+	s.sender = sender
+	return p, nil
 }
 
 // runProcessor 循环执行单个处理任务
+// 由新区块触发的任务在订阅可用时跳过定时轮询，订阅断开期间按 Interval 轮询
 func (s *ProcessorService) runProcessor(p processor.Processor) {
 	defer s.wg.Done()
 
+	var heads <-chan struct{}
+	if s.heads != nil && p == s.headDriven {
+		heads = s.heads.Triggers()
+	}
+
 	interval := s.config.Interval
 	if interval <= 0 {
 		interval = 10
@@ -135,6 +281,11 @@ func (s *ProcessorService) runProcessor(p processor.Processor) {
 	for {
 		select {
 		case <-ticker.C:
+			if heads != nil && s.heads.Subscribed() {
+				continue
+			}
+			s.executeProcessor(p)
+		case <-heads:
 			s.executeProcessor(p)
 		case <-s.ctx.Done():
 			logger.Info("处理任务 %s 收到停止信号", p.Name())
@@ -0,0 +1,21 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"go_bullayer_v1/processor/internal/config"
+)
+
+func TestProcessorService_StartFailsWhenDatabaseUnreachable(t *testing.T) {
+	var cfg config.Config
+	cfg.ProcessorEnabled = true
+	cfg.Database.Host = "127.0.0.1"
+	cfg.Database.Port = 1 // 无服务监听的端口
+
+	svc := NewProcessorService(context.Background(), cfg)
+	defer svc.Stop()
+	if err := svc.Start(); err == nil {
+		t.Fatal("expected start to fail when configured database is unreachable")
+	}
+}
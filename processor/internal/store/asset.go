@@ -0,0 +1,170 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"go_bullayer_v1/base/pkg/common"
+	"go_bullayer_v1/base/pkg/db"
+)
+
+// CreditableDeposit 达到确认数、等待入账的充值记录
+type CreditableDeposit struct {
+	ID        int64
+	AccountID int64
+	Coin      string
+	Amount    string
+}
+
+// ListCreditableDeposits 查询链 chainID 上确认数达到 required 且尚未入账的充值
+func ListCreditableDeposits(ctx context.Context, q db.Querier, chainID, required int64, limit int) ([]CreditableDeposit, error) {
+	rows, err := q.QueryContext(ctx,
+		`SELECT id, account_id, coin, amount FROM transactions
+		WHERE chain_id = ? AND tx_type = ? AND status = ? AND confirmations >= ? ORDER BY id LIMIT ?`,
+		chainID, common.TxTypeDeposit, common.TxStatusPending, required, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("查询待入账充值失败: %w", err)
+	}
+	defer rows.Close()
+
+	deposits := make([]CreditableDeposit, 0)
+	for rows.Next() {
+		var d CreditableDeposit
+		if err := rows.Scan(&d.ID, &d.AccountID, &d.Coin, &d.Amount); err != nil {
+			return nil, fmt.Errorf("读取待入账充值失败: %w", err)
+		}
+		deposits = append(deposits, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历待入账充值失败: %w", err)
+	}
+	return deposits, nil
+}
+
+// CreditDeposit 将充值标记为成功并增加用户资产，必须在事务内调用
+// 只有状态从待确认切换为成功的那一次才会入账，重复调用返回 false，保证恰好入账一次
+func CreditDeposit(ctx context.Context, q db.Querier, d CreditableDeposit) (bool, error) {
+	res, err := q.ExecContext(ctx,
+		"UPDATE transactions SET status = ? WHERE id = ? AND tx_type = ? AND status = ?",
+		common.TxStatusSuccess, d.ID, common.TxTypeDeposit, common.TxStatusPending,
+	)
+	if err != nil {
+		return false, fmt.Errorf("更新充值状态失败: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("读取充值状态更新行数失败: %w", err)
+	}
+	if n == 0 {
+		return false, nil
+	}
+
+	// 金额以字符串传入，由 MySQL 按 DECIMAL 精确计算
+	_, err = q.ExecContext(ctx,
+		`INSERT INTO user_assets (account_id, coin, total, available) VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE total = total + VALUES(total), available = available + VALUES(available)`,
+		d.AccountID, d.Coin, d.Amount, d.Amount,
+	)
+	if err != nil {
+		return false, fmt.Errorf("增加用户资产失败: %w", err)
+	}
+	return true, nil
+}
+
+// AssetShortfall 链重组扣回充值时可用余额不足的差额，用户已提现或冻结了孤块中的充值
+type AssetShortfall struct {
+	AccountID     int64
+	Coin          string
+	TransactionID int64  // 被扣回的充值记录
+	Amount        string // 未能扣回的金额
+}
+
+// DebitCreditedDepositsAbove 扣回链 chainID 上 height 以上区块中已入账的充值，链重组回滚时在同一事务内调用。
+// 扣回金额以可用余额为限，不会让 available/total 变为负数；不足部分写入 asset_shortfalls 待人工处理并返回。
+// 返回被扣回的充值笔数
+func DebitCreditedDepositsAbove(ctx context.Context, q db.Querier, chainID, height int64) (int64, []AssetShortfall, error) {
+	rows, err := q.QueryContext(ctx,
+		`SELECT id, account_id, coin, amount FROM transactions
+		WHERE chain_id = ? AND tx_type = ? AND status = ? AND block_number > ? FOR UPDATE`,
+		chainID, common.TxTypeDeposit, common.TxStatusSuccess, height,
+	)
+	if err != nil {
+		return 0, nil, fmt.Errorf("查询已入账孤块充值失败: %w", err)
+	}
+
+	credited := make([]CreditableDeposit, 0)
+	for rows.Next() {
+		var d CreditableDeposit
+		if err := rows.Scan(&d.ID, &d.AccountID, &d.Coin, &d.Amount); err != nil {
+			rows.Close()
+			return 0, nil, fmt.Errorf("读取已入账孤块充值失败: %w", err)
+		}
+		credited = append(credited, d)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return 0, nil, fmt.Errorf("遍历已入账孤块充值失败: %w", err)
+	}
+	rows.Close()
+
+	var shortfalls []AssetShortfall
+	for _, d := range credited {
+		shortfall, err := debitDeposit(ctx, q, d)
+		if err != nil {
+			return 0, nil, err
+		}
+		if shortfall == "" {
+			continue
+		}
+		s := AssetShortfall{AccountID: d.AccountID, Coin: d.Coin, TransactionID: d.ID, Amount: shortfall}
+		_, err = q.ExecContext(ctx,
+			"INSERT INTO asset_shortfalls (account_id, coin, transaction_id, amount) VALUES (?, ?, ?, ?)",
+			s.AccountID, s.Coin, s.TransactionID, s.Amount,
+		)
+		if err != nil {
+			return 0, nil, fmt.Errorf("记录资产缺口失败: %w", err)
+		}
+		shortfalls = append(shortfalls, s)
+	}
+	return int64(len(credited)), shortfalls, nil
+}
+
+// debitDeposit 从可用余额中扣回一笔充值，返回未能扣回的金额，全部扣回时为空
+func debitDeposit(ctx context.Context, q db.Querier, d CreditableDeposit) (string, error) {
+	// 金额参数显式转换为 DECIMAL，避免字符串参与运算时按 DOUBLE 计算或按字符串比较
+	var shortfall string
+	err := q.QueryRowContext(ctx,
+		"SELECT GREATEST(CAST(? AS DECIMAL(36,18)) - available, 0) FROM user_assets WHERE account_id = ? AND coin = ? FOR UPDATE",
+		d.Amount, d.AccountID, d.Coin,
+	).Scan(&shortfall)
+	if errors.Is(err, sql.ErrNoRows) {
+		return d.Amount, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("查询用户可用资产失败: %w", err)
+	}
+
+	// MySQL 按从左到右的顺序赋值，total 使用扣减前的 available
+	_, err = q.ExecContext(ctx,
+		`UPDATE user_assets SET total = total - LEAST(available, CAST(? AS DECIMAL(36,18))),
+		available = available - LEAST(available, CAST(? AS DECIMAL(36,18)))
+		WHERE account_id = ? AND coin = ?`,
+		d.Amount, d.Amount, d.AccountID, d.Coin,
+	)
+	if err != nil {
+		return "", fmt.Errorf("扣回用户资产失败: %w", err)
+	}
+
+	r, ok := new(big.Rat).SetString(shortfall)
+	if !ok {
+		return "", fmt.Errorf("资产缺口金额无效: %s", shortfall)
+	}
+	if r.Sign() == 0 {
+		return "", nil
+	}
+	return shortfall, nil
+}
@@ -0,0 +1,135 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"go_bullayer_v1/base/pkg/db"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCreditDeposit_ExactlyOnce(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock failed: %v", err)
+	}
+	defer conn.Close()
+
+	d := CreditableDeposit{ID: 42, AccountID: 7, Coin: "USDT", Amount: "10.500000000000000000"}
+
+	// 首次入账：状态切换成功后增加资产
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE transactions SET status").
+		WithArgs(1, int64(42), 1, 0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO user_assets").
+		WithArgs(int64(7), "USDT", d.Amount, d.Amount).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	// 重复入账：状态已不是待确认，不再增加资产
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE transactions SET status").
+		WithArgs(1, int64(42), 1, 0).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	for i, want := range []bool{true, false} {
+		var credited bool
+		err := db.WithTx(context.Background(), conn, func(tx *sql.Tx) error {
+			var err error
+			credited, err = CreditDeposit(context.Background(), tx, d)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("credit #%d failed: %v", i, err)
+		}
+		if credited != want {
+			t.Fatalf("credit #%d: expected credited=%v, got %v", i, want, credited)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDebitCreditedDepositsAbove_RecordsShortfall(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock failed: %v", err)
+	}
+	defer conn.Close()
+
+	mock.ExpectQuery("SELECT id, account_id, coin, amount FROM transactions").
+		WithArgs(int64(11155111), 1, 1, int64(10)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "coin", "amount"}).
+			AddRow(int64(5), int64(7), "USDT", "10.000000000000000000").
+			AddRow(int64(6), int64(8), "USDT", "2.000000000000000000"))
+	// 账户 7 已提现部分充值，可用余额只剩 4
+	mock.ExpectQuery("SELECT GREATEST").
+		WithArgs("10.000000000000000000", int64(7), "USDT").
+		WillReturnRows(sqlmock.NewRows([]string{"shortfall"}).AddRow("6.000000000000000000"))
+	mock.ExpectExec("UPDATE user_assets SET total = total - LEAST").
+		WithArgs("10.000000000000000000", "10.000000000000000000", int64(7), "USDT").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO asset_shortfalls").
+		WithArgs(int64(7), "USDT", int64(5), "6.000000000000000000").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	// 账户 8 没有资产记录，全部记为缺口
+	mock.ExpectQuery("SELECT GREATEST").
+		WithArgs("2.000000000000000000", int64(8), "USDT").
+		WillReturnRows(sqlmock.NewRows([]string{"shortfall"}))
+	mock.ExpectExec("INSERT INTO asset_shortfalls").
+		WithArgs(int64(8), "USDT", int64(6), "2.000000000000000000").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+
+	debited, shortfalls, err := DebitCreditedDepositsAbove(context.Background(), conn, 11155111, 10)
+	if err != nil {
+		t.Fatalf("debit failed: %v", err)
+	}
+	if debited != 2 || len(shortfalls) != 2 || shortfalls[0].Amount != "6.000000000000000000" {
+		t.Fatalf("unexpected result: debited %d, shortfalls %+v", debited, shortfalls)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDebitCreditedDepositsAbove_KeepsFullPrecision(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock failed: %v", err)
+	}
+	defer conn.Close()
+
+	// 18 位小数的金额必须以 DECIMAL 参与运算，按 DOUBLE 计算会丢失末尾精度
+	amount := "1.123456789012345678"
+	mock.ExpectQuery("SELECT id, account_id, coin, amount FROM transactions").
+		WithArgs(int64(11155111), 1, 1, int64(10)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "coin", "amount"}).
+			AddRow(int64(5), int64(7), "ETH", amount))
+	mock.ExpectQuery("SELECT GREATEST\\(CAST\\(\\? AS DECIMAL\\(36,18\\)\\) - available, 0\\)").
+		WithArgs(amount, int64(7), "ETH").
+		WillReturnRows(sqlmock.NewRows([]string{"shortfall"}).AddRow("0.000000000000000001"))
+	mock.ExpectExec("UPDATE user_assets SET total = total - LEAST\\(available, CAST\\(\\? AS DECIMAL\\(36,18\\)\\)\\),\\s+"+
+		"available = available - LEAST\\(available, CAST\\(\\? AS DECIMAL\\(36,18\\)\\)\\)").
+		WithArgs(amount, amount, int64(7), "ETH").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO asset_shortfalls").
+		WithArgs(int64(7), "ETH", int64(5), "0.000000000000000001").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	_, shortfalls, err := DebitCreditedDepositsAbove(context.Background(), conn, 11155111, 10)
+	if err != nil {
+		t.Fatalf("debit failed: %v", err)
+	}
+	if len(shortfalls) != 1 || shortfalls[0].Amount != "0.000000000000000001" {
+		t.Fatalf("unexpected shortfalls %+v", shortfalls)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
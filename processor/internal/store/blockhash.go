@@ -0,0 +1,78 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"go_bullayer_v1/base/pkg/db"
+)
+
+// BlockHash 已处理区块的哈希记录，用于检测链重组
+type BlockHash struct {
+	Height     int64
+	Hash       string
+	ParentHash string
+}
+
+// LoadBlockHashes 按高度升序读取 fromHeight 及以上的区块哈希
+func LoadBlockHashes(ctx context.Context, q db.Querier, chainID int64, fromHeight int64) ([]BlockHash, error) {
+	rows, err := q.QueryContext(ctx,
+		`SELECT height, block_hash, parent_hash FROM block_hashes
+		WHERE chain_id = ? AND height >= ? ORDER BY height`,
+		chainID, fromHeight,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("查询区块哈希失败: %w", err)
+	}
+	defer rows.Close()
+
+	hashes := make([]BlockHash, 0)
+	for rows.Next() {
+		var h BlockHash
+		if err := rows.Scan(&h.Height, &h.Hash, &h.ParentHash); err != nil {
+			return nil, fmt.Errorf("读取区块哈希失败: %w", err)
+		}
+		hashes = append(hashes, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历区块哈希失败: %w", err)
+	}
+	return hashes, nil
+}
+
+// SaveBlockHash 写入已处理区块的哈希，重复处理同一高度时覆盖
+func SaveBlockHash(ctx context.Context, q db.Querier, chainID int64, h BlockHash) error {
+	_, err := q.ExecContext(ctx,
+		`INSERT INTO block_hashes (chain_id, height, block_hash, parent_hash) VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE block_hash = VALUES(block_hash), parent_hash = VALUES(parent_hash)`,
+		chainID, h.Height, h.Hash, h.ParentHash,
+	)
+	if err != nil {
+		return fmt.Errorf("写入区块哈希失败: %w", err)
+	}
+	return nil
+}
+
+// PruneBlockHashes 删除 belowHeight 以下的区块哈希，控制窗口大小
+func PruneBlockHashes(ctx context.Context, q db.Querier, chainID int64, belowHeight int64) error {
+	_, err := q.ExecContext(ctx,
+		"DELETE FROM block_hashes WHERE chain_id = ? AND height < ?",
+		chainID, belowHeight,
+	)
+	if err != nil {
+		return fmt.Errorf("清理区块哈希失败: %w", err)
+	}
+	return nil
+}
+
+// DeleteBlockHashesAbove 删除 height 以上的区块哈希，链重组回滚时使用
+func DeleteBlockHashesAbove(ctx context.Context, q db.Querier, chainID int64, height int64) error {
+	_, err := q.ExecContext(ctx,
+		"DELETE FROM block_hashes WHERE chain_id = ? AND height > ?",
+		chainID, height,
+	)
+	if err != nil {
+		return fmt.Errorf("删除孤块哈希失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"go_bullayer_v1/base/pkg/db"
+)
+
+// CoinConfig coin_configs 表中的币种配置
+type CoinConfig struct {
+	Coin        string
+	CoinAddress string // 原生币为空
+	MinDeposit  string
+	Status      int
+}
+
+// LoadCoinConfigs 加载全部币种配置
+func LoadCoinConfigs(ctx context.Context, q db.Querier) ([]CoinConfig, error) {
+	rows, err := q.QueryContext(ctx, "SELECT coin, coin_address, min_deposit, status FROM coin_configs")
+	if err != nil {
+		return nil, fmt.Errorf("查询币种配置失败: %w", err)
+	}
+	defer rows.Close()
+
+	configs := make([]CoinConfig, 0)
+	for rows.Next() {
+		var (
+			c           CoinConfig
+			coinAddress sql.NullString
+			status      sql.NullInt64
+		)
+		if err := rows.Scan(&c.Coin, &coinAddress, &c.MinDeposit, &status); err != nil {
+			return nil, fmt.Errorf("读取币种配置失败: %w", err)
+		}
+		c.CoinAddress = coinAddress.String
+		c.Status = int(status.Int64)
+		configs = append(configs, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历币种配置失败: %w", err)
+	}
+	return configs, nil
+}
@@ -0,0 +1,44 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"go_bullayer_v1/base/pkg/db"
+)
+
+// ContractEvent 按 ABI 解码后的合约事件，对应 contract_events 表
+type ContractEvent struct {
+	ChainID         int64
+	TxHash          string
+	LogIndex        int
+	BlockNumber     int64
+	ContractAddress string
+	EventName       string
+	Signature       string
+	Args            []byte // JSON 编码的具名参数
+}
+
+// SaveContractEvent 按 (chain_id, tx_hash, log_index) 幂等写入合约事件，重复扫描时更新所在区块和参数
+func SaveContractEvent(ctx context.Context, q db.Querier, e ContractEvent) error {
+	_, err := q.ExecContext(ctx,
+		`INSERT INTO contract_events
+		(chain_id, tx_hash, log_index, block_number, contract_address, event_name, signature, args)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE block_number = VALUES(block_number), args = VALUES(args)`,
+		e.ChainID, e.TxHash, e.LogIndex, e.BlockNumber, e.ContractAddress, e.EventName, e.Signature, string(e.Args),
+	)
+	if err != nil {
+		return fmt.Errorf("写入合约事件失败: %w", err)
+	}
+	return nil
+}
+
+// DeleteContractEventsAbove 删除链 chainID 上 height 以上区块的合约事件，链重组回滚时使用
+func DeleteContractEventsAbove(ctx context.Context, q db.Querier, chainID, height int64) (int64, error) {
+	res, err := q.ExecContext(ctx, "DELETE FROM contract_events WHERE chain_id = ? AND block_number > ?", chainID, height)
+	if err != nil {
+		return 0, fmt.Errorf("删除孤块合约事件失败: %w", err)
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,48 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"go_bullayer_v1/base/pkg/db"
+)
+
+// BlockCursor 区块处理游标，记录最后一个完整处理的区块。
+type BlockCursor struct {
+	ChainID   int64
+	Name      string
+	Height    int64
+	BlockHash string
+}
+
+// LoadCursor 读取指定链和名称的游标，不存在时 found 返回 false。
+func LoadCursor(ctx context.Context, q db.Querier, chainID int64, name string) (cursor BlockCursor, found bool, err error) {
+	row := q.QueryRowContext(ctx,
+		"SELECT height, block_hash FROM block_cursors WHERE chain_id = ? AND name = ?",
+		chainID, name,
+	)
+
+	cursor = BlockCursor{ChainID: chainID, Name: name}
+	if err := row.Scan(&cursor.Height, &cursor.BlockHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return BlockCursor{}, false, nil
+		}
+		return BlockCursor{}, false, fmt.Errorf("查询区块游标失败: %w", err)
+	}
+	return cursor, true, nil
+}
+
+// SaveCursor 写入或推进游标，通常在区块结果所在的事务内调用。
+func SaveCursor(ctx context.Context, q db.Querier, cursor BlockCursor) error {
+	_, err := q.ExecContext(ctx,
+		`INSERT INTO block_cursors (chain_id, name, height, block_hash) VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE height = VALUES(height), block_hash = VALUES(block_hash)`,
+		cursor.ChainID, cursor.Name, cursor.Height, cursor.BlockHash,
+	)
+	if err != nil {
+		return fmt.Errorf("更新区块游标失败: %w", err)
+	}
+	return nil
+}
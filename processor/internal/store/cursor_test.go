@@ -0,0 +1,82 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"go_bullayer_v1/base/pkg/db"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestLoadCursor(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock failed: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT height, block_hash FROM block_cursors").
+		WithArgs(int64(11155111), "block_processor").
+		WillReturnRows(sqlmock.NewRows([]string{"height", "block_hash"}).AddRow(int64(100), "0xabc"))
+
+	cursor, found, err := LoadCursor(context.Background(), db, 11155111, "block_processor")
+	if err != nil {
+		t.Fatalf("load cursor failed: %v", err)
+	}
+	if !found || cursor.Height != 100 || cursor.BlockHash != "0xabc" {
+		t.Fatalf("unexpected cursor: found=%v %+v", found, cursor)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadCursor_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock failed: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT height, block_hash FROM block_cursors").
+		WillReturnError(sql.ErrNoRows)
+
+	_, found, err := LoadCursor(context.Background(), db, 1, "block_processor")
+	if err != nil {
+		t.Fatalf("expected no error for missing cursor, got %v", err)
+	}
+	if found {
+		t.Fatal("expected cursor not found")
+	}
+}
+
+func TestSaveCursor_InTx(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock failed: %v", err)
+	}
+	defer conn.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO block_cursors").
+		WithArgs(int64(1), "block_processor", int64(101), "0xdef").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err = db.WithTx(context.Background(), conn, func(tx *sql.Tx) error {
+		return SaveCursor(context.Background(), tx, BlockCursor{
+			ChainID:   1,
+			Name:      "block_processor",
+			Height:    101,
+			BlockHash: "0xdef",
+		})
+	})
+	if err != nil {
+		t.Fatalf("save cursor failed: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
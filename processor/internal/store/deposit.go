@@ -0,0 +1,77 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"go_bullayer_v1/base/pkg/common"
+	"go_bullayer_v1/base/pkg/db"
+)
+
+// Deposit 待写入 transactions 表的充值记录
+type Deposit struct {
+	ChainID     int64
+	TxHash      string
+	EventIndex  int // ERC20 为日志序号，原生 ETH 为 -1
+	BlockNumber int64
+	AccountID   int64
+	Coin        string
+	CoinAddress string // 原生 ETH 为空
+	Amount      string // 已按精度换算的十进制金额
+	From        string
+	To          string
+}
+
+// FindAccountIDByAddress 按钱包地址查询账户ID，不存在时 found 返回 false
+func FindAccountIDByAddress(ctx context.Context, q db.Querier, address string) (accountID int64, found bool, err error) {
+	row := q.QueryRowContext(ctx, "SELECT account_id FROM accounts WHERE address = ?", address)
+	if err := row.Scan(&accountID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("查询账户失败: %w", err)
+	}
+	return accountID, true, nil
+}
+
+// UpsertDeposit 幂等写入充值记录
+// 以 (chain_id, tx_hash, event_index) 去重，重试和重复扫描不会产生重复记录；
+// 已回滚的记录被重新打包进主链时恢复为待确认，并更新所在区块
+func UpsertDeposit(ctx context.Context, q db.Querier, d Deposit) error {
+	var coinAddress interface{}
+	if d.CoinAddress != "" {
+		coinAddress = d.CoinAddress
+	}
+
+	_, err := q.ExecContext(ctx,
+		`INSERT INTO transactions
+		(chain_id, block_number, tx_hash, event_index, tx_type, account_id, coin, coin_address, amount, from_address, to_address, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+		block_number = IF(status = ?, VALUES(block_number), block_number),
+		confirmations = IF(status = ?, 0, confirmations),
+		status = IF(status = ?, VALUES(status), status)`,
+		d.ChainID, d.BlockNumber, d.TxHash, d.EventIndex, common.TxTypeDeposit, d.AccountID, d.Coin, coinAddress,
+		d.Amount, d.From, d.To, common.TxStatusPending,
+		common.TxStatusReverted, common.TxStatusReverted, common.TxStatusReverted,
+	)
+	if err != nil {
+		return fmt.Errorf("写入充值记录失败: %w", err)
+	}
+	return nil
+}
+
+// UpdateDepositConfirmations 按链 chainID 的最新高度刷新该链待确认充值的确认数
+func UpdateDepositConfirmations(ctx context.Context, q db.Querier, chainID, latestHeight int64) error {
+	_, err := q.ExecContext(ctx,
+		`UPDATE transactions SET confirmations = ? - block_number + 1
+		WHERE chain_id = ? AND tx_type = ? AND status = ? AND block_number <= ?`,
+		latestHeight, chainID, common.TxTypeDeposit, common.TxStatusPending, latestHeight,
+	)
+	if err != nil {
+		return fmt.Errorf("更新充值确认数失败: %w", err)
+	}
+	return nil
+}
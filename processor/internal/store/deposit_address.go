@@ -0,0 +1,93 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"go_bullayer_v1/base/pkg/db"
+)
+
+// DepositAddress 账户专属充值地址，由 xpub 按 derivation_index 派生
+type DepositAddress struct {
+	ID              int64
+	AccountID       int64
+	Address         string
+	DerivationIndex uint32
+}
+
+// LoadDepositAddresses 按 id 递增加载 afterID 之后新分配的充值地址，用于增量刷新
+func LoadDepositAddresses(ctx context.Context, q db.Querier, afterID int64) ([]DepositAddress, error) {
+	rows, err := q.QueryContext(ctx,
+		`SELECT id, account_id, address, derivation_index FROM deposit_addresses
+		WHERE id > ? ORDER BY id`,
+		afterID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("查询充值地址失败: %w", err)
+	}
+	defer rows.Close()
+
+	addresses := make([]DepositAddress, 0)
+	for rows.Next() {
+		var a DepositAddress
+		if err := rows.Scan(&a.ID, &a.AccountID, &a.Address, &a.DerivationIndex); err != nil {
+			return nil, fmt.Errorf("读取充值地址失败: %w", err)
+		}
+		addresses = append(addresses, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历充值地址失败: %w", err)
+	}
+	return addresses, nil
+}
+
+// ListAccountsWithoutDepositAddress 查询尚未分配充值地址的账户
+func ListAccountsWithoutDepositAddress(ctx context.Context, q db.Querier, limit int) ([]int64, error) {
+	rows, err := q.QueryContext(ctx,
+		`SELECT a.account_id FROM accounts a
+		LEFT JOIN deposit_addresses d ON d.account_id = a.account_id
+		WHERE d.id IS NULL ORDER BY a.account_id LIMIT ?`,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("查询待分配充值地址账户失败: %w", err)
+	}
+	defer rows.Close()
+
+	accountIDs := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("读取待分配充值地址账户失败: %w", err)
+		}
+		accountIDs = append(accountIDs, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历待分配充值地址账户失败: %w", err)
+	}
+	return accountIDs, nil
+}
+
+// NextDerivationIndex 返回下一个可用的派生序号，必须在事务内调用
+// 通过 FOR UPDATE 锁定当前最大序号，避免并发分配出重复地址
+func NextDerivationIndex(ctx context.Context, q db.Querier) (uint32, error) {
+	var next uint32
+	row := q.QueryRowContext(ctx,
+		"SELECT COALESCE(MAX(derivation_index) + 1, 0) FROM deposit_addresses FOR UPDATE")
+	if err := row.Scan(&next); err != nil {
+		return 0, fmt.Errorf("查询派生序号失败: %w", err)
+	}
+	return next, nil
+}
+
+// InsertDepositAddress 写入账户充值地址
+func InsertDepositAddress(ctx context.Context, q db.Querier, a DepositAddress) error {
+	_, err := q.ExecContext(ctx,
+		"INSERT INTO deposit_addresses (account_id, address, derivation_index) VALUES (?, ?, ?)",
+		a.AccountID, a.Address, a.DerivationIndex,
+	)
+	if err != nil {
+		return fmt.Errorf("写入充值地址失败: %w", err)
+	}
+	return nil
+}
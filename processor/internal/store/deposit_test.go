@@ -0,0 +1,108 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestFindAccountIDByAddress(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock failed: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT account_id FROM accounts WHERE address").
+		WithArgs("0xabc").
+		WillReturnRows(sqlmock.NewRows([]string{"account_id"}).AddRow(int64(7)))
+	mock.ExpectQuery("SELECT account_id FROM accounts WHERE address").
+		WithArgs("0xdef").
+		WillReturnError(sql.ErrNoRows)
+
+	id, found, err := FindAccountIDByAddress(context.Background(), db, "0xabc")
+	if err != nil || !found || id != 7 {
+		t.Fatalf("unexpected result: id=%d found=%v err=%v", id, found, err)
+	}
+	_, found, err = FindAccountIDByAddress(context.Background(), db, "0xdef")
+	if err != nil || found {
+		t.Fatalf("expected not found without error, got found=%v err=%v", found, err)
+	}
+}
+
+func TestUpsertDeposit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock failed: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("INSERT INTO transactions").
+		WithArgs(
+			int64(11155111), int64(100), "0xtx", 3, 1, int64(7), "USDT", "0xtoken", "12.5", "0xfrom", "0xto", 0,
+			3, 3, 3,
+		).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO transactions").
+		WithArgs(
+			int64(11155111), int64(101), "0xeth", -1, 1, int64(7), "ETH", nil, "1", "0xfrom", "0xto", 0,
+			3, 3, 3,
+		).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+
+	err = UpsertDeposit(context.Background(), db, Deposit{
+		ChainID:     11155111,
+		TxHash:      "0xtx",
+		EventIndex:  3,
+		BlockNumber: 100,
+		AccountID:   7,
+		Coin:        "USDT",
+		CoinAddress: "0xtoken",
+		Amount:      "12.5",
+		From:        "0xfrom",
+		To:          "0xto",
+	})
+	if err != nil {
+		t.Fatalf("upsert erc20 deposit failed: %v", err)
+	}
+
+	err = UpsertDeposit(context.Background(), db, Deposit{
+		ChainID:     11155111,
+		TxHash:      "0xeth",
+		EventIndex:  -1,
+		BlockNumber: 101,
+		AccountID:   7,
+		Coin:        "ETH",
+		Amount:      "1",
+		From:        "0xfrom",
+		To:          "0xto",
+	})
+	if err != nil {
+		t.Fatalf("upsert eth deposit failed: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdateDepositConfirmations(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock failed: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("UPDATE transactions SET confirmations").
+		WithArgs(int64(120), int64(11155111), 1, 0, int64(120)).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	if err := UpdateDepositConfirmations(context.Background(), db, 11155111, 120); err != nil {
+		t.Fatalf("update confirmations failed: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
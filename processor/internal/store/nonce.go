@@ -0,0 +1,47 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"go_bullayer_v1/base/pkg/db"
+)
+
+// ReserveNonce 在事务内为发送地址分配下一个 nonce。
+// 以 wallet_nonces 行锁串行化多个实例的分配；chainNonce 为节点返回的 pending nonce，
+// 地址在系统外发送过交易时以较大值为准，避免复用已上链的 nonce。q 必须是事务。
+func ReserveNonce(ctx context.Context, q db.Querier, chainID int64, address string, chainNonce uint64) (uint64, error) {
+	var next uint64
+	err := q.QueryRowContext(ctx,
+		"SELECT next_nonce FROM wallet_nonces WHERE chain_id = ? AND address = ? FOR UPDATE",
+		chainID, address,
+	).Scan(&next)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("查询 nonce 失败: %w", err)
+	}
+
+	nonce := max(next, chainNonce)
+	_, err = q.ExecContext(ctx,
+		`INSERT INTO wallet_nonces (chain_id, address, next_nonce) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE next_nonce = VALUES(next_nonce)`,
+		chainID, address, nonce+1,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("分配 nonce 失败: %w", err)
+	}
+	return nonce, nil
+}
+
+// ReleaseNonce 交易未能广播时归还 nonce，仅当它仍是最后分配的 nonce 时生效，避免留下空洞
+func ReleaseNonce(ctx context.Context, q db.Querier, chainID int64, address string, nonce uint64) error {
+	_, err := q.ExecContext(ctx,
+		"UPDATE wallet_nonces SET next_nonce = ? WHERE chain_id = ? AND address = ? AND next_nonce = ?",
+		nonce, chainID, address, nonce+1,
+	)
+	if err != nil {
+		return fmt.Errorf("归还 nonce 失败: %w", err)
+	}
+	return nil
+}
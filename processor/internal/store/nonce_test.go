@@ -0,0 +1,50 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestReserveNonce(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock failed: %v", err)
+	}
+	defer db.Close()
+
+	cases := []struct {
+		name       string
+		stored     []int64 // 为空时表示地址尚未分配过
+		chainNonce uint64
+		want       uint64
+	}{
+		{"first reservation uses chain nonce", nil, 7, 7},
+		{"stored nonce ahead of pending pool", []int64{5}, 3, 5},
+		{"external transactions advance chain nonce", []int64{5}, 9, 9},
+	}
+	for _, c := range cases {
+		rows := sqlmock.NewRows([]string{"next_nonce"})
+		for _, n := range c.stored {
+			rows.AddRow(n)
+		}
+		mock.ExpectQuery("SELECT next_nonce FROM wallet_nonces WHERE chain_id = \\? AND address = \\? FOR UPDATE").
+			WithArgs(int64(1), "0xhot").
+			WillReturnRows(rows)
+		mock.ExpectExec("INSERT INTO wallet_nonces").
+			WithArgs(int64(1), "0xhot", int64(c.want+1)).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		got, err := ReserveNonce(context.Background(), db, 1, "0xhot", c.chainNonce)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if got != c.want {
+			t.Fatalf("%s: expected nonce %d, got %d", c.name, c.want, got)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
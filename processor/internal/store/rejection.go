@@ -0,0 +1,41 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"go_bullayer_v1/base/pkg/db"
+)
+
+// DepositRejection 流入充值地址但未通过币种规则校验的转账
+type DepositRejection struct {
+	TxHash      string
+	EventIndex  int
+	BlockNumber int64
+	Coin        string
+	CoinAddress string // 原生 ETH 为空
+	Amount      string // 已按精度换算的十进制金额
+	From        string
+	To          string
+	Reason      string
+}
+
+// SaveDepositRejection 幂等记录被拒绝的充值及原因，重复扫描时更新所在区块和原因
+func SaveDepositRejection(ctx context.Context, q db.Querier, r DepositRejection) error {
+	var coinAddress interface{}
+	if r.CoinAddress != "" {
+		coinAddress = r.CoinAddress
+	}
+
+	_, err := q.ExecContext(ctx,
+		`INSERT INTO deposit_rejections
+		(tx_hash, event_index, block_number, coin, coin_address, amount, from_address, to_address, reason)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE block_number = VALUES(block_number), reason = VALUES(reason)`,
+		r.TxHash, r.EventIndex, r.BlockNumber, r.Coin, coinAddress, r.Amount, r.From, r.To, r.Reason,
+	)
+	if err != nil {
+		return fmt.Errorf("记录被拒绝充值失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"go_bullayer_v1/base/pkg/common"
+	"go_bullayer_v1/base/pkg/db"
+)
+
+// ReorgEvent 链重组审计记录
+type ReorgEvent struct {
+	ChainID        int64
+	DetectedHeight int64  // 发现父哈希不一致的区块高度
+	CommonAncestor int64  // 新旧链的共同祖先高度
+	Depth          int64  // 被回滚的区块数量
+	OldHash        string // 本地记录的旧链区块哈希（DetectedHeight-1）
+	NewHash        string // 主链上同高度的区块哈希
+	RevertedCount  int64  // 被标记为回滚的交易数量
+}
+
+// RevertDepositsAbove 将链 chainID 上 height 以上区块中的充值记录标记为已回滚，返回受影响行数
+func RevertDepositsAbove(ctx context.Context, q db.Querier, chainID, height int64) (int64, error) {
+	res, err := q.ExecContext(ctx,
+		"UPDATE transactions SET status = ? WHERE chain_id = ? AND tx_type = ? AND block_number > ? AND status <> ?",
+		common.TxStatusReverted, chainID, common.TxTypeDeposit, height, common.TxStatusReverted,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("回滚充值记录失败: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("读取回滚行数失败: %w", err)
+	}
+	return n, nil
+}
+
+// InsertReorgEvent 写入链重组审计记录
+func InsertReorgEvent(ctx context.Context, q db.Querier, e ReorgEvent) error {
+	_, err := q.ExecContext(ctx,
+		`INSERT INTO chain_reorgs
+		(chain_id, detected_height, common_ancestor, depth, old_hash, new_hash, reverted_count)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		e.ChainID, e.DetectedHeight, e.CommonAncestor, e.Depth, e.OldHash, e.NewHash, e.RevertedCount,
+	)
+	if err != nil {
+		return fmt.Errorf("写入链重组记录失败: %w", err)
+	}
+	return nil
+}
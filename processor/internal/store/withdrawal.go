@@ -0,0 +1,168 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"go_bullayer_v1/base/pkg/common"
+	"go_bullayer_v1/base/pkg/db"
+)
+
+// Withdrawal transactions 表中的提现记录
+type Withdrawal struct {
+	ID          int64
+	AccountID   int64
+	Coin        string
+	CoinAddress string // 原生 ETH 为空；记录未填写时取自 coin_configs
+	Amount      string // 十进制金额
+	To          string
+	TxHash      string // 签名前为空
+	Nonce       uint64
+}
+
+// ListPendingWithdrawals 按 id 顺序查询待处理的提现
+func ListPendingWithdrawals(ctx context.Context, q db.Querier, limit int) ([]Withdrawal, error) {
+	rows, err := q.QueryContext(ctx,
+		`SELECT t.id, t.account_id, t.coin, COALESCE(t.coin_address, c.coin_address, ''), t.amount, t.to_address
+		FROM transactions t LEFT JOIN coin_configs c ON c.coin = t.coin
+		WHERE t.tx_type = ? AND t.status = ?
+		ORDER BY t.id LIMIT ?`,
+		common.TxTypeWithdraw, common.TxStatusPending, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("查询待处理提现失败: %w", err)
+	}
+	defer rows.Close()
+
+	var withdrawals []Withdrawal
+	for rows.Next() {
+		var w Withdrawal
+		if err := rows.Scan(&w.ID, &w.AccountID, &w.Coin, &w.CoinAddress, &w.Amount, &w.To); err != nil {
+			return nil, fmt.Errorf("读取提现记录失败: %w", err)
+		}
+		withdrawals = append(withdrawals, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取提现记录失败: %w", err)
+	}
+	return withdrawals, nil
+}
+
+// ClaimWithdrawal 将待处理提现标记为处理中，记录已被其他实例领取时 claimed 返回 false
+func ClaimWithdrawal(ctx context.Context, q db.Querier, id int64) (claimed bool, err error) {
+	res, err := q.ExecContext(ctx,
+		"UPDATE transactions SET status = ? WHERE id = ? AND tx_type = ? AND status = ?",
+		common.TxStatusProcessing, id, common.TxTypeWithdraw, common.TxStatusPending,
+	)
+	if err != nil {
+		return false, fmt.Errorf("领取提现 %d 失败: %w", id, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("领取提现 %d 失败: %w", id, err)
+	}
+	return affected == 1, nil
+}
+
+// SetWithdrawalBroadcast 在广播前保存已签名交易的发送方、哈希和 nonce
+func SetWithdrawalBroadcast(ctx context.Context, q db.Querier, id int64, from, txHash string, nonce uint64) error {
+	_, err := q.ExecContext(ctx,
+		"UPDATE transactions SET from_address = ?, tx_hash = ?, nonce = ? WHERE id = ? AND status = ?",
+		from, txHash, nonce, id, common.TxStatusProcessing,
+	)
+	if err != nil {
+		return fmt.Errorf("保存提现 %d 交易哈希失败: %w", id, err)
+	}
+	return nil
+}
+
+// ListProcessingWithdrawals 查询已广播、等待回执的提现
+func ListProcessingWithdrawals(ctx context.Context, q db.Querier, limit int) ([]Withdrawal, error) {
+	rows, err := q.QueryContext(ctx,
+		`SELECT t.id, t.account_id, t.coin, COALESCE(t.coin_address, c.coin_address, ''), t.amount, t.to_address, t.tx_hash, t.nonce
+		FROM transactions t LEFT JOIN coin_configs c ON c.coin = t.coin
+		WHERE t.tx_type = ? AND t.status = ? AND t.tx_hash IS NOT NULL
+		ORDER BY t.id LIMIT ?`,
+		common.TxTypeWithdraw, common.TxStatusProcessing, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("查询处理中提现失败: %w", err)
+	}
+	defer rows.Close()
+
+	var withdrawals []Withdrawal
+	for rows.Next() {
+		var w Withdrawal
+		if err := rows.Scan(&w.ID, &w.AccountID, &w.Coin, &w.CoinAddress, &w.Amount, &w.To, &w.TxHash, &w.Nonce); err != nil {
+			return nil, fmt.Errorf("读取提现记录失败: %w", err)
+		}
+		withdrawals = append(withdrawals, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取提现记录失败: %w", err)
+	}
+	return withdrawals, nil
+}
+
+// WithdrawalResult 提现交易的最终结果
+type WithdrawalResult struct {
+	ID            int64
+	Status        int    // common.TxStatusSuccess 或 common.TxStatusFailed
+	BlockNumber   int64  // 未上链时为 0
+	Gas           string // 以 ETH 计的 gas 费用，十进制
+	Confirmations int64
+	TxHash        string // 实际打包的交易哈希，为空时保持不变
+}
+
+// CompleteWithdrawal 按回执更新提现的最终状态，只更新处理中的记录，必须在事务内调用。
+// 状态切换成功时同时结算申请提现时冻结的 amount + fee：成功从冻结和总资产中扣除，失败退回可用余额。
+func CompleteWithdrawal(ctx context.Context, q db.Querier, r WithdrawalResult) error {
+	var blockNumber interface{}
+	if r.BlockNumber > 0 {
+		blockNumber = r.BlockNumber
+	}
+	var txHash interface{}
+	if r.TxHash != "" {
+		txHash = r.TxHash
+	}
+	gas := r.Gas
+	if gas == "" {
+		gas = "0"
+	}
+
+	res, err := q.ExecContext(ctx,
+		`UPDATE transactions SET status = ?, block_number = ?, gas = ?, confirmations = ?, tx_hash = COALESCE(?, tx_hash)
+		WHERE id = ? AND status = ?`,
+		r.Status, blockNumber, gas, r.Confirmations, txHash, r.ID, common.TxStatusProcessing,
+	)
+	if err != nil {
+		return fmt.Errorf("更新提现 %d 状态失败: %w", r.ID, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("更新提现 %d 状态失败: %w", r.ID, err)
+	}
+	if n == 0 {
+		return nil
+	}
+
+	if r.Status == common.TxStatusSuccess {
+		_, err = q.ExecContext(ctx,
+			`UPDATE user_assets u JOIN transactions t ON t.account_id = u.account_id AND t.coin = u.coin
+			SET u.total = u.total - (t.amount + COALESCE(t.fee, 0)), u.freeze = u.freeze - (t.amount + COALESCE(t.fee, 0))
+			WHERE t.id = ?`,
+			r.ID,
+		)
+	} else {
+		_, err = q.ExecContext(ctx,
+			`UPDATE user_assets u JOIN transactions t ON t.account_id = u.account_id AND t.coin = u.coin
+			SET u.available = u.available + (t.amount + COALESCE(t.fee, 0)), u.freeze = u.freeze - (t.amount + COALESCE(t.fee, 0))
+			WHERE t.id = ?`,
+			r.ID,
+		)
+	}
+	if err != nil {
+		return fmt.Errorf("结算提现 %d 冻结资产失败: %w", r.ID, err)
+	}
+	return nil
+}
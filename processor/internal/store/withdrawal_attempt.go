@@ -0,0 +1,89 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"go_bullayer_v1/base/pkg/common"
+	"go_bullayer_v1/base/pkg/db"
+)
+
+// WithdrawalAttempt 提现交易的一次广播，同一提现的所有尝试使用相同 nonce
+type WithdrawalAttempt struct {
+	ID           int64
+	WithdrawalID int64
+	Nonce        uint64
+	TxHash       string
+	Kind         int    // common.AttemptKind*
+	GasTipCap    uint64 // wei
+	GasFeeCap    uint64 // wei
+	Status       int    // common.AttemptStatus*
+	AgeSeconds   int64  // 距广播的秒数，按数据库时钟计算
+}
+
+// InsertWithdrawalAttempt 在广播前记录交易
+func InsertWithdrawalAttempt(ctx context.Context, q db.Querier, a WithdrawalAttempt) (int64, error) {
+	res, err := q.ExecContext(ctx,
+		`INSERT INTO withdrawal_attempts (withdrawal_id, nonce, tx_hash, kind, gas_tip_cap, gas_fee_cap, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		a.WithdrawalID, a.Nonce, a.TxHash, a.Kind, a.GasTipCap, a.GasFeeCap, a.Status,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("记录提现 %d 广播失败: %w", a.WithdrawalID, err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("记录提现 %d 广播失败: %w", a.WithdrawalID, err)
+	}
+	return id, nil
+}
+
+// ListWithdrawalAttempts 按广播顺序查询提现的全部尝试
+func ListWithdrawalAttempts(ctx context.Context, q db.Querier, withdrawalID int64) ([]WithdrawalAttempt, error) {
+	rows, err := q.QueryContext(ctx,
+		`SELECT id, withdrawal_id, nonce, tx_hash, kind, gas_tip_cap, gas_fee_cap, status,
+		TIMESTAMPDIFF(SECOND, created_at, NOW())
+		FROM withdrawal_attempts WHERE withdrawal_id = ? ORDER BY id`,
+		withdrawalID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("查询提现 %d 广播记录失败: %w", withdrawalID, err)
+	}
+	defer rows.Close()
+
+	var attempts []WithdrawalAttempt
+	for rows.Next() {
+		var a WithdrawalAttempt
+		if err := rows.Scan(&a.ID, &a.WithdrawalID, &a.Nonce, &a.TxHash, &a.Kind,
+			&a.GasTipCap, &a.GasFeeCap, &a.Status, &a.AgeSeconds); err != nil {
+			return nil, fmt.Errorf("读取提现广播记录失败: %w", err)
+		}
+		attempts = append(attempts, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取提现广播记录失败: %w", err)
+	}
+	return attempts, nil
+}
+
+// SetWithdrawalAttemptStatus 更新单次广播的状态
+func SetWithdrawalAttemptStatus(ctx context.Context, q db.Querier, id int64, status int) error {
+	_, err := q.ExecContext(ctx, "UPDATE withdrawal_attempts SET status = ? WHERE id = ?", status, id)
+	if err != nil {
+		return fmt.Errorf("更新提现广播记录 %d 失败: %w", id, err)
+	}
+	return nil
+}
+
+// SettleWithdrawalAttempts 标记已打包的广播，同一提现的其他待打包广播标记为已被替换
+func SettleWithdrawalAttempts(ctx context.Context, q db.Querier, withdrawalID, minedID int64) error {
+	_, err := q.ExecContext(ctx,
+		`UPDATE withdrawal_attempts SET status = IF(id = ?, ?, ?)
+		WHERE withdrawal_id = ? AND status = ?`,
+		minedID, common.AttemptStatusMined, common.AttemptStatusReplaced, withdrawalID, common.AttemptStatusPending,
+	)
+	if err != nil {
+		return fmt.Errorf("更新提现 %d 广播记录失败: %w", withdrawalID, err)
+	}
+	return nil
+}
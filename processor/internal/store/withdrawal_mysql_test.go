@@ -0,0 +1,67 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"go_bullayer_v1/base/pkg/common"
+	"go_bullayer_v1/base/pkg/db"
+)
+
+// TestCompleteWithdrawalMySQL 验证提现结束时冻结资产的结算：
+// 失败（含广播失败）退回可用余额，成功从冻结和总资产中扣除，重复结束不重复结算
+func TestCompleteWithdrawalMySQL(t *testing.T) {
+	conn := openTestDB(t)
+	ctx := context.Background()
+
+	// 账户 1 总资产 100，两笔提现各冻结 20 + 1 手续费
+	for _, q := range []string{
+		"INSERT INTO user_assets (account_id, coin, total, freeze, available) VALUES (1, 'USDT', 100, 42, 58)",
+		`INSERT INTO transactions (id, tx_type, account_id, coin, amount, fee, to_address, status)
+		VALUES (1, 2, 1, 'USDT', 20, 1, '0x00000000000000000000000000000000000000aa', 0),
+		(2, 2, 1, 'USDT', 20, 1, '0x00000000000000000000000000000000000000aa', 0)`,
+	} {
+		if _, err := conn.Exec(q); err != nil {
+			t.Fatalf("seed failed: %v", err)
+		}
+	}
+
+	complete := func(r WithdrawalResult) {
+		t.Helper()
+		err := db.WithTx(ctx, conn, func(tx *sql.Tx) error {
+			if _, err := ClaimWithdrawal(ctx, tx, r.ID); err != nil {
+				return err
+			}
+			return CompleteWithdrawal(ctx, tx, r)
+		})
+		if err != nil {
+			t.Fatalf("complete withdrawal %d failed: %v", r.ID, err)
+		}
+	}
+	balance := func() (total, freeze, available string) {
+		t.Helper()
+		err := conn.QueryRow("SELECT total, freeze, available FROM user_assets WHERE account_id = 1 AND coin = 'USDT'").
+			Scan(&total, &freeze, &available)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return total, freeze, available
+	}
+
+	complete(WithdrawalResult{ID: 1, Status: common.TxStatusFailed})
+	if total, freeze, available := balance(); total != "100.000000000000000000" || freeze != "21.000000000000000000" || available != "79.000000000000000000" {
+		t.Fatalf("unexpected balance after failure: total=%s freeze=%s available=%s", total, freeze, available)
+	}
+
+	complete(WithdrawalResult{ID: 2, Status: common.TxStatusSuccess, BlockNumber: 10, Gas: "0.001", Confirmations: 12})
+	if total, freeze, available := balance(); total != "79.000000000000000000" || freeze != "0.000000000000000000" || available != "79.000000000000000000" {
+		t.Fatalf("unexpected balance after success: total=%s freeze=%s available=%s", total, freeze, available)
+	}
+
+	// 已结束的提现不会再被领取和结算
+	complete(WithdrawalResult{ID: 1, Status: common.TxStatusFailed})
+	if total, freeze, available := balance(); total != "79.000000000000000000" || freeze != "0.000000000000000000" || available != "79.000000000000000000" {
+		t.Fatalf("withdrawal settled twice: total=%s freeze=%s available=%s", total, freeze, available)
+	}
+}
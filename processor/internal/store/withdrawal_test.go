@@ -0,0 +1,76 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestClaimWithdrawal(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock failed: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("UPDATE transactions SET status = \\? WHERE id = \\? AND tx_type = \\? AND status = \\?").
+		WithArgs(4, int64(1), 2, 0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE transactions SET status = \\? WHERE id = \\? AND tx_type = \\? AND status = \\?").
+		WithArgs(4, int64(1), 2, 0).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	claimed, err := ClaimWithdrawal(context.Background(), db, 1)
+	if err != nil || !claimed {
+		t.Fatalf("expected first claim to succeed, got claimed=%v err=%v", claimed, err)
+	}
+	claimed, err = ClaimWithdrawal(context.Background(), db, 1)
+	if err != nil || claimed {
+		t.Fatalf("expected second claim to be rejected, got claimed=%v err=%v", claimed, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCompleteWithdrawalSettlesFrozenAssets(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock failed: %v", err)
+	}
+	defer db.Close()
+
+	// 成功：从冻结和总资产中扣除
+	mock.ExpectExec("UPDATE transactions SET status = \\?, block_number").
+		WithArgs(1, int64(100), "0.001", int64(12), nil, int64(1), 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE user_assets u JOIN transactions t .* SET u.total = u.total - .*, u.freeze = u.freeze - ").
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// 失败：退回可用余额
+	mock.ExpectExec("UPDATE transactions SET status = \\?, block_number").
+		WithArgs(2, nil, "0", int64(0), nil, int64(2), 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE user_assets u JOIN transactions t .* SET u.available = u.available \\+ .*, u.freeze = u.freeze - ").
+		WithArgs(int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// 已结束的提现不重复结算
+	mock.ExpectExec("UPDATE transactions SET status = \\?, block_number").
+		WithArgs(2, nil, "0", int64(0), nil, int64(2), 4).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ctx := context.Background()
+	if err := CompleteWithdrawal(ctx, db, WithdrawalResult{ID: 1, Status: 1, BlockNumber: 100, Gas: "0.001", Confirmations: 12}); err != nil {
+		t.Fatalf("complete success failed: %v", err)
+	}
+	if err := CompleteWithdrawal(ctx, db, WithdrawalResult{ID: 2, Status: 2}); err != nil {
+		t.Fatalf("complete failure failed: %v", err)
+	}
+	if err := CompleteWithdrawal(ctx, db, WithdrawalResult{ID: 2, Status: 2}); err != nil {
+		t.Fatalf("repeat complete failed: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -4,6 +4,8 @@ go 1.25.7
 
 require (
 	go_bullayer_v1/base v0.0.0
+	github.com/DATA-DOG/go-sqlmock v1.5.2
+	github.com/ethereum/go-ethereum v1.14.12
 	github.com/zeromicro/go-zero v1.6.0
 )
 
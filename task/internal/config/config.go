@@ -9,10 +9,36 @@ type Config struct {
 	// 统计任务配置
 	StatsTask struct {
 		Enabled bool `json:"enabled"` // 是否启用统计任务
-		Hour    int  `json:"hour"`     // 执行时间（小时，0-23）
-		Minute  int  `json:"minute"`   // 执行时间（分钟，0-59）
+		Hour    int  `json:"hour"`    // 执行时间（小时，0-23）
+		Minute  int  `json:"minute"`  // 执行时间（分钟，0-59）
 	} `json:"stats_task"`
 
+	// 充值地址归集任务配置
+	Sweep struct {
+		Enabled                 bool              `json:"enabled,optional"`                    // 是否启用归集任务，需要配置数据库
+		RPCURL                  string            `json:"rpc_url,optional"`                    // 查询余额和广播交易的节点
+		ChainID                 int64             `json:"chain_id,optional"`                   // 非 0 时校验节点链ID
+		XPrvFile                string            `json:"xprv_file,optional"`                  // 账户层级扩展私钥文件，需与 processor 的 DepositAddress.XPub 对应
+		HotWallet               string            `json:"hot_wallet,optional"`                 // 归集目标热钱包地址
+		GasKeystoreFile         string            `json:"gas_keystore_file,optional"`          // 补充 gas 的钱包 keystore，应使用归集专用钱包
+		GasKeystorePasswordFile string            `json:"gas_keystore_password_file,optional"` // gas 钱包 keystore 密码文件
+		Thresholds              map[string]string `json:"thresholds,optional"`                 // 币种 -> 归集阈值（十进制），未配置的币种不归集
+		BatchSize               int               `json:"batch_size,default=100"`              // 每轮检查的充值地址数量
+		Confirmations           int64             `json:"confirmations,default=12"`            // 归集交易确认数
+		GasLimitMultiplier      float64           `json:"gas_limit_multiplier,default=1.2"`    // ERC20 归集 gas 估算放大系数
+		MaxFeePerGasGwei        int64             `json:"max_fee_per_gas_gwei,optional"`       // 手续费上限，当前手续费超过上限时暂停归集
+	} `json:"sweep,optional"`
+
+	// 热钱包余额监控任务配置
+	WalletMonitor struct {
+		Enabled        bool              `json:"enabled,optional"`          // 是否启用监控任务，需要配置数据库
+		RPCURL         string            `json:"rpc_url,optional"`          // 查询余额的节点
+		HotWallet      string            `json:"hot_wallet,optional"`       // 监控的热钱包地址
+		Thresholds     []WalletThreshold `json:"thresholds,optional"`       // 按币种的余额阈值，未配置的币种不监控
+		WebhookURL     string            `json:"webhook_url,optional"`      // 告警 webhook 地址，为空时只写日志
+		WebhookTimeout int               `json:"webhook_timeout,default=5"` // webhook 请求超时（秒）
+	} `json:"wallet_monitor,optional"`
+
 	// 数据库配置（可选）
 	Database struct {
 		Host     string `json:"host"`
@@ -22,3 +48,10 @@ type Config struct {
 		Database string `json:"database"`
 	} `json:"database"`
 }
+
+// WalletThreshold 热钱包单个币种的余额阈值，金额为十进制
+type WalletThreshold struct {
+	Coin    string `json:"coin"`             // 币种，需在 coin_configs 中启用
+	Floor   string `json:"floor,optional"`   // 覆盖待处理提现后需保留的最低余额，为空表示 0
+	Ceiling string `json:"ceiling,optional"` // 余额上限，为空表示不限制
+}
@@ -0,0 +1,43 @@
+package notify
+
+import (
+	"context"
+	"time"
+
+	"go_bullayer_v1/base/pkg/logger"
+)
+
+// 告警类型
+const (
+	KindBelowFloor   = "below_floor"   // 热钱包余额不足以覆盖待处理提现和下限，需要从冷钱包补充
+	KindAboveCeiling = "above_ceiling" // 热钱包余额超过上限，应转入冷钱包
+	KindRecovered    = "recovered"     // 余额恢复正常
+)
+
+// Alert 钱包余额告警，金额均为十进制字符串
+type Alert struct {
+	Kind               string    `json:"kind"`
+	Coin               string    `json:"coin"`
+	Wallet             string    `json:"wallet"`
+	Balance            string    `json:"balance"`
+	PendingWithdrawals string    `json:"pending_withdrawals"`
+	Floor              string    `json:"floor"`
+	Ceiling            string    `json:"ceiling,omitempty"`
+	Amount             string    `json:"amount,omitempty"` // 建议调拨金额：低于下限时为缺口，高于上限时为超出部分
+	Message            string    `json:"message"`
+	Time               time.Time `json:"time"`
+}
+
+// Notifier 告警发送接口，可按需实现 webhook、IM 机器人等渠道
+type Notifier interface {
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// LogNotifier 只把告警写入日志，未配置其他渠道时使用
+type LogNotifier struct{}
+
+// Notify 将告警写入错误日志
+func (LogNotifier) Notify(ctx context.Context, alert Alert) error {
+	logger.Error("钱包余额告警 [%s] %s", alert.Kind, alert.Message)
+	return nil
+}
@@ -0,0 +1,52 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebhookNotifier_PostsAlert(t *testing.T) {
+	received := make(chan Alert, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected request %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		var alert Alert
+		if err := json.NewDecoder(r.Body).Decode(&alert); err != nil {
+			t.Errorf("decode alert failed: %v", err)
+		}
+		received <- alert
+	}))
+	defer server.Close()
+
+	notifier, err := NewWebhookNotifier(server.URL, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alert := Alert{Kind: KindBelowFloor, Coin: "ETH", Balance: "1", Amount: "4", Time: time.Unix(1700000000, 0).UTC()}
+	if err := notifier.Notify(context.Background(), alert); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if got := <-received; got != alert {
+		t.Fatalf("received %+v, want %+v", got, alert)
+	}
+}
+
+func TestWebhookNotifier_FailsOnErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	notifier, err := NewWebhookNotifier(server.URL, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := notifier.Notify(context.Background(), Alert{Kind: KindAboveCeiling}); err == nil {
+		t.Fatal("expected error for 500 response")
+	}
+}
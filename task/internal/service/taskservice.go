@@ -2,11 +2,16 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"sync"
 	"time"
 
+	"go_bullayer_v1/base/pkg/db"
+	"go_bullayer_v1/base/pkg/eth"
 	"go_bullayer_v1/base/pkg/logger"
 	"go_bullayer_v1/task/internal/config"
+	"go_bullayer_v1/task/internal/notify"
 	"go_bullayer_v1/task/internal/task"
 )
 
@@ -17,6 +22,8 @@ type TaskService struct {
 	cancel context.CancelFunc // 取消函数
 	config config.Config      // 配置
 	tasks  []task.Task        // 任务列表
+	db     *sql.DB            // 数据库连接，未配置时为 nil
+	closes []func()           // 停止时释放的资源
 	wg     sync.WaitGroup     // 等待组，用于等待所有任务完成
 	mu     sync.Mutex         // 互斥锁
 }
@@ -66,6 +73,15 @@ func (s *TaskService) Stop() {
 	// 等待所有任务完成
 	s.wg.Wait()
 
+	for _, c := range s.closes {
+		c()
+	}
+	if s.db != nil {
+		if err := s.db.Close(); err != nil {
+			logger.Error("关闭数据库连接失败: %v", err)
+		}
+	}
+
 	logger.Info("任务服务已停止")
 }
 
@@ -81,10 +97,98 @@ func (s *TaskService) registerTasks() {
 		logger.Info("已注册统计任务")
 	}
 
+	// 注册归集任务
+	if s.config.Sweep.Enabled {
+		s.registerSweepTask()
+	}
+
+	// 注册热钱包余额监控任务
+	if s.config.WalletMonitor.Enabled {
+		s.registerWalletMonitorTask()
+	}
+
 	// 可以在这里注册更多任务
 	// 例如：数据清理任务、报表生成任务等
 }
 
+// registerSweepTask 连接数据库和广播节点并注册归集任务，初始化失败时跳过
+func (s *TaskService) registerSweepTask() {
+	database, err := s.initDB()
+	if err != nil {
+		logger.Error("归集任务数据库初始化失败: %v", err)
+		return
+	}
+	client, err := eth.DialSender(s.ctx, s.config.Sweep.RPCURL)
+	if err != nil {
+		logger.Error("归集任务节点连接失败: %v", err)
+		return
+	}
+	sweepTask, err := task.NewSweepTask(s.ctx, s.config, database, client)
+	if err != nil {
+		client.Close()
+		logger.Error("归集任务初始化失败: %v", err)
+		return
+	}
+	s.closes = append(s.closes, client.Close)
+	s.tasks = append(s.tasks, sweepTask)
+	logger.Info("已注册归集任务")
+}
+
+// registerWalletMonitorTask 连接数据库和查询节点并注册余额监控任务，初始化失败时跳过
+func (s *TaskService) registerWalletMonitorTask() {
+	database, err := s.initDB()
+	if err != nil {
+		logger.Error("余额监控任务数据库初始化失败: %v", err)
+		return
+	}
+
+	var notifier notify.Notifier
+	if s.config.WalletMonitor.WebhookURL != "" {
+		timeout := time.Duration(s.config.WalletMonitor.WebhookTimeout) * time.Second
+		if notifier, err = notify.NewWebhookNotifier(s.config.WalletMonitor.WebhookURL, timeout); err != nil {
+			logger.Error("余额监控告警初始化失败: %v", err)
+			return
+		}
+	}
+
+	client, err := eth.DialSender(s.ctx, s.config.WalletMonitor.RPCURL)
+	if err != nil {
+		logger.Error("余额监控任务节点连接失败: %v", err)
+		return
+	}
+	monitorTask, err := task.NewWalletMonitorTask(s.config, database, client, notifier)
+	if err != nil {
+		client.Close()
+		logger.Error("余额监控任务初始化失败: %v", err)
+		return
+	}
+	s.closes = append(s.closes, client.Close)
+	s.tasks = append(s.tasks, monitorTask)
+	logger.Info("已注册余额监控任务")
+}
+
+// initDB 按需创建数据库连接，多个任务共用
+func (s *TaskService) initDB() (*sql.DB, error) {
+	if s.db != nil {
+		return s.db, nil
+	}
+	if s.config.Database.Host == "" {
+		return nil, errors.New("未配置数据库连接")
+	}
+	database, err := db.NewDB(db.DBConfig{
+		Host:     s.config.Database.Host,
+		Port:     s.config.Database.Port,
+		User:     s.config.Database.User,
+		Password: s.config.Database.Password,
+		Database: s.config.Database.Database,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.db = database
+	return database, nil
+}
+
 // runTask 运行单个任务
 // t: 任务实例
 func (s *TaskService) runTask(t task.Task) {
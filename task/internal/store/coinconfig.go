@@ -0,0 +1,40 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"go_bullayer_v1/base/pkg/common"
+	"go_bullayer_v1/base/pkg/db"
+)
+
+// EnabledCoin 启用中的币种
+type EnabledCoin struct {
+	Coin        string
+	CoinAddress string // 原生 ETH 为空
+}
+
+// ListEnabledCoins 查询启用中的币种
+func ListEnabledCoins(ctx context.Context, q db.Querier) ([]EnabledCoin, error) {
+	rows, err := q.QueryContext(ctx,
+		"SELECT coin, COALESCE(coin_address, '') FROM coin_configs WHERE status = ? ORDER BY id",
+		common.CoinStatusEnabled,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("查询币种配置失败: %w", err)
+	}
+	defer rows.Close()
+
+	var coins []EnabledCoin
+	for rows.Next() {
+		var c EnabledCoin
+		if err := rows.Scan(&c.Coin, &c.CoinAddress); err != nil {
+			return nil, fmt.Errorf("读取币种配置失败: %w", err)
+		}
+		coins = append(coins, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取币种配置失败: %w", err)
+	}
+	return coins, nil
+}
@@ -0,0 +1,134 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"go_bullayer_v1/base/pkg/common"
+	"go_bullayer_v1/base/pkg/db"
+)
+
+// DepositAddress 账户专属充值地址
+type DepositAddress struct {
+	ID              int64
+	Address         string
+	DerivationIndex uint32
+}
+
+// ListDepositAddresses 按 id 顺序查询 afterID 之后的充值地址
+func ListDepositAddresses(ctx context.Context, q db.Querier, afterID int64, limit int) ([]DepositAddress, error) {
+	rows, err := q.QueryContext(ctx,
+		"SELECT id, address, derivation_index FROM deposit_addresses WHERE id > ? ORDER BY id LIMIT ?",
+		afterID, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("查询充值地址失败: %w", err)
+	}
+	defer rows.Close()
+
+	var addresses []DepositAddress
+	for rows.Next() {
+		var a DepositAddress
+		if err := rows.Scan(&a.ID, &a.Address, &a.DerivationIndex); err != nil {
+			return nil, fmt.Errorf("读取充值地址失败: %w", err)
+		}
+		addresses = append(addresses, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取充值地址失败: %w", err)
+	}
+	return addresses, nil
+}
+
+// SweepTransaction sweep_transactions 表中的归集或 gas 补充交易
+type SweepTransaction struct {
+	ID             int64
+	ChainID        int64
+	Kind           int // common.SweepKind*
+	Coin           string
+	CoinAddress    string // 原生 ETH 为空
+	Amount         string // 十进制
+	From           string
+	To             string
+	DepositAddress string
+	TxHash         string
+	Nonce          uint64
+}
+
+// InsertSweep 在广播前记录已签名的交易，返回记录 id
+func InsertSweep(ctx context.Context, q db.Querier, s SweepTransaction) (int64, error) {
+	var coinAddress interface{}
+	if s.CoinAddress != "" {
+		coinAddress = s.CoinAddress
+	}
+	res, err := q.ExecContext(ctx,
+		`INSERT INTO sweep_transactions
+		(chain_id, kind, coin, coin_address, amount, from_address, to_address, deposit_address, tx_hash, nonce, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		s.ChainID, s.Kind, s.Coin, coinAddress, s.Amount, s.From, s.To, s.DepositAddress, s.TxHash, s.Nonce,
+		common.SweepStatusPending,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("记录归集交易 %s 失败: %w", s.TxHash, err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("记录归集交易 %s 失败: %w", s.TxHash, err)
+	}
+	return id, nil
+}
+
+// ListPendingSweeps 查询待确认的归集和 gas 补充交易
+func ListPendingSweeps(ctx context.Context, q db.Querier, chainID int64) ([]SweepTransaction, error) {
+	rows, err := q.QueryContext(ctx,
+		`SELECT id, chain_id, kind, coin, COALESCE(coin_address, ''), amount, from_address, to_address, deposit_address, tx_hash, nonce
+		FROM sweep_transactions WHERE chain_id = ? AND status = ? ORDER BY id`,
+		chainID, common.SweepStatusPending,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("查询待确认归集交易失败: %w", err)
+	}
+	defer rows.Close()
+
+	var sweeps []SweepTransaction
+	for rows.Next() {
+		var s SweepTransaction
+		if err := rows.Scan(&s.ID, &s.ChainID, &s.Kind, &s.Coin, &s.CoinAddress, &s.Amount,
+			&s.From, &s.To, &s.DepositAddress, &s.TxHash, &s.Nonce); err != nil {
+			return nil, fmt.Errorf("读取归集交易失败: %w", err)
+		}
+		sweeps = append(sweeps, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取归集交易失败: %w", err)
+	}
+	return sweeps, nil
+}
+
+// SweepResult 归集交易的最终结果
+type SweepResult struct {
+	ID          int64
+	Status      int    // common.SweepStatusSuccess 或 common.SweepStatusFailed
+	BlockNumber int64  // 未上链时为 0
+	Gas         string // 以 ETH 计的 gas 费用，十进制
+}
+
+// CompleteSweep 更新待确认归集交易的最终状态
+func CompleteSweep(ctx context.Context, q db.Querier, r SweepResult) error {
+	var blockNumber interface{}
+	if r.BlockNumber > 0 {
+		blockNumber = r.BlockNumber
+	}
+	gas := r.Gas
+	if gas == "" {
+		gas = "0"
+	}
+	_, err := q.ExecContext(ctx,
+		"UPDATE sweep_transactions SET status = ?, block_number = ?, gas = ? WHERE id = ? AND status = ?",
+		r.Status, blockNumber, gas, r.ID, common.SweepStatusPending,
+	)
+	if err != nil {
+		return fmt.Errorf("更新归集交易 %d 状态失败: %w", r.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"go_bullayer_v1/base/pkg/common"
+	"go_bullayer_v1/base/pkg/db"
+)
+
+// SumPendingWithdrawals 按币种汇总热钱包 wallet 尚需支付的提现金额，返回币种 -> 十进制金额。
+// 包括待审核、待处理，以及尚未签名或 nonce 不小于 minedNonce（未打包进最新区块）的处理中提现；
+// 已打包的处理中提现转出金额已体现在最新区块的余额中，不重复计入
+func SumPendingWithdrawals(ctx context.Context, q db.Querier, wallet string, minedNonce uint64) (map[string]string, error) {
+	rows, err := q.QueryContext(ctx,
+		`SELECT coin, SUM(amount) FROM transactions
+		WHERE tx_type = ? AND (status IN (?, ?)
+			OR (status = ? AND (from_address IS NULL OR (from_address = ? AND nonce >= ?))))
+		GROUP BY coin`,
+		common.TxTypeWithdraw, common.TxStatusPending, common.TxStatusPendingReview,
+		common.TxStatusProcessing, wallet, minedNonce,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("汇总待处理提现失败: %w", err)
+	}
+	defer rows.Close()
+
+	sums := make(map[string]string)
+	for rows.Next() {
+		var coin, amount string
+		if err := rows.Scan(&coin, &amount); err != nil {
+			return nil, fmt.Errorf("读取待处理提现汇总失败: %w", err)
+		}
+		sums[coin] = amount
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取待处理提现汇总失败: %w", err)
+	}
+	return sums, nil
+}
+
+// BalanceSnapshot 热钱包某币种的余额快照，金额均为十进制
+type BalanceSnapshot struct {
+	Coin               string
+	Wallet             string
+	Balance            string
+	PendingWithdrawals string
+	Floor              string
+	Ceiling            string // 未配置上限时为空
+	Status             int    // common.WalletBalance*
+}
+
+// InsertBalanceSnapshot 写入余额快照
+func InsertBalanceSnapshot(ctx context.Context, q db.Querier, s BalanceSnapshot) error {
+	var ceiling interface{}
+	if s.Ceiling != "" {
+		ceiling = s.Ceiling
+	}
+	_, err := q.ExecContext(ctx,
+		`INSERT INTO wallet_balance_snapshots (coin, wallet_address, balance, pending_withdrawals, floor, ceiling, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		s.Coin, s.Wallet, s.Balance, s.PendingWithdrawals, s.Floor, ceiling, s.Status,
+	)
+	if err != nil {
+		return fmt.Errorf("记录 %s 余额快照失败: %w", s.Coin, err)
+	}
+	return nil
+}
@@ -0,0 +1,483 @@
+package task
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	basecommon "go_bullayer_v1/base/pkg/common"
+	"go_bullayer_v1/base/pkg/eth"
+	"go_bullayer_v1/base/pkg/hdwallet"
+	"go_bullayer_v1/base/pkg/logger"
+	"go_bullayer_v1/base/pkg/utils"
+	"go_bullayer_v1/task/internal/config"
+	"go_bullayer_v1/task/internal/store"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// nativeDecimals 原生 ETH 精度
+const nativeDecimals = 18
+
+// 归集任务默认参数
+const (
+	defaultSweepBatchSize     = 100
+	defaultSweepGasMultiplier = 1.2
+)
+
+// SweepChain 归集任务使用的节点接口。
+// *ethclient.Client 和 go-ethereum 的模拟后端（ethclient/simulated）均满足该接口。
+type SweepChain interface {
+	eth.TxSender
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+}
+
+// sweepCoin 配置了归集阈值的币种
+type sweepCoin struct {
+	coin      string
+	token     *common.Address // 原生 ETH 为空
+	decimals  int
+	threshold *big.Int // 最小单位
+}
+
+// SweepTask 充值地址归集任务。
+// 每轮先确认已广播的归集交易，再分批检查充值地址余额：ERC20 余额达到阈值时，
+// 地址 ETH 不足以支付手续费则由 gas 钱包补充，补充确认后将代币转入热钱包；
+// ETH 余额达到阈值时扣除手续费后全部转入热钱包。
+// 同一充值地址有待确认交易时不再发起新交易，归集交易记录在 sweep_transactions，不影响用户资产。
+type SweepTask struct {
+	config    config.Config
+	db        *sql.DB
+	chain     SweepChain
+	xprv      *hdwallet.XPrv
+	hotWallet common.Address
+	gasKey    *ecdsa.PrivateKey
+	gasWallet common.Address
+	chainID   int64
+	signer    types.Signer
+	tokens    *eth.TokenReader
+	cursor    int64  // 上一轮检查到的充值地址 id
+	gasNonce  uint64 // 本轮 gas 钱包下一个 nonce
+}
+
+// NewSweepTask 创建归集任务，读取扩展私钥和 gas 钱包 keystore
+func NewSweepTask(ctx context.Context, cfg config.Config, db *sql.DB, chain SweepChain) (*SweepTask, error) {
+	if db == nil {
+		return nil, errors.New("归集任务需要配置数据库")
+	}
+	if chain == nil {
+		return nil, errors.New("未初始化链客户端，请检查 Sweep.RPCURL 配置")
+	}
+	if !common.IsHexAddress(cfg.Sweep.HotWallet) {
+		return nil, fmt.Errorf("Sweep.HotWallet 地址 %q 格式错误", cfg.Sweep.HotWallet)
+	}
+	if len(cfg.Sweep.Thresholds) == 0 {
+		return nil, errors.New("未配置 Sweep.Thresholds")
+	}
+
+	if cfg.Sweep.XPrvFile == "" {
+		return nil, errors.New("未配置 Sweep.XPrvFile")
+	}
+	raw, err := os.ReadFile(cfg.Sweep.XPrvFile)
+	if err != nil {
+		return nil, fmt.Errorf("读取扩展私钥文件失败: %w", err)
+	}
+	xprv, err := hdwallet.ParseXPrv(string(raw))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Sweep.GasKeystoreFile == "" {
+		return nil, errors.New("未配置 Sweep.GasKeystoreFile")
+	}
+	gasKey, err := eth.LoadKeystore(cfg.Sweep.GasKeystoreFile, cfg.Sweep.GasKeystorePasswordFile)
+	if err != nil {
+		return nil, fmt.Errorf("加载 gas 钱包 keystore 失败: %w", err)
+	}
+
+	chainID, err := chain.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("查询链ID失败: %w", err)
+	}
+	if cfg.Sweep.ChainID > 0 && chainID.Int64() != cfg.Sweep.ChainID {
+		return nil, fmt.Errorf("节点链ID %s 与配置的 Sweep.ChainID %d 不一致", chainID, cfg.Sweep.ChainID)
+	}
+
+	t := &SweepTask{
+		config:    cfg,
+		db:        db,
+		chain:     chain,
+		xprv:      xprv,
+		hotWallet: common.HexToAddress(cfg.Sweep.HotWallet),
+		gasKey:    gasKey,
+		gasWallet: crypto.PubkeyToAddress(gasKey.PublicKey),
+		chainID:   chainID.Int64(),
+		signer:    types.LatestSignerForChainID(chainID),
+		tokens:    eth.NewTokenReader(eth.CallMsgCaller(chain)),
+	}
+	logger.Info("归集热钱包: %s，gas 钱包: %s", t.hotWallet.Hex(), t.gasWallet.Hex())
+	return t, nil
+}
+
+// Name 返回任务名称
+func (t *SweepTask) Name() string {
+	return "充值地址归集任务"
+}
+
+// GasWallet gas 钱包地址，需配置到 processor 的 BlockProcessor.InternalAddresses，避免补充的 ETH 被记为充值
+func (t *SweepTask) GasWallet() common.Address {
+	return t.gasWallet
+}
+
+// Execute 确认已广播的归集交易并检查下一批充值地址
+// ctx: 上下文
+// 返回错误信息
+func (t *SweepTask) Execute(ctx context.Context) error {
+	busy, err := t.confirmSweeps(ctx)
+	if err != nil {
+		return err
+	}
+	return t.sweepAddresses(ctx, busy)
+}
+
+func (t *SweepTask) batchSize() int {
+	if t.config.Sweep.BatchSize <= 0 {
+		return defaultSweepBatchSize
+	}
+	return t.config.Sweep.BatchSize
+}
+
+// confirmSweeps 查询待确认归集交易的回执，达到确认数后更新为成功或失败；
+// 未打包且发送方 pending nonce 未超过交易 nonce 的交易已被节点丢弃，标记为失败，下一轮重新归集。
+// 返回仍有待确认交易的充值地址（小写）
+func (t *SweepTask) confirmSweeps(ctx context.Context) (map[string]struct{}, error) {
+	pending, err := store.ListPendingSweeps(ctx, t.db, t.chainID)
+	if err != nil {
+		return nil, err
+	}
+	busy := make(map[string]struct{}, len(pending))
+	if len(pending) == 0 {
+		return busy, nil
+	}
+
+	latest, err := t.chain.BlockNumber(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("查询最新高度失败: %w", err)
+	}
+
+	for _, s := range pending {
+		receipt, err := t.chain.TransactionReceipt(ctx, common.HexToHash(s.TxHash))
+		if eth.IsReceiptNotFound(err) {
+			dropped, err := t.isDropped(ctx, s)
+			if err != nil {
+				return nil, err
+			}
+			if !dropped {
+				busy[strings.ToLower(s.DepositAddress)] = struct{}{}
+				continue
+			}
+			logger.Error("归集交易 %s 已被节点丢弃，标记为失败", s.TxHash)
+			if err := store.CompleteSweep(ctx, t.db, store.SweepResult{ID: s.ID, Status: basecommon.SweepStatusFailed}); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("查询归集交易 %s 回执失败: %w", s.TxHash, err)
+		}
+
+		confirmations := int64(latest) - receipt.BlockNumber.Int64() + 1
+		if confirmations < t.config.Sweep.Confirmations {
+			busy[strings.ToLower(s.DepositAddress)] = struct{}{}
+			continue
+		}
+
+		result := store.SweepResult{ID: s.ID, Status: basecommon.SweepStatusSuccess, BlockNumber: receipt.BlockNumber.Int64()}
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			result.Status = basecommon.SweepStatusFailed
+		}
+		if receipt.EffectiveGasPrice != nil {
+			fee := new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), receipt.EffectiveGasPrice)
+			gas, err := utils.ScaleToDecimal(fee.String(), nativeDecimals, 36, 18)
+			if err != nil {
+				return nil, fmt.Errorf("归集交易 %s gas 费用换算失败: %w", s.TxHash, err)
+			}
+			result.Gas = gas
+		}
+		if err := store.CompleteSweep(ctx, t.db, result); err != nil {
+			return nil, err
+		}
+		if result.Status == basecommon.SweepStatusSuccess {
+			logger.Info("归集交易 %s 已确认：%s %s %s -> %s", s.TxHash, s.Amount, s.Coin, s.From, s.To)
+		} else {
+			logger.Error("归集交易 %s 执行失败，区块 %d", s.TxHash, result.BlockNumber)
+		}
+	}
+	return busy, nil
+}
+
+// isDropped 未打包的交易 nonce 仍未被发送方使用且不在交易池中时视为已丢弃
+func (t *SweepTask) isDropped(ctx context.Context, s store.SweepTransaction) (bool, error) {
+	nonce, err := t.chain.PendingNonceAt(ctx, common.HexToAddress(s.From))
+	if err != nil {
+		return false, fmt.Errorf("查询 %s pending nonce 失败: %w", s.From, err)
+	}
+	return nonce <= s.Nonce, nil
+}
+
+// sweepAddresses 检查下一批充值地址并发起归集或 gas 补充，每个地址每轮最多发送一笔交易
+func (t *SweepTask) sweepAddresses(ctx context.Context, busy map[string]struct{}) error {
+	coins, err := t.sweepCoins(ctx)
+	if err != nil {
+		return err
+	}
+	if len(coins) == 0 {
+		return nil
+	}
+
+	addresses, err := store.ListDepositAddresses(ctx, t.db, t.cursor, t.batchSize())
+	if err != nil {
+		return err
+	}
+	if len(addresses) < t.batchSize() {
+		t.cursor = 0
+	} else {
+		t.cursor = addresses[len(addresses)-1].ID
+	}
+	if len(addresses) == 0 {
+		return nil
+	}
+
+	tipCap, feeCap, err := eth.SuggestFees(ctx, t.chain)
+	if err != nil {
+		return err
+	}
+	if maxFee := eth.MaxFeePerGas(t.config.Sweep.MaxFeePerGasGwei); maxFee != nil && feeCap.Cmp(maxFee) > 0 {
+		logger.Info("当前 maxFeePerGas %s 超过上限 %s，暂停归集", feeCap, maxFee)
+		return nil
+	}
+	t.gasNonce = 0
+
+	for _, a := range addresses {
+		if _, ok := busy[strings.ToLower(a.Address)]; ok {
+			continue
+		}
+		key, err := t.xprv.DerivePrivateKey(a.DerivationIndex)
+		if err != nil {
+			return fmt.Errorf("派生充值地址 %s 私钥失败: %w", a.Address, err)
+		}
+		if from := crypto.PubkeyToAddress(key.PublicKey); !strings.EqualFold(from.Hex(), a.Address) {
+			logger.Error("充值地址 %s 与派生序号 %d 的私钥不匹配，请检查 Sweep.XPrvFile", a.Address, a.DerivationIndex)
+			continue
+		}
+		if err := t.sweepAddress(ctx, key, coins, tipCap, feeCap); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sweepCoins 启用中且配置了归集阈值的币种，ERC20 在前，原生 ETH 最后归集，保证代币归集时地址上保留手续费
+func (t *SweepTask) sweepCoins(ctx context.Context) ([]sweepCoin, error) {
+	enabled, err := store.ListEnabledCoins(ctx, t.db)
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens []sweepCoin
+	var native *sweepCoin
+	for _, c := range enabled {
+		threshold, ok := t.threshold(c.Coin)
+		if !ok {
+			continue
+		}
+		coin := sweepCoin{coin: c.Coin, decimals: nativeDecimals}
+		if c.CoinAddress != "" {
+			if !common.IsHexAddress(c.CoinAddress) {
+				logger.Error("币种 %s 合约地址 %q 格式错误，跳过归集", c.Coin, c.CoinAddress)
+				continue
+			}
+			token := common.HexToAddress(c.CoinAddress)
+			coin.token = &token
+			if coin.decimals, err = t.tokens.Decimals(ctx, token); err != nil {
+				return nil, fmt.Errorf("查询代币 %s 精度失败: %w", c.Coin, err)
+			}
+		}
+		if coin.threshold, err = utils.ParseUnits(threshold, coin.decimals); err != nil {
+			return nil, fmt.Errorf("币种 %s 归集阈值无效: %w", c.Coin, err)
+		}
+		if coin.token == nil {
+			native = &coin
+		} else {
+			tokens = append(tokens, coin)
+		}
+	}
+	if native != nil {
+		tokens = append(tokens, *native)
+	}
+	return tokens, nil
+}
+
+// threshold 按币种查找归集阈值，不区分大小写
+func (t *SweepTask) threshold(coin string) (string, bool) {
+	for symbol, value := range t.config.Sweep.Thresholds {
+		if strings.EqualFold(symbol, coin) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// sweepAddress 检查单个充值地址：第一个余额达到阈值的币种发起归集，ETH 手续费不足时改为补充 gas
+func (t *SweepTask) sweepAddress(ctx context.Context, key *ecdsa.PrivateKey, coins []sweepCoin, tipCap, feeCap *big.Int) error {
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	ethBalance, err := t.chain.BalanceAt(ctx, from, nil)
+	if err != nil {
+		return fmt.Errorf("查询 %s ETH 余额失败: %w", from.Hex(), err)
+	}
+
+	for _, c := range coins {
+		if c.token == nil {
+			if ethBalance.Cmp(c.threshold) < 0 {
+				continue
+			}
+			fee := new(big.Int).Mul(new(big.Int).SetUint64(params.TxGas), feeCap)
+			value := new(big.Int).Sub(ethBalance, fee)
+			if value.Sign() <= 0 {
+				continue
+			}
+			return t.sendSweep(ctx, key, c, &t.hotWallet, value, nil, params.TxGas, value, tipCap, feeCap)
+		}
+
+		balance, err := t.tokens.BalanceOf(ctx, *c.token, from)
+		if err != nil {
+			return fmt.Errorf("查询 %s %s 余额失败: %w", from.Hex(), c.coin, err)
+		}
+		if balance.Cmp(c.threshold) < 0 {
+			continue
+		}
+		data := eth.ERC20TransferData(t.hotWallet, balance)
+		gas, err := t.chain.EstimateGas(ctx, ethereum.CallMsg{From: from, To: c.token, Data: data})
+		if err != nil {
+			return fmt.Errorf("估算 %s %s 归集 gas 失败: %w", from.Hex(), c.coin, err)
+		}
+		gas = uint64(float64(gas) * t.gasMultiplier())
+
+		fee := new(big.Int).Mul(new(big.Int).SetUint64(gas), feeCap)
+		if ethBalance.Cmp(fee) < 0 {
+			return t.topUpGas(ctx, from, new(big.Int).Sub(fee, ethBalance), tipCap, feeCap)
+		}
+		return t.sendSweep(ctx, key, c, c.token, new(big.Int), data, gas, balance, tipCap, feeCap)
+	}
+	return nil
+}
+
+// sendSweep 从充值地址向热钱包发送归集交易，amount 为归集金额（最小单位）
+func (t *SweepTask) sendSweep(ctx context.Context, key *ecdsa.PrivateKey, c sweepCoin, to *common.Address,
+	value *big.Int, data []byte, gas uint64, amount, tipCap, feeCap *big.Int) error {
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	nonce, err := t.chain.PendingNonceAt(ctx, from)
+	if err != nil {
+		return fmt.Errorf("查询 %s pending nonce 失败: %w", from.Hex(), err)
+	}
+	decimalAmount, err := utils.ScaleToDecimal(amount.String(), c.decimals, 36, 18)
+	if err != nil {
+		return fmt.Errorf("%s 归集金额换算失败: %w", c.coin, err)
+	}
+
+	record := store.SweepTransaction{
+		ChainID:        t.chainID,
+		Kind:           basecommon.SweepKindSweep,
+		Coin:           c.coin,
+		Amount:         decimalAmount,
+		From:           from.Hex(),
+		To:             t.hotWallet.Hex(),
+		DepositAddress: from.Hex(),
+	}
+	if c.token != nil {
+		record.CoinAddress = c.token.Hex()
+	}
+	inner := &types.DynamicFeeTx{Nonce: nonce, GasTipCap: tipCap, GasFeeCap: feeCap, Gas: gas, To: to, Value: value, Data: data}
+	return t.send(ctx, key, inner, record)
+}
+
+// topUpGas 从 gas 钱包向充值地址补充 ETH，补充确认前该地址不会发起归集
+func (t *SweepTask) topUpGas(ctx context.Context, depositAddress common.Address, amount, tipCap, feeCap *big.Int) error {
+	nonce, err := t.chain.PendingNonceAt(ctx, t.gasWallet)
+	if err != nil {
+		return fmt.Errorf("查询 gas 钱包 pending nonce 失败: %w", err)
+	}
+	// 同一轮内连续补充时节点可能尚未反映刚广播的交易
+	nonce = max(nonce, t.gasNonce)
+	decimalAmount, err := utils.ScaleToDecimal(amount.String(), nativeDecimals, 36, 18)
+	if err != nil {
+		return fmt.Errorf("gas 补充金额换算失败: %w", err)
+	}
+
+	record := store.SweepTransaction{
+		ChainID:        t.chainID,
+		Kind:           basecommon.SweepKindGasTopUp,
+		Coin:           "ETH",
+		Amount:         decimalAmount,
+		From:           t.gasWallet.Hex(),
+		To:             depositAddress.Hex(),
+		DepositAddress: depositAddress.Hex(),
+	}
+	inner := &types.DynamicFeeTx{Nonce: nonce, GasTipCap: tipCap, GasFeeCap: feeCap, Gas: params.TxGas, To: &depositAddress, Value: amount}
+	if err := t.send(ctx, t.gasKey, inner, record); err != nil {
+		return err
+	}
+	t.gasNonce = nonce + 1
+	return nil
+}
+
+// send 签名交易，记录到 sweep_transactions 后广播；节点明确拒绝时记录标记为失败，结果未知时保持待确认
+func (t *SweepTask) send(ctx context.Context, key *ecdsa.PrivateKey, inner *types.DynamicFeeTx, record store.SweepTransaction) error {
+	inner.ChainID = t.signer.ChainID()
+	tx, err := types.SignNewTx(key, t.signer, inner)
+	if err != nil {
+		return fmt.Errorf("签名交易失败: %w", err)
+	}
+	record.TxHash = tx.Hash().Hex()
+	record.Nonce = tx.Nonce()
+
+	id, err := store.InsertSweep(ctx, t.db, record)
+	if err != nil {
+		return err
+	}
+	if err := t.chain.SendTransaction(ctx, tx); err != nil {
+		if !eth.IsTxRejected(err) {
+			// 超时、连接中断等情况下交易可能已经发出，保持待确认，由 confirmSweeps 按回执或 nonce 判断结果，
+			// 避免同一地址重复归集或重复补充 gas
+			return fmt.Errorf("广播归集交易 %s 结果未知，等待确认: %w", record.TxHash, err)
+		}
+		logger.Error("节点拒绝归集交易 %s: %v", record.TxHash, err)
+		if err := store.CompleteSweep(ctx, t.db, store.SweepResult{ID: id, Status: basecommon.SweepStatusFailed}); err != nil {
+			return err
+		}
+		return fmt.Errorf("广播归集交易 %s 失败: %w", record.TxHash, err)
+	}
+
+	if record.Kind == basecommon.SweepKindGasTopUp {
+		logger.Info("已向充值地址 %s 补充 gas %s ETH，交易 %s", record.To, record.Amount, record.TxHash)
+	} else {
+		logger.Info("已广播归集交易 %s：%s %s %s -> %s", record.TxHash, record.Amount, record.Coin, record.From, record.To)
+	}
+	return nil
+}
+
+func (t *SweepTask) gasMultiplier() float64 {
+	if t.config.Sweep.GasLimitMultiplier < 1 {
+		return defaultSweepGasMultiplier
+	}
+	return t.config.Sweep.GasLimitMultiplier
+}
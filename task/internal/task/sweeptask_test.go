@@ -0,0 +1,385 @@
+package task
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go_bullayer_v1/base/pkg/eth"
+	"go_bullayer_v1/base/pkg/hdwallet"
+	"go_bullayer_v1/task/internal/config"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+var (
+	sweepCoinCols    = []string{"coin", "coin_address"}
+	depositCols      = []string{"id", "address", "derivation_index"}
+	pendingSweepCols = []string{"id", "chain_id", "kind", "coin", "coin_address", "amount", "from_address", "to_address", "deposit_address", "tx_hash", "nonce"}
+)
+
+// fakeTokenChain 在模拟后端上模拟一个 ERC20 合约的 decimals() 和 balanceOf()
+type fakeTokenChain struct {
+	SweepChain
+	token    common.Address
+	decimals int64
+	balances map[common.Address]*big.Int
+}
+
+func (c *fakeTokenChain) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if call.To == nil || *call.To != c.token {
+		return c.SweepChain.CallContract(ctx, call, blockNumber)
+	}
+	switch {
+	case bytes.Equal(call.Data, eth.ERC20DecimalsSelector):
+		return common.LeftPadBytes(big.NewInt(c.decimals).Bytes(), 32), nil
+	case bytes.HasPrefix(call.Data, eth.ERC20BalanceOfSelector):
+		balance := c.balances[common.BytesToAddress(call.Data[4:])]
+		if balance == nil {
+			balance = new(big.Int)
+		}
+		return common.LeftPadBytes(balance.Bytes(), 32), nil
+	}
+	return nil, ethereum.NotFound
+}
+
+// sweepTestEnv 归集测试环境：充值地址为测试助记词派生的第 0 个地址
+type sweepTestEnv struct {
+	cfg       config.Config
+	deposit   common.Address
+	gasWallet common.Address
+	hotWallet common.Address
+}
+
+// newSweepTestEnv 写入扩展私钥和 gas 钱包 keystore，创建 gas 钱包持有 10 ETH 的模拟后端，depositBalance 为充值地址初始余额
+func newSweepTestEnv(t *testing.T, depositBalance *big.Int) (sweepTestEnv, *simulated.Backend) {
+	t.Helper()
+
+	xprv, err := hdwallet.XPrvFromMnemonic(testMnemonic, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := hdwallet.ParseXPrv(xprv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	depositKey, err := parsed.DerivePrivateKey(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gasKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("generate key failed: %v", err)
+	}
+	gasWallet := crypto.PubkeyToAddress(gasKey.PublicKey)
+	keyJSON, err := keystore.EncryptKey(&keystore.Key{Address: gasWallet, PrivateKey: gasKey},
+		"secret", keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatalf("encrypt key failed: %v", err)
+	}
+
+	dir := t.TempDir()
+	env := sweepTestEnv{
+		deposit:   crypto.PubkeyToAddress(depositKey.PublicKey),
+		gasWallet: gasWallet,
+		hotWallet: common.HexToAddress("0x00000000000000000000000000000000000000b0"),
+	}
+	env.cfg.Sweep.XPrvFile = writeFile(t, dir, "xprv", []byte(xprv+"\n"))
+	env.cfg.Sweep.GasKeystoreFile = writeFile(t, dir, "gas.json", keyJSON)
+	env.cfg.Sweep.GasKeystorePasswordFile = writeFile(t, dir, "password", []byte("secret\n"))
+	env.cfg.Sweep.HotWallet = env.hotWallet.Hex()
+	env.cfg.Sweep.Confirmations = 1
+
+	alloc := types.GenesisAlloc{gasWallet: {Balance: ether(10)}}
+	if depositBalance != nil {
+		alloc[env.deposit] = types.Account{Balance: depositBalance}
+	}
+	backend := simulated.NewBackend(alloc)
+	t.Cleanup(func() { backend.Close() })
+	return env, backend
+}
+
+func writeFile(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func ether(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), big.NewInt(1e18))
+}
+
+// expectSweepRound 期望一轮归集查询：启用币种和一个充值地址
+func expectSweepRound(mock sqlmock.Sqlmock, env sweepTestEnv, coin, coinAddress string) {
+	mock.ExpectQuery("SELECT coin, COALESCE\\(coin_address, ''\\) FROM coin_configs").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(sweepCoinCols).AddRow(coin, coinAddress))
+	mock.ExpectQuery("SELECT id, address, derivation_index FROM deposit_addresses").
+		WithArgs(int64(0), defaultSweepBatchSize).
+		WillReturnRows(sqlmock.NewRows(depositCols).AddRow(int64(1), env.deposit.Hex(), uint32(0)))
+}
+
+func TestSweepTask_SweepsETHToHotWallet(t *testing.T) {
+	env, backend := newSweepTestEnv(t, ether(1))
+	env.cfg.Sweep.Thresholds = map[string]string{"ETH": "0.5"}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	sweeper, err := NewSweepTask(ctx, env.cfg, db, backend.Client())
+	if err != nil {
+		t.Fatalf("NewSweepTask failed: %v", err)
+	}
+
+	var hash string
+	mock.ExpectQuery("SELECT id, chain_id, kind").
+		WithArgs(int64(1337), 0).
+		WillReturnRows(sqlmock.NewRows(pendingSweepCols))
+	expectSweepRound(mock, env, "ETH", "")
+	mock.ExpectExec("INSERT INTO sweep_transactions").
+		WithArgs(int64(1337), 1, "ETH", nil, sqlmock.AnyArg(), env.deposit.Hex(), env.hotWallet.Hex(), env.deposit.Hex(),
+			captureHash{&hash}, uint64(0), 0).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	if err := sweeper.Execute(ctx); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	backend.Commit()
+
+	// 下一轮确认归集交易，充值地址已无可归集余额
+	mock.ExpectQuery("SELECT id, chain_id, kind").
+		WithArgs(int64(1337), 0).
+		WillReturnRows(sqlmock.NewRows(pendingSweepCols).
+			AddRow(int64(1), int64(1337), 1, "ETH", "", "0.99", env.deposit.Hex(), env.hotWallet.Hex(), env.deposit.Hex(), hash, uint64(0)))
+	mock.ExpectExec("UPDATE sweep_transactions SET status").
+		WithArgs(1, int64(1), sqlmock.AnyArg(), int64(1), 0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectSweepRound(mock, env, "ETH", "")
+	if err := sweeper.Execute(ctx); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+
+	hot, err := backend.Client().BalanceAt(ctx, env.hotWallet, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hot.Cmp(ether(1)) >= 0 || hot.Cmp(new(big.Int).Div(ether(1), big.NewInt(2))) < 0 {
+		t.Fatalf("hot wallet balance = %s, want most of 1 ETH", hot)
+	}
+}
+
+func TestSweepTask_TopsUpGasBeforeTokenSweep(t *testing.T) {
+	env, backend := newSweepTestEnv(t, nil)
+	env.cfg.Sweep.Thresholds = map[string]string{"usdt": "50"}
+	token := common.HexToAddress("0x00000000000000000000000000000000000000c0")
+	chain := &fakeTokenChain{
+		SweepChain: backend.Client(),
+		token:      token,
+		decimals:   6,
+		balances:   map[common.Address]*big.Int{env.deposit: big.NewInt(100_000_000)},
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	sweeper, err := NewSweepTask(ctx, env.cfg, db, chain)
+	if err != nil {
+		t.Fatalf("NewSweepTask failed: %v", err)
+	}
+
+	// 第一轮：充值地址没有 ETH，由 gas 钱包补充
+	var topUpHash string
+	mock.ExpectQuery("SELECT id, chain_id, kind").
+		WithArgs(int64(1337), 0).
+		WillReturnRows(sqlmock.NewRows(pendingSweepCols))
+	expectSweepRound(mock, env, "USDT", token.Hex())
+	mock.ExpectExec("INSERT INTO sweep_transactions").
+		WithArgs(int64(1337), 2, "ETH", nil, sqlmock.AnyArg(), env.gasWallet.Hex(), env.deposit.Hex(), env.deposit.Hex(),
+			captureHash{&topUpHash}, uint64(0), 0).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	if err := sweeper.Execute(ctx); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	backend.Commit()
+
+	gas, err := backend.Client().BalanceAt(ctx, env.deposit, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gas.Sign() == 0 {
+		t.Fatal("deposit address was not topped up")
+	}
+
+	// 第二轮：补充确认后从充值地址归集代币
+	var sweepHash string
+	mock.ExpectQuery("SELECT id, chain_id, kind").
+		WithArgs(int64(1337), 0).
+		WillReturnRows(sqlmock.NewRows(pendingSweepCols).
+			AddRow(int64(1), int64(1337), 2, "ETH", "", "0.0001", env.gasWallet.Hex(), env.deposit.Hex(), env.deposit.Hex(), topUpHash, uint64(0)))
+	mock.ExpectExec("UPDATE sweep_transactions SET status").
+		WithArgs(1, int64(1), sqlmock.AnyArg(), int64(1), 0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectSweepRound(mock, env, "USDT", token.Hex())
+	mock.ExpectExec("INSERT INTO sweep_transactions").
+		WithArgs(int64(1337), 1, "USDT", token.Hex(), "100", env.deposit.Hex(), env.hotWallet.Hex(), env.deposit.Hex(),
+			captureHash{&sweepHash}, uint64(0), 0).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	if err := sweeper.Execute(ctx); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	backend.Commit()
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+
+	receipt, err := backend.Client().TransactionReceipt(ctx, common.HexToHash(sweepHash))
+	if err != nil {
+		t.Fatalf("sweep transaction not mined: %v", err)
+	}
+	tx, _, err := backend.Client().TransactionByHash(ctx, receipt.TxHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *tx.To() != token || !bytes.HasPrefix(tx.Data(), eth.ERC20TransferSelector) ||
+		common.BytesToAddress(tx.Data()[4:36]) != env.hotWallet {
+		t.Fatalf("unexpected sweep transaction to %s data %x", tx.To(), tx.Data())
+	}
+}
+
+// flakySweepChain 广播时返回 err；sent 为 true 时交易仍会发出，模拟超时等结果未知的错误
+type flakySweepChain struct {
+	SweepChain
+	err  error
+	sent bool
+}
+
+func (c *flakySweepChain) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if c.sent {
+		if err := c.SweepChain.SendTransaction(ctx, tx); err != nil {
+			return err
+		}
+	}
+	return c.err
+}
+
+func TestSweepTask_BroadcastErrors(t *testing.T) {
+	t.Run("unknown result stays pending", func(t *testing.T) {
+		env, backend := newSweepTestEnv(t, ether(1))
+		env.cfg.Sweep.Thresholds = map[string]string{"ETH": "0.5"}
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+
+		ctx := context.Background()
+		chain := &flakySweepChain{SweepChain: backend.Client(), err: context.DeadlineExceeded, sent: true}
+		sweeper, err := NewSweepTask(ctx, env.cfg, db, chain)
+		if err != nil {
+			t.Fatalf("NewSweepTask failed: %v", err)
+		}
+
+		// 广播超时但交易已发出：不标记失败，地址保持占用
+		var hash string
+		mock.ExpectQuery("SELECT id, chain_id, kind").
+			WithArgs(int64(1337), 0).
+			WillReturnRows(sqlmock.NewRows(pendingSweepCols))
+		expectSweepRound(mock, env, "ETH", "")
+		mock.ExpectExec("INSERT INTO sweep_transactions").
+			WithArgs(int64(1337), 1, "ETH", nil, sqlmock.AnyArg(), env.deposit.Hex(), env.hotWallet.Hex(), env.deposit.Hex(),
+				captureHash{&hash}, uint64(0), 0).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		if err := sweeper.Execute(ctx); err == nil {
+			t.Fatal("expected broadcast error")
+		}
+		backend.Commit()
+
+		// 下一轮按回执确认，不会重复归集
+		chain.err = nil
+		mock.ExpectQuery("SELECT id, chain_id, kind").
+			WithArgs(int64(1337), 0).
+			WillReturnRows(sqlmock.NewRows(pendingSweepCols).
+				AddRow(int64(1), int64(1337), 1, "ETH", "", "0.99", env.deposit.Hex(), env.hotWallet.Hex(), env.deposit.Hex(), hash, uint64(0)))
+		mock.ExpectExec("UPDATE sweep_transactions SET status").
+			WithArgs(1, int64(1), sqlmock.AnyArg(), int64(1), 0).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		expectSweepRound(mock, env, "ETH", "")
+		if err := sweeper.Execute(ctx); err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("rejected marks failed", func(t *testing.T) {
+		env, backend := newSweepTestEnv(t, ether(1))
+		env.cfg.Sweep.Thresholds = map[string]string{"ETH": "0.5"}
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+
+		ctx := context.Background()
+		chain := &flakySweepChain{SweepChain: backend.Client(), err: errors.New("insufficient funds for gas * price + value")}
+		sweeper, err := NewSweepTask(ctx, env.cfg, db, chain)
+		if err != nil {
+			t.Fatalf("NewSweepTask failed: %v", err)
+		}
+
+		mock.ExpectQuery("SELECT id, chain_id, kind").
+			WithArgs(int64(1337), 0).
+			WillReturnRows(sqlmock.NewRows(pendingSweepCols))
+		expectSweepRound(mock, env, "ETH", "")
+		mock.ExpectExec("INSERT INTO sweep_transactions").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("UPDATE sweep_transactions SET status").
+			WithArgs(2, nil, "0", int64(1), 0).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		if err := sweeper.Execute(ctx); err == nil {
+			t.Fatal("expected broadcast error")
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// captureHash 匹配交易哈希参数并记录其值
+type captureHash struct{ value *string }
+
+func (c captureHash) Match(v driver.Value) bool {
+	s, ok := v.(string)
+	if ok {
+		*c.value = s
+	}
+	return ok
+}
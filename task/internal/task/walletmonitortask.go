@@ -0,0 +1,264 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	basecommon "go_bullayer_v1/base/pkg/common"
+	"go_bullayer_v1/base/pkg/eth"
+	"go_bullayer_v1/base/pkg/logger"
+	"go_bullayer_v1/base/pkg/utils"
+	"go_bullayer_v1/task/internal/config"
+	"go_bullayer_v1/task/internal/notify"
+	"go_bullayer_v1/task/internal/store"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// WalletMonitorChain 余额监控使用的节点接口，*ethclient.Client 满足该接口
+type WalletMonitorChain interface {
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+}
+
+// walletThreshold 解析后的币种阈值
+type walletThreshold struct {
+	coin    string
+	floor   *big.Rat
+	ceiling *big.Rat // 为 nil 表示不限制
+}
+
+// WalletMonitorTask 热钱包余额监控任务。
+// 每轮按币种比较热钱包链上余额与待处理提现合计加下限、以及上限，写入 wallet_balance_snapshots；
+// 状态变为低于下限、高于上限或恢复正常时通过 Notifier 发送告警，状态不变时不重复告警。
+type WalletMonitorTask struct {
+	db         *sql.DB
+	chain      WalletMonitorChain
+	tokens     *eth.TokenReader
+	wallet     common.Address
+	thresholds []walletThreshold
+	notifier   notify.Notifier
+	states     map[string]int // 币种 -> 最近一次成功告警的状态
+}
+
+// NewWalletMonitorTask 创建余额监控任务，notifier 为 nil 时告警只写日志
+func NewWalletMonitorTask(cfg config.Config, db *sql.DB, chain WalletMonitorChain, notifier notify.Notifier) (*WalletMonitorTask, error) {
+	if db == nil {
+		return nil, errors.New("余额监控任务需要配置数据库")
+	}
+	if chain == nil {
+		return nil, errors.New("未初始化链客户端，请检查 WalletMonitor.RPCURL 配置")
+	}
+	if !common.IsHexAddress(cfg.WalletMonitor.HotWallet) {
+		return nil, fmt.Errorf("WalletMonitor.HotWallet 地址 %q 格式错误", cfg.WalletMonitor.HotWallet)
+	}
+	if len(cfg.WalletMonitor.Thresholds) == 0 {
+		return nil, errors.New("未配置 WalletMonitor.Thresholds")
+	}
+
+	thresholds := make([]walletThreshold, 0, len(cfg.WalletMonitor.Thresholds))
+	seen := make(map[string]struct{}, len(cfg.WalletMonitor.Thresholds))
+	for _, c := range cfg.WalletMonitor.Thresholds {
+		coin := strings.ToUpper(strings.TrimSpace(c.Coin))
+		if coin == "" {
+			return nil, errors.New("余额阈值缺少币种")
+		}
+		if _, ok := seen[coin]; ok {
+			return nil, fmt.Errorf("币种 %s 的余额阈值重复配置", coin)
+		}
+		seen[coin] = struct{}{}
+
+		th := walletThreshold{coin: coin}
+		var err error
+		if th.floor, err = parseDecimal(c.Floor); err != nil {
+			return nil, fmt.Errorf("币种 %s 的 Floor 无效: %w", coin, err)
+		}
+		if th.floor == nil {
+			th.floor = new(big.Rat)
+		}
+		if th.ceiling, err = parseDecimal(c.Ceiling); err != nil {
+			return nil, fmt.Errorf("币种 %s 的 Ceiling 无效: %w", coin, err)
+		}
+		if th.ceiling != nil && th.ceiling.Cmp(th.floor) <= 0 {
+			return nil, fmt.Errorf("币种 %s 的 Ceiling 必须大于 Floor", coin)
+		}
+		thresholds = append(thresholds, th)
+	}
+
+	if notifier == nil {
+		notifier = notify.LogNotifier{}
+	}
+	return &WalletMonitorTask{
+		db:         db,
+		chain:      chain,
+		tokens:     eth.NewTokenReader(eth.CallMsgCaller(chain)),
+		wallet:     common.HexToAddress(cfg.WalletMonitor.HotWallet),
+		thresholds: thresholds,
+		notifier:   notifier,
+		states:     make(map[string]int),
+	}, nil
+}
+
+// Name 返回任务名称
+func (t *WalletMonitorTask) Name() string {
+	return "热钱包余额监控任务"
+}
+
+// Execute 检查热钱包各币种余额，记录快照并在状态变化时告警
+// ctx: 上下文
+// 返回错误信息，单个币种失败不影响其他币种
+func (t *WalletMonitorTask) Execute(ctx context.Context) error {
+	enabled, err := store.ListEnabledCoins(ctx, t.db)
+	if err != nil {
+		return err
+	}
+	coins := make(map[string]store.EnabledCoin, len(enabled))
+	for _, c := range enabled {
+		coins[strings.ToUpper(c.Coin)] = c
+	}
+	// 先于余额查询读取已打包的 nonce，期间新打包的提现最多被重复计入一次，不会漏计
+	minedNonce, err := t.chain.NonceAt(ctx, t.wallet, nil)
+	if err != nil {
+		return fmt.Errorf("查询热钱包 nonce 失败: %w", err)
+	}
+	pending, err := store.SumPendingWithdrawals(ctx, t.db, t.wallet.Hex(), minedNonce)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, th := range t.thresholds {
+		c, ok := coins[th.coin]
+		if !ok {
+			logger.Error("币种 %s 未启用，跳过余额监控", th.coin)
+			continue
+		}
+		if err := t.check(ctx, c, th, pending[c.Coin]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// check 检查单个币种，pendingAmount 为待处理提现合计，没有时为空
+func (t *WalletMonitorTask) check(ctx context.Context, c store.EnabledCoin, th walletThreshold, pendingAmount string) error {
+	balance, err := t.balance(ctx, c)
+	if err != nil {
+		return fmt.Errorf("查询热钱包 %s 余额失败: %w", c.Coin, err)
+	}
+	pendingRat := new(big.Rat)
+	if pendingAmount != "" {
+		if _, ok := pendingRat.SetString(pendingAmount); !ok {
+			return fmt.Errorf("币种 %s 待处理提现合计无效: %s", c.Coin, pendingAmount)
+		}
+	}
+
+	required := new(big.Rat).Add(pendingRat, th.floor)
+	status, amount := basecommon.WalletBalanceNormal, new(big.Rat)
+	switch {
+	case balance.Cmp(required) < 0:
+		status, amount = basecommon.WalletBalanceBelowFloor, amount.Sub(required, balance)
+	case th.ceiling != nil && balance.Cmp(th.ceiling) > 0:
+		status, amount = basecommon.WalletBalanceAboveCeiling, amount.Sub(balance, th.ceiling)
+	}
+
+	snapshot := store.BalanceSnapshot{
+		Coin:               c.Coin,
+		Wallet:             t.wallet.Hex(),
+		Balance:            formatDecimal(balance),
+		PendingWithdrawals: formatDecimal(pendingRat),
+		Floor:              formatDecimal(th.floor),
+		Status:             status,
+	}
+	if th.ceiling != nil {
+		snapshot.Ceiling = formatDecimal(th.ceiling)
+	}
+	if err := store.InsertBalanceSnapshot(ctx, t.db, snapshot); err != nil {
+		return err
+	}
+
+	if status == t.states[th.coin] {
+		return nil
+	}
+	alert := notify.Alert{
+		Coin:               c.Coin,
+		Wallet:             snapshot.Wallet,
+		Balance:            snapshot.Balance,
+		PendingWithdrawals: snapshot.PendingWithdrawals,
+		Floor:              snapshot.Floor,
+		Ceiling:            snapshot.Ceiling,
+		Time:               time.Now(),
+	}
+	switch status {
+	case basecommon.WalletBalanceBelowFloor:
+		alert.Kind, alert.Amount = notify.KindBelowFloor, formatDecimal(amount)
+		alert.Message = fmt.Sprintf("热钱包 %s 余额 %s 低于待处理提现 %s 与下限 %s 之和，需从冷钱包补充 %s",
+			c.Coin, alert.Balance, alert.PendingWithdrawals, alert.Floor, alert.Amount)
+	case basecommon.WalletBalanceAboveCeiling:
+		alert.Kind, alert.Amount = notify.KindAboveCeiling, formatDecimal(amount)
+		alert.Message = fmt.Sprintf("热钱包 %s 余额 %s 超过上限 %s，可转出 %s 至冷钱包",
+			c.Coin, alert.Balance, alert.Ceiling, alert.Amount)
+	default:
+		alert.Kind = notify.KindRecovered
+		alert.Message = fmt.Sprintf("热钱包 %s 余额 %s 已恢复正常", c.Coin, alert.Balance)
+	}
+	// 发送失败时不更新状态，下一轮重新告警
+	if err := t.notifier.Notify(ctx, alert); err != nil {
+		return fmt.Errorf("发送 %s 余额告警失败: %w", c.Coin, err)
+	}
+	t.states[th.coin] = status
+	return nil
+}
+
+// balance 查询热钱包链上余额并按精度换算为十进制
+func (t *WalletMonitorTask) balance(ctx context.Context, c store.EnabledCoin) (*big.Rat, error) {
+	var raw *big.Int
+	var err error
+	decimals := nativeDecimals
+	if c.CoinAddress == "" {
+		if raw, err = t.chain.BalanceAt(ctx, t.wallet, nil); err != nil {
+			return nil, err
+		}
+	} else {
+		if !common.IsHexAddress(c.CoinAddress) {
+			return nil, fmt.Errorf("合约地址 %q 格式错误", c.CoinAddress)
+		}
+		token := common.HexToAddress(c.CoinAddress)
+		if decimals, err = t.tokens.Decimals(ctx, token); err != nil {
+			return nil, err
+		}
+		if raw, err = t.tokens.BalanceOf(ctx, token, t.wallet); err != nil {
+			return nil, err
+		}
+	}
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	return new(big.Rat).SetFrac(raw, unit), nil
+}
+
+// parseDecimal 解析非负十进制金额，为空返回 nil
+func parseDecimal(value string) (*big.Rat, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	r, ok := new(big.Rat).SetString(value)
+	if !ok || r.Sign() < 0 {
+		return nil, fmt.Errorf("金额格式错误: %s", value)
+	}
+	return r, nil
+}
+
+// formatDecimal 按 DECIMAL(36,18) 的 18 位小数输出非负金额，超出部分截断，去掉末尾的 0
+func formatDecimal(r *big.Rat) string {
+	scaled := new(big.Int).Mul(r.Num(), big.NewInt(1e18))
+	scaled.Quo(scaled, r.Denom())
+	value, _ := utils.FormatUnits(scaled.String(), 18) // 整数输入不会出错
+	return value
+}
@@ -0,0 +1,190 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"go_bullayer_v1/task/internal/config"
+	"go_bullayer_v1/task/internal/notify"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// fakeWalletChain 返回固定的 ETH 余额和已打包 nonce，并模拟一个 ERC20 合约
+type fakeWalletChain struct {
+	fakeTokenChain
+	ethBalance *big.Int
+	nonce      uint64
+}
+
+func (c *fakeWalletChain) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	return c.ethBalance, nil
+}
+
+func (c *fakeWalletChain) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	return c.nonce, nil
+}
+
+// recordingNotifier 记录收到的告警，err 不为空时返回发送失败
+type recordingNotifier struct {
+	alerts []notify.Alert
+	err    error
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, alert notify.Alert) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.alerts = append(n.alerts, alert)
+	return nil
+}
+
+var (
+	pendingSumCols = []string{"coin", "amount"}
+	testHotWallet  = common.HexToAddress("0x00000000000000000000000000000000000000b0")
+	testToken      = common.HexToAddress("0x00000000000000000000000000000000000000c0")
+)
+
+// expectMonitorRound 期望一轮监控：热钱包已打包 nonce 为 3，ETH 有 2.5 待处理提现，按 ETH、USDT 顺序写入快照
+func expectMonitorRound(mock sqlmock.Sqlmock, ethBalance string, ethStatus int, usdtStatus int) {
+	mock.ExpectQuery("SELECT coin, COALESCE\\(coin_address, ''\\) FROM coin_configs").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(sweepCoinCols).AddRow("ETH", "").AddRow("USDT", testToken.Hex()))
+	mock.ExpectQuery("SELECT coin, SUM\\(amount\\) FROM transactions").
+		WithArgs(2, 0, 5, 4, testHotWallet.Hex(), uint64(3)).
+		WillReturnRows(sqlmock.NewRows(pendingSumCols).AddRow("ETH", "2.500000000000000000"))
+	mock.ExpectExec("INSERT INTO wallet_balance_snapshots").
+		WithArgs("ETH", testHotWallet.Hex(), ethBalance, "2.5", "1", nil, ethStatus).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO wallet_balance_snapshots").
+		WithArgs("USDT", testHotWallet.Hex(), "5000", "0", "0", "1000", usdtStatus).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+}
+
+func newTestWalletMonitor(t *testing.T, chain WalletMonitorChain, notifier notify.Notifier) (*WalletMonitorTask, sqlmock.Sqlmock) {
+	t.Helper()
+
+	var cfg config.Config
+	cfg.WalletMonitor.HotWallet = testHotWallet.Hex()
+	cfg.WalletMonitor.Thresholds = []config.WalletThreshold{
+		{Coin: "eth", Floor: "1"},
+		{Coin: "USDT", Ceiling: "1000"},
+	}
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	monitor, err := NewWalletMonitorTask(cfg, db, chain, notifier)
+	if err != nil {
+		t.Fatalf("NewWalletMonitorTask failed: %v", err)
+	}
+	return monitor, mock
+}
+
+func newFakeWalletChain(ethBalance *big.Int) *fakeWalletChain {
+	return &fakeWalletChain{
+		fakeTokenChain: fakeTokenChain{
+			token:    testToken,
+			decimals: 6,
+			balances: map[common.Address]*big.Int{testHotWallet: big.NewInt(5_000_000_000)},
+		},
+		ethBalance: ethBalance,
+		nonce:      3,
+	}
+}
+
+func TestWalletMonitorTask_AlertsOnStatusChange(t *testing.T) {
+	chain := newFakeWalletChain(ether(3))
+	notifier := &recordingNotifier{}
+	monitor, mock := newTestWalletMonitor(t, chain, notifier)
+	ctx := context.Background()
+
+	// 3 ETH 低于待处理提现 2.5 与下限 1 之和，USDT 5000 超过上限 1000
+	expectMonitorRound(mock, "3", 1, 2)
+	if err := monitor.Execute(ctx); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if len(notifier.alerts) != 2 {
+		t.Fatalf("alerts = %+v, want 2", notifier.alerts)
+	}
+	if a := notifier.alerts[0]; a.Kind != notify.KindBelowFloor || a.Coin != "ETH" || a.Amount != "0.5" {
+		t.Fatalf("unexpected ETH alert %+v", a)
+	}
+	if a := notifier.alerts[1]; a.Kind != notify.KindAboveCeiling || a.Coin != "USDT" || a.Amount != "4000" {
+		t.Fatalf("unexpected USDT alert %+v", a)
+	}
+
+	// 状态不变时只记录快照，不重复告警
+	expectMonitorRound(mock, "3", 1, 2)
+	if err := monitor.Execute(ctx); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if len(notifier.alerts) != 2 {
+		t.Fatalf("alerts = %d, want no new alerts", len(notifier.alerts))
+	}
+
+	// 补充到 3.75 ETH 后发送恢复告警
+	chain.ethBalance = new(big.Int).Add(ether(3), big.NewInt(75e16))
+	expectMonitorRound(mock, "3.75", 0, 2)
+	if err := monitor.Execute(ctx); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if len(notifier.alerts) != 3 || notifier.alerts[2].Kind != notify.KindRecovered || notifier.alerts[2].Coin != "ETH" {
+		t.Fatalf("unexpected alerts %+v", notifier.alerts)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWalletMonitorTask_RetriesFailedAlert(t *testing.T) {
+	chain := newFakeWalletChain(ether(3))
+	notifier := &recordingNotifier{err: errors.New("webhook down")}
+	monitor, mock := newTestWalletMonitor(t, chain, notifier)
+	ctx := context.Background()
+
+	expectMonitorRound(mock, "3", 1, 2)
+	if err := monitor.Execute(ctx); err == nil {
+		t.Fatal("expected notify error")
+	}
+
+	notifier.err = nil
+	expectMonitorRound(mock, "3", 1, 2)
+	if err := monitor.Execute(ctx); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if len(notifier.alerts) != 2 {
+		t.Fatalf("alerts = %+v, want both alerts resent", notifier.alerts)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewWalletMonitorTask_RejectsInvalidThresholds(t *testing.T) {
+	cases := []config.WalletThreshold{
+		{Coin: "", Floor: "1"},
+		{Coin: "ETH", Floor: "-1"},
+		{Coin: "ETH", Floor: "abc"},
+		{Coin: "ETH", Floor: "10", Ceiling: "5"},
+	}
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for _, c := range cases {
+		var cfg config.Config
+		cfg.WalletMonitor.HotWallet = testHotWallet.Hex()
+		cfg.WalletMonitor.Thresholds = []config.WalletThreshold{c}
+		if _, err := NewWalletMonitorTask(cfg, db, newFakeWalletChain(ether(1)), nil); err == nil {
+			t.Fatalf("expected error for %+v", c)
+		}
+	}
+}